@@ -1,10 +1,25 @@
 package main
 
 import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/catalog"
+	"ccooler/backend/fsys"
+	"ccooler/backend/history"
+	"ccooler/backend/ipc"
 	"ccooler/backend/models"
+	"ccooler/backend/monitor"
+	"ccooler/backend/ops"
+	"ccooler/backend/protect"
+	"ccooler/backend/quarantine"
+	"ccooler/backend/report"
+	"ccooler/backend/scheduler"
 	"ccooler/backend/services"
+	"ccooler/backend/settings"
+	"ccooler/backend/trend"
+	"ccooler/backend/walker"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -23,48 +38,223 @@ import (
 // App struct
 type App struct {
 	ctx              context.Context
+	fs               fsys.FS
+	baseCatalog      *catalog.Catalog // 清理规则（不含用户设置）
+	catalog          *catalog.Catalog // 应用了用户设置的清理规则
 	cleanService     *services.CleanService
 	softwareService  *services.SoftwareService
 	wechatService    *services.WeChatService
 	adminService     *services.AdminService
 	largeFileService *services.LargeFileService
 	optimizeService  *services.OptimizeService
-
-	// HTTP服务器用于接收辅助程序结果和进度
+	quarantine       *quarantine.Store
+	ops              *ops.Registry        // 可取消的扫描和清理操作
+	sizeIndex        *walker.Index        // 持久化的目录大小索引
+	protect          *protect.List        // 用户设置的受保护路径
+	scheduler        *scheduler.Scheduler // 自动清理方案
+	monitor          *monitor.Monitor     // 剩余空间监控
+	history          *history.Store       // 扫描和清理记录
+	trend            *trend.Tracker       // 空间趋势和写满预测
+	settings         *settings.Store      // 用户设置
+
+	// 最近一次各项扫描的结果（ExportReport 使用）
+	lastScan      report.Input
+	lastScanMutex sync.Mutex
+
+	// HTTP服务器用于接收辅助程序结果和进度（请求经 ipc 签名校验）
 	httpServer       *http.Server
 	httpPort         string
+	ipcServer        *ipc.Server
 	elevatedResults  map[string]chan *ElevatedResult
-	elevatedProgress map[string]chan *ElevatedProgress
+	elevatedProgress map[string]chan *models.CleanProgress
+	elevatedStatus   map[string]chan *ipc.TaskStatus // 辅助程序的生命周期消息
+	elevatedCancel   map[string]bool                 // 已请求取消的辅助程序任务
 	resultsMutex     sync.Mutex
+
+	// 常驻的辅助程序会话（StartElevatedSession 启动，没有时单次启动辅助程序）
+	session      *elevatedSession
+	sessionMutex sync.Mutex
 }
 
 // ElevatedResult 提升权限执行结果
 type ElevatedResult struct {
-	Success      bool   `json:"success"`
-	Error        string `json:"error,omitempty"`
-	CleanedSize  int64  `json:"cleanedSize"`
-	CleanedCount int    `json:"cleanedCount"`
+	Success      bool          `json:"success"`
+	Error        string        `json:"error,omitempty"`       // 提示文字（当前语言）
+	ErrorDetail  *apperr.Error `json:"errorDetail,omitempty"` // 错误码和参数，前端据此处理
+	CleanedSize  int64         `json:"cleanedSize"`
+	CleanedCount int           `json:"cleanedCount"`
+
+	// 试运行时返回删除清单
+	Manifest *models.CleanManifest `json:"manifest,omitempty"`
+
+	// 隔离模式下的清理批次 ID（可用于 RestoreCleanRun 恢复）
+	RunID string `json:"runId,omitempty"`
+
+	// 清理报告（实际删除的文件、未能清理的文件及原因、各路径明细）
+	Report *models.CleanReport `json:"report,omitempty"`
+
+	// 操作被取消时为 "cancelled"，结果只包含取消前已完成的部分；拒绝 UAC 提示时为 "uac-declined"
+	Status string `json:"status,omitempty"`
+}
+
+// StatusUACDeclined 用户拒绝了 UAC 提示，辅助程序没有启动（ElevatedResult.Status）
+const StatusUACDeclined = "uac-declined"
+
+// ErrUACDeclined 用户拒绝了 UAC 提示
+var ErrUACDeclined = apperr.New(apperr.UACDeclined)
+
+// helperName 辅助程序文件名
+const helperName = "CCoolerElevated.exe"
+
+// failedResult 失败的结果
+func failedResult(err error) *ElevatedResult {
+	result := &ElevatedResult{}
+	result.setError(err)
+	return result
+}
+
+// setError 标记结果失败，记录错误码和当前语言的提示文字
+func (r *ElevatedResult) setError(err error) {
+	detail := apperr.From(err)
+	r.Success = false
+	r.Error = detail.Error()
+	r.ErrorDetail = detail
+}
+
+// err 失败的结果转换为错误（成功时为 nil）
+func (r *ElevatedResult) err() error {
+	switch {
+	case r.Success:
+		return nil
+	case r.ErrorDetail != nil:
+		return r.ErrorDetail
+	}
+	return errors.New(r.Error)
+}
+
+// newCleanResult 根据清理报告生成结果（清理大小和数量只统计实际删除的文件）
+func newCleanResult(report *models.CleanReport, runID string) *ElevatedResult {
+	return &ElevatedResult{
+		Success:      true,
+		CleanedSize:  report.FreedSize,
+		CleanedCount: report.RemovedCount,
+		RunID:        runID,
+		Report:       report,
+	}
 }
 
-// ElevatedProgress 提升权限执行进度
-type ElevatedProgress struct {
-	ProcessedPaths int    `json:"processedPaths"`
-	TotalPaths     int    `json:"totalPaths"`
-	CleanedSize    int64  `json:"cleanedSize"`
-	CleanedCount   int    `json:"cleanedCount"`
-	CurrentPath    string `json:"currentPath"`
+// mergeResult 把单个清理结果累加到汇总结果中
+func mergeResult(dst, src *ElevatedResult) {
+	if src == nil {
+		return
+	}
+	dst.CleanedSize += src.CleanedSize
+	dst.CleanedCount += src.CleanedCount
+	if src.Report == nil {
+		return
+	}
+	if dst.Report == nil {
+		dst.Report = services.NewCleanReport()
+	}
+	services.MergeCleanReport(dst.Report, src.Report)
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{
-		cleanService:     services.NewCleanService(),
-		softwareService:  services.NewSoftwareService(),
-		wechatService:    services.NewWeChatService(),
+	filesystem := fsys.OS()
+	cat := loadCatalog()
+	app := &App{
+		fs:               filesystem,
+		baseCatalog:      cat,
+		catalog:          cat,
+		cleanService:     services.NewCleanService(filesystem, cat),
+		softwareService:  services.NewSoftwareService(filesystem),
+		wechatService:    services.NewWeChatService(filesystem),
 		adminService:     services.NewAdminService(),
-		largeFileService: services.NewLargeFileService(),
-		optimizeService:  services.NewOptimizeService(),
+		largeFileService: services.NewLargeFileService(filesystem),
+		optimizeService:  services.NewOptimizeService(filesystem),
+		quarantine:       quarantine.NewStore(filesystem),
+		ops:              ops.NewRegistry(),
+		sizeIndex:        walker.OpenIndex(filesystem, walker.DefaultIndexPath()),
+		protect:          loadProtected(filesystem),
+		history:          history.New(filesystem, history.DefaultPath()),
+		trend:            trend.New(filesystem, trend.DefaultPath()),
+	}
+
+	// 以管理员身份运行时使用只有管理员可以写入的隔离区
+	if app.adminService.IsElevated() {
+		app.quarantine = quarantine.NewAdminStore(filesystem)
+	}
+
+	// 扫描时只重新遍历有变化的目录
+	app.cleanService.SetSizeIndex(app.sizeIndex)
+	app.largeFileService.SetSizeIndex(app.sizeIndex)
+	app.softwareService.SetSizeIndex(app.sizeIndex)
+	app.trend.SetSizeIndex(app.sizeIndex)
+
+	// 受保护路径在扫描时单独统计，清理和删除时跳过
+	app.cleanService.SetProtected(app.protect)
+	app.largeFileService.SetProtected(app.protect)
+
+	// 自动清理：需要管理员权限的项目只在程序以管理员身份运行时清理
+	app.scheduler = scheduler.New(filesystem, scheduler.DefaultPath(), app.cleanService, app.quarantine)
+	app.scheduler.SetSizeIndex(app.sizeIndex)
+	app.scheduler.SetElevated(app.adminService.IsElevated)
+	app.scheduler.SetHistory(app.history)
+
+	diskMonitor, err := monitor.Open(filesystem, monitor.DefaultPath(), app.cleanService.GetDiskInfo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Failed to load disk monitor settings: %v\n", err)
+	}
+	app.monitor = diskMonitor
+
+	// 用户设置（过滤条件、默认选中的清理项、大文件阈值）在启动时应用到各服务
+	store, err := settings.Open(filesystem, settings.DefaultPath(), cat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Failed to load settings: %v\n", err)
+	}
+	store.SetProtected(app.protect)
+	store.SetScheduler(app.scheduler)
+	app.settings = store
+	app.applySettings(store.Current())
+	return app
+}
+
+// applySettings 把用户设置应用到清理规则和大文件扫描（设置中的过滤条件无效时使用原有规则）
+func (a *App) applySettings(s settings.Settings) {
+	cat, err := s.Catalog(a.baseCatalog)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Failed to apply settings to clean rules: %v\n", err)
+		cat = a.baseCatalog
+	}
+	a.catalog = cat
+	a.cleanService.SetCatalog(cat)
+	a.largeFileService.SetThresholds(s.LargeFiles.MinSize, s.LargeFiles.Categories)
+	apperr.SetLocale(s.Locale)
+}
+
+// loadProtected 加载受保护路径（文件无效时不使用其中的规则，保存新规则后覆盖）
+func loadProtected(filesystem fsys.FS) *protect.List {
+	list, err := protect.Load(filesystem, protect.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Failed to load protected paths: %v\n", err)
+	}
+	return list
+}
+
+// loadCatalog 加载清理规则（程序目录下的规则文件优先，失败时使用内置规则）
+func loadCatalog() *catalog.Catalog {
+	exePath, err := os.Executable()
+	if err != nil {
+		return catalog.Builtin()
+	}
+
+	cat, err := catalog.Load(filepath.Dir(exePath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Failed to load clean rules, using builtin: %v\n", err)
+		return catalog.Builtin()
 	}
+	return cat
 }
 
 // startup is called when the app starts. The context is saved
@@ -72,10 +262,49 @@ func NewApp() *App {
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 	a.elevatedResults = make(map[string]chan *ElevatedResult)
-	a.elevatedProgress = make(map[string]chan *ElevatedProgress)
+	a.elevatedProgress = make(map[string]chan *models.CleanProgress)
+	a.elevatedStatus = make(map[string]chan *ipc.TaskStatus)
+	a.elevatedCancel = make(map[string]bool)
+	a.ipcServer = ipc.NewServer()
+
+	// 服务的扫描进度通过事件发送给前端
+	a.cleanService.SetProgressSink(a.emitProgress)
+	a.largeFileService.SetProgressSink(a.emitProgress)
+	a.softwareService.SetProgressSink(a.emitProgress)
 
 	// 启动HTTP服务器接收辅助程序结果和进度
 	a.startHTTPServer()
+
+	// 清除超过保留期的隔离文件
+	go a.quarantine.Purge()
+
+	// 程序运行期间按计划和剩余空间自动清理，每次运行后通知前端
+	a.scheduler.SetOnRun(func(run *scheduler.Run) {
+		runtime.EventsEmit(a.ctx, "schedule-run", run)
+	})
+	go a.scheduler.Start(ctx, func(err error) {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Scheduled clean check failed: %v\n", err)
+	})
+
+	// 后台采样剩余空间，跨过阈值或下降过快时通知前端
+	a.monitor.SetOnEvent(func(event monitor.Event) {
+		runtime.EventsEmit(a.ctx, "disk-monitor", event)
+	})
+	// 采样同时保存到空间趋势（每小时一个），每天在后台生成一次系统盘的目录快照
+	a.monitor.SetOnSample(func(sample monitor.Sample) {
+		if err := a.trend.Add(sample.Time, sample.Total, sample.Free); err != nil {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Failed to save disk trend: %v\n", err)
+		}
+	})
+	go a.monitor.Start(ctx)
+	go a.trend.Start(ctx, fsys.SystemDrive(), func(err error) {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Folder snapshot failed: %v\n", err)
+	})
+}
+
+// emitProgress 把服务的进度事件转发给前端
+func (a *App) emitProgress(event string, data any) {
+	runtime.EventsEmit(a.ctx, event, data)
 }
 
 func (a *App) startHTTPServer() error {
@@ -90,6 +319,10 @@ func (a *App) startHTTPServer() error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/elevated-result", a.handleElevatedResult)
 	mux.HandleFunc("/elevated-progress", a.handleElevatedProgress)
+	mux.HandleFunc("/elevated-cancel", a.handleElevatedCancel)
+	mux.HandleFunc("/elevated-status", a.handleElevatedStatus)
+	mux.HandleFunc("/elevated-session/next", a.handleSessionNext)
+	mux.HandleFunc("/elevated-session/heartbeat", a.handleSessionHeartbeat)
 
 	a.httpServer = &http.Server{Handler: mux}
 
@@ -100,29 +333,46 @@ func (a *App) startHTTPServer() error {
 	return nil
 }
 
+// verifyElevated 校验辅助程序的请求，拒绝未知任务、签名无效和重复的请求
+func (a *App) verifyElevated(w http.ResponseWriter, r *http.Request) (string, []byte, bool) {
+	taskID, body, err := a.ipcServer.Verify(r)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Rejected elevated request %s: %v\n", r.URL.Path, err)
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return "", nil, false
+	}
+	return taskID, body, true
+}
+
 func (a *App) handleElevatedResult(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	taskID, body, ok := a.verifyElevated(w, r)
+	if !ok {
+		return
+	}
 
 	var result ElevatedResult
-	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if result.ErrorDetail != nil {
+		result.Error = result.ErrorDetail.Error() // 按主程序的语言重新生成提示文字
+	}
 
-	// 发送结果到等待的通道
+	// 只发送给对应的任务，结果是任务的最后一个请求
 	a.resultsMutex.Lock()
-	for _, ch := range a.elevatedResults {
+	if ch, ok := a.elevatedResults[taskID]; ok {
 		select {
 		case ch <- &result:
-			// 发送成功
 		default:
-			// 通道已满，跳过
 		}
 	}
 	a.resultsMutex.Unlock()
+	a.ipcServer.Unregister(taskID)
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -132,21 +382,23 @@ func (a *App) handleElevatedProgress(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	taskID, body, ok := a.verifyElevated(w, r)
+	if !ok {
+		return
+	}
 
-	var progress ElevatedProgress
-	if err := json.NewDecoder(r.Body).Decode(&progress); err != nil {
+	var progress models.CleanProgress
+	if err := json.Unmarshal(body, &progress); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// 发送进度到等待的通道
+	// 发送进度到对应任务的通道（通道已满时丢弃）
 	a.resultsMutex.Lock()
-	for _, ch := range a.elevatedProgress {
+	if ch, ok := a.elevatedProgress[taskID]; ok {
 		select {
 		case ch <- &progress:
-			// 发送成功
 		default:
-			// 通道已满，跳过
 		}
 	}
 	a.resultsMutex.Unlock()
@@ -154,149 +406,628 @@ func (a *App) handleElevatedProgress(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// GetDiskInfo 获取磁盘信息
-func (a *App) GetDiskInfo() (*models.DiskInfo, error) {
-	return a.cleanService.GetDiskInfo()
+// handleElevatedStatus 辅助程序报告任务的生命周期（启动、已提升权限、心跳、完成）
+func (a *App) handleElevatedStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	taskID, body, ok := a.verifyElevated(w, r)
+	if !ok {
+		return
+	}
+
+	var status ipc.TaskStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a.resultsMutex.Lock()
+	if ch, ok := a.elevatedStatus[taskID]; ok {
+		select {
+		case ch <- &status:
+		default:
+		}
+	}
+	a.resultsMutex.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleElevatedCancel 辅助程序定期查询是否需要取消当前任务
+func (a *App) handleElevatedCancel(w http.ResponseWriter, r *http.Request) {
+	taskID, _, ok := a.verifyElevated(w, r)
+	if !ok {
+		return
+	}
+
+	a.resultsMutex.Lock()
+	cancel := a.elevatedCancel[taskID]
+	a.resultsMutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"cancel": cancel})
+}
+
+// elevatedTask 一个辅助程序任务的结果和进度通道
+type elevatedTask struct {
+	id       string
+	key      string // 任务密钥，辅助程序用来签名请求和校验任务文件
+	file     string // 任务文件
+	result   chan *ElevatedResult
+	progress chan *models.CleanProgress
+	status   chan *ipc.TaskStatus
+}
+
+// beginElevatedTask 登记辅助程序任务，任务结束后必须调用 endElevatedTask
+func (a *App) beginElevatedTask(name string) *elevatedTask {
+	task := &elevatedTask{
+		id:       ipc.NewTaskID(name),
+		result:   make(chan *ElevatedResult, 1),
+		progress: make(chan *models.CleanProgress, 10),
+		status:   make(chan *ipc.TaskStatus, 10),
+	}
+
+	a.resultsMutex.Lock()
+	a.elevatedResults[task.id] = task.result
+	a.elevatedProgress[task.id] = task.progress
+	a.elevatedStatus[task.id] = task.status
+	a.resultsMutex.Unlock()
+
+	task.key = a.ipcServer.Register(task.id)
+	return task
+}
+
+// launchElevated 写入任务文件并交给常驻辅助程序执行，没有可用的会话时启动提升的辅助程序。
+// 命令行只包含端口、任务 ID、任务密钥和任务文件位置，任务参数都在任务文件中。
+// 返回辅助程序的进程句柄（交给常驻辅助程序时为 0），由 waitElevated 关闭
+func (a *App) launchElevated(ctx context.Context, helperPath string, task *elevatedTask, spec *ipc.TaskFile) (windows.Handle, error) {
+	spec.TaskID = task.id
+	spec.Protected = a.protect.Path() // 辅助程序读取同一个受保护路径文件
+	spec.Settings = a.settings.Path() // 以及同一个设置文件（过滤条件）
+	file, err := ipc.WriteTaskFile(a.fs, ipc.TaskDir(), task.key, spec)
+	if err != nil {
+		return 0, err
+	}
+	task.file = file
+
+	// 设置为常驻辅助程序时，没有会话就先启动（只弹出一次 UAC 提示）；启动失败时单次启动辅助程序
+	if a.settings.Current().HelperMode == settings.HelperSession && !a.IsElevated() {
+		if _, err := a.StartElevatedSession(); errors.Is(err, ErrUACDeclined) {
+			return 0, err
+		} else if err != nil {
+			runtime.LogInfof(a.ctx, "常驻辅助程序启动失败，单次启动: %v", err)
+		}
+	}
+	if ok, err := a.submitToSession(ctx, task); ok || err != nil {
+		return 0, err
+	}
+
+	args := fmt.Sprintf("-port=%s -task-id=%s -key=%s -task-file=\"%s\"", a.httpPort, task.id, task.key, file)
+	return a.shellExecuteElevated(helperPath, args)
+}
+
+// endElevatedTask 注销任务并删除任务文件（辅助程序未启动时仍在），之后辅助程序的请求都会被拒绝
+func (a *App) endElevatedTask(task *elevatedTask) {
+	a.ipcServer.Unregister(task.id)
+	if task.file != "" {
+		a.fs.Remove(task.file)
+	}
+
+	a.resultsMutex.Lock()
+	delete(a.elevatedResults, task.id)
+	delete(a.elevatedProgress, task.id)
+	delete(a.elevatedStatus, task.id)
+	delete(a.elevatedCancel, task.id)
+	a.resultsMutex.Unlock()
+}
+
+// NewOperationID 生成操作 ID（传给扫描或清理方法后可用 CancelOperation 取消）
+func (a *App) NewOperationID() string {
+	return ops.NewID()
+}
+
+// CancelOperation 取消正在执行的扫描或清理，操作会返回取消前已完成的部分结果
+func (a *App) CancelOperation(opID string) error {
+	fmt.Fprintf(os.Stderr, "[DEBUG] CancelOperation: %s\n", opID)
+	return a.ops.Cancel(opID)
 }
 
-// ScanCleanItems 扫描清理项
-func (a *App) ScanCleanItems() ([]*models.CleanItem, error) {
-	return a.cleanService.ScanCleanItems()
+// beginOperation 开始可取消的操作（opID 为空时不可取消），done 必须调用
+func (a *App) beginOperation(opID string) (context.Context, func()) {
+	return a.ops.Begin(a.ctx, opID)
+}
+
+// markCancelled 操作被取消时标记结果
+func markCancelled(ctx context.Context, result *ElevatedResult) *ElevatedResult {
+	if result != nil && ops.Cancelled(ctx) {
+		result.Status = ops.StatusCancelled
+	}
+	return result
+}
+
+// GetDiskInfo 获取分区信息，drive 为空时返回系统盘（Windows 所在分区）
+func (a *App) GetDiskInfo(drive string) (*models.DiskInfo, error) {
+	if drive == "" {
+		return a.cleanService.GetDiskInfo()
+	}
+	return a.cleanService.GetDriveInfo(drive)
+}
+
+// GetVolumes 列出分区，includeAll 为 false 时不包括可移动磁盘、网络驱动器和光驱
+func (a *App) GetVolumes(includeAll bool) ([]fsys.Volume, error) {
+	return fsys.Volumes(includeAll)
+}
+
+// ScanCleanItems 扫描清理项（opID 用于取消，取消时未扫描完的项目状态为 "cancelled"）
+func (a *App) ScanCleanItems(opID string) ([]*models.CleanItem, error) {
+	ctx, done := a.beginOperation(opID)
+	defer done()
+	defer a.saveSizeIndex()
+
+	record := a.beginHistory(history.KindScan, a.IsElevated())
+	items, err := a.cleanService.ScanCleanItems(ctx)
+	record.SetScan(items)
+	a.addHistory(ctx, record, err)
+	a.rememberScan(report.SectionClean, err, func(in *report.Input) { in.CleanItems = items })
+	return items, err
+}
+
+// GetCleanCatalog 获取清理项目录（未扫描状态）
+func (a *App) GetCleanCatalog() []*models.CleanItem {
+	return a.cleanService.CatalogItems()
 }
 
 // ScanSingleCleanItem 扫描单个清理项
 func (a *App) ScanSingleCleanItem(itemID string) (*models.CleanItem, error) {
-	// 创建清理项
-	var item *models.CleanItem
-	switch itemID {
-	case "1":
-		item = &models.CleanItem{ID: "1", Name: "系统临时文件", Checked: true, Safe: true, Status: "scanning"}
-	case "2":
-		item = &models.CleanItem{ID: "2", Name: "浏览器缓存", Checked: true, Safe: true, Status: "scanning"}
-	case "3":
-		item = &models.CleanItem{ID: "3", Name: "回收站", Checked: true, Safe: true, Status: "scanning"}
-	case "4":
-		item = &models.CleanItem{ID: "4", Name: "Windows更新缓存", Checked: true, Safe: true, Status: "scanning"}
-	case "5":
-		item = &models.CleanItem{ID: "5", Name: "系统文件清理", Checked: true, Safe: true, Status: "scanning"}
-	case "6":
-		item = &models.CleanItem{ID: "6", Name: "应用缓存", Checked: false, Safe: false, Status: "scanning"}
-	case "7":
-		item = &models.CleanItem{ID: "7", Name: "应用日志文件", Checked: false, Safe: false, Status: "scanning"}
-	default:
-		return nil, fmt.Errorf("unknown item ID: %s", itemID)
+	rule, ok := a.catalog.Rule(itemID)
+	if !ok {
+		return nil, apperr.New(apperr.UnknownItem, "id", itemID)
 	}
 
 	// 扫描单个项目
-	a.cleanService.ScanSingleItem(item)
+	record := a.beginHistory(history.KindScan, a.IsElevated())
+	item := rule.NewItem("scanning")
+	a.cleanService.ScanSingleItem(a.ctx, item)
+	a.saveSizeIndex()
+	record.SetScan([]*models.CleanItem{item})
+	a.addHistory(a.ctx, record, nil)
 	return item, nil
 }
 
-// CleanItems 清理选中的项目（统一使用扫描结果中的路径）
-func (a *App) CleanItems(items []*models.CleanItem) error {
-	fmt.Printf("[DEBUG] CleanItems called with %d items\n", len(items))
+// CleanItems 清理选中的项目（统一使用扫描结果中的路径），返回清理报告
+// 试运行时不删除任何文件，返回将要删除的文件清单
+func (a *App) CleanItems(items []*models.CleanItem, opts models.CleanOptions) (*ElevatedResult, error) {
+	fmt.Fprintf(os.Stderr, "[DEBUG] CleanItems called with %d items, dryRun=%v\n", len(items), opts.DryRun)
+
+	ctx, done := a.beginOperation(opts.OpID)
+	defer done()
+
+	if opts.DryRun {
+		// 需要管理员权限的项目由 CleanItemsElevated 处理，不计入清单
+		return markCancelled(ctx, &ElevatedResult{Success: true, Manifest: a.planItems(ctx, items, false)}), nil
+	}
+
+	run, err := a.beginQuarantine(&opts)
+	if err != nil {
+		return nil, err
+	}
+	if run != nil {
+		defer run.Close()
+	}
+	defer a.invalidateItems(items)
+
+	ctx, _, finish := a.beginCleanProgress(ctx, items, false)
+	defer finish()
+
+	record := a.beginHistory(history.KindClean, a.IsElevated())
+	report := services.NewCleanReport()
+	var cleaned []*models.CleanItem
 
 	for _, item := range items {
-		fmt.Printf("[DEBUG] Processing item: id=%s, name=%s, checked=%v\n", item.ID, item.Name, item.Checked)
+		fmt.Fprintf(os.Stderr, "[DEBUG] Processing item: id=%s, name=%s, checked=%v\n", item.ID, item.Name, item.Checked)
 
 		if !item.Checked {
-			fmt.Printf("[DEBUG] Item %s not checked, skipping\n", item.ID)
+			fmt.Fprintf(os.Stderr, "[DEBUG] Item %s not checked, skipping\n", item.ID)
+			continue
+		}
+
+		if ctx.Err() != nil {
+			item.Status = ops.StatusCancelled
+			continue
+		}
+
+		rule, ok := a.catalog.Rule(item.ID)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Unknown item %s, skipping\n", item.ID)
+			item.Status = "error"
+			item.Error = apperr.New(apperr.UnknownItem, "id", item.ID).Error()
 			continue
 		}
 
 		// 跳过需要管理员权限的项目（应该通过CleanItemElevated处理）
-		if item.ID == "4" || item.ID == "5" {
-			fmt.Printf("[DEBUG] Item %s needs admin, skipping\n", item.ID)
+		if rule.NeedsAdmin {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Item %s needs admin, skipping\n", item.ID)
 			continue
 		}
 
-		fmt.Printf("[DEBUG] Cleaning item %s: %s\n", item.ID, item.Name)
+		fmt.Fprintf(os.Stderr, "[DEBUG] Cleaning item %s: %s (%d paths)\n", item.ID, item.Name, len(item.Paths))
+		a.cleanService.CleanItem(ctx, item, run, report)
+		cleaned = append(cleaned, item)
+		fmt.Fprintf(os.Stderr, "[DEBUG] Item %s: status=%s\n", item.ID, item.Status)
+	}
 
-		// 特殊处理：回收站和日志文件
-		if item.ID == "3" {
-			// 回收站使用特殊API
-			fmt.Printf("[DEBUG] Emptying recycle bin...\n")
-			err := a.cleanService.EmptyRecycleBin()
-			if err != nil {
-				fmt.Printf("[DEBUG] Recycle bin empty failed: %v\n", err)
-				item.Status = "error"
-				item.Error = "清空回收站失败: " + err.Error()
-				continue
-			}
-			fmt.Printf("[DEBUG] Recycle bin emptied successfully\n")
-			item.Status = "completed"
+	fmt.Fprintf(os.Stderr, "[DEBUG] CleanItems completed: freed=%d bytes, removed=%d, skipped=%d\n", report.FreedSize, report.RemovedCount, report.SkippedCount)
+	result := markCancelled(ctx, newCleanResult(report, opts.RunID))
+	a.recordClean(ctx, record, cleaned, result, nil)
+	return result, nil
+}
+
+// beginCleanProgress 开始发送应用内清理的进度（路径总数按 items 统计，回收站计为一个路径）。
+// ctx 中已有清理进度时沿用外层的进度，返回的 finish 为空操作
+func (a *App) beginCleanProgress(ctx context.Context, items []*models.CleanItem, includeAdmin bool) (context.Context, *services.CleanTracker, func()) {
+	if tracker := services.CleanTrackerFrom(ctx); tracker != nil {
+		return ctx, tracker, func() {}
+	}
+
+	totalPaths := 0
+	for _, item := range items {
+		if !item.Checked {
+			continue
+		}
+		rule, ok := a.catalog.Rule(item.ID)
+		if !ok || (rule.NeedsAdmin && !includeAdmin) {
 			continue
 		}
+		if rule.Handler == catalog.HandlerRecycleBin {
+			totalPaths++
+		} else {
+			totalPaths += len(item.Paths)
+		}
+	}
+
+	tracker := services.NewCleanTracker(a.emitProgress, totalPaths)
+	return services.WithCleanTracker(ctx, tracker), tracker, tracker.Finish
+}
+
+// beginQuarantine 隔离模式下开始（或继续）清理批次，未开启隔离时返回 nil
+func (a *App) beginQuarantine(opts *models.CleanOptions) (*quarantine.Run, error) {
+	if !opts.Quarantine {
+		return nil, nil
+	}
+	if opts.RunID == "" {
+		opts.RunID = quarantine.NewRunID()
+	}
+	return a.quarantine.Begin(opts.RunID, "app")
+}
+
+// GetProtectedPaths 获取受保护路径
+func (a *App) GetProtectedPaths() models.ProtectedRules {
+	return a.protect.Rules()
+}
+
+// SetProtectedPaths 保存受保护路径（索引中的统计按旧规则计算，需要重新扫描）
+func (a *App) SetProtectedPaths(rules models.ProtectedRules) error {
+	if err := a.protect.Set(rules); err != nil {
+		return err
+	}
+	return a.ClearSizeIndex()
+}
+
+// GetDiskMonitorConfig 获取剩余空间监控设置
+func (a *App) GetDiskMonitorConfig() monitor.Config {
+	return a.monitor.Config()
+}
+
+// SetDiskMonitorConfig 保存剩余空间监控设置（立即生效）
+func (a *App) SetDiskMonitorConfig(config monitor.Config) error {
+	return a.monitor.SetConfig(config)
+}
+
+// GetDiskSamples 获取最近 24 小时的剩余空间采样，since 为 RFC3339 时间（为空时返回全部）
+func (a *App) GetDiskSamples(since string) ([]monitor.Sample, error) {
+	from, err := parseSince(since)
+	if err != nil {
+		return nil, err
+	}
+	return a.monitor.Samples(from), nil
+}
 
-		if item.ID == "7" {
-			// 日志文件：使用扫描结果中的路径
-			fmt.Printf("[DEBUG] Using %d log paths from scan results\n", len(item.Paths))
-			var totalCleaned int64
-			var totalCount int
-			for i, pathDetail := range item.Paths {
-				fmt.Printf("[DEBUG] Cleaning log path [%d/%d]: %s\n", i+1, len(item.Paths), pathDetail.Path)
-				cleaned, count := a.cleanLogFilesInPath(pathDetail.Path)
-				totalCleaned += cleaned
-				totalCount += count
-				fmt.Printf("[DEBUG] Cleaned %s: %d bytes, %d files\n", pathDetail.Path, cleaned, count)
+// GetDiskEvents 获取剩余空间监控事件，since 为 RFC3339 时间（为空时返回全部）
+func (a *App) GetDiskEvents(since string) ([]monitor.Event, error) {
+	from, err := parseSince(since)
+	if err != nil {
+		return nil, err
+	}
+	return a.monitor.Events(from), nil
+}
+
+// GetDiskForecast 分析最近 days 天（0 为 30 天）系统盘已用空间的趋势，预测写满时间，
+// 并返回两次目录快照之间增长最多的 top 个目录（0 为 10 个）
+func (a *App) GetDiskForecast(days int, top int) (*trend.Forecast, error) {
+	if days <= 0 {
+		days = 30
+	}
+	if top <= 0 {
+		top = 10
+	}
+	return a.trend.Forecast(time.Now(), time.Duration(days)*24*time.Hour, top)
+}
+
+// TakeDiskSnapshot 立即生成系统盘的目录大小快照（opID 用于取消，取消时不保存）
+func (a *App) TakeDiskSnapshot(opID string) (*trend.Snapshot, error) {
+	ctx, done := a.beginOperation(opID)
+	defer done()
+	return a.trend.TakeSnapshot(ctx, fsys.SystemDrive())
+}
+
+// parseSince 解析 RFC3339 时间，为空时返回零值
+func parseSince(since string) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	from, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, apperr.New(apperr.InvalidTime, "value", since)
+	}
+	return from, nil
+}
+
+// beginHistory 开始一条扫描或清理记录（elevated：是否以管理员权限执行）
+func (a *App) beginHistory(kind string, elevated bool) *history.Record {
+	return history.Begin(kind, history.SourceApp, elevated, a.freeSpace())
+}
+
+// addHistory 保存扫描或清理记录（保存失败不影响操作结果）
+func (a *App) addHistory(ctx context.Context, record *history.Record, err error) {
+	record.Finish(ctx, a.freeSpace(), err)
+	if err := a.history.Add(record); err != nil {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Failed to save history: %v\n", err)
+	}
+}
+
+// recordClean 根据清理结果保存清理记录，record 为空（试运行）时忽略
+func (a *App) recordClean(ctx context.Context, record *history.Record, items []*models.CleanItem, result *ElevatedResult, err error) {
+	if record == nil {
+		return
+	}
+	if result != nil {
+		record.SetClean(items, result.Report)
+		record.QuarantineRun = result.RunID
+		if err == nil && result.Error != "" {
+			err = errors.New(result.Error)
+			if result.ErrorDetail != nil {
+				err = result.ErrorDetail
 			}
-			item.Size = totalCleaned
-			item.FileCount = totalCount
-			item.Status = "completed"
-			fmt.Printf("[DEBUG] Log files cleaned: %d bytes, %d files total\n", totalCleaned, totalCount)
-			continue
 		}
+	} else {
+		record.SetClean(items, nil)
+	}
+	a.addHistory(ctx, record, err)
+}
+
+// freeSpace 返回系统盘剩余空间，无法获取时返回 -1
+func (a *App) freeSpace() int64 {
+	info, err := a.cleanService.GetDiskInfo()
+	if err != nil {
+		return -1
+	}
+	return info.Free
+}
+
+// GetHistory 获取扫描和清理记录（最新的在前）。kind 为 scan 或 clean，为空时不限；
+// itemID 不为空时只返回包含该清理项的记录；since 为 RFC3339 时间（为空时不限）；limit 为 0 时不限
+func (a *App) GetHistory(kind string, itemID string, since string, limit int) ([]*history.Record, error) {
+	from, err := parseSince(since)
+	if err != nil {
+		return nil, err
+	}
+	return a.history.List(history.Query{Kind: kind, ItemID: itemID, Since: from, Limit: limit})
+}
+
+// GetHistoryStats 按清理项汇总 [since, until) 之间的记录（RFC3339 时间，为空时不限）
+func (a *App) GetHistoryStats(since string, until string) (*history.Summary, error) {
+	from, err := parseSince(since)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseSince(until)
+	if err != nil {
+		return nil, err
+	}
+	return a.history.Aggregate(history.Query{Since: from, Until: to})
+}
+
+// PruneHistory 删除 before（RFC3339 时间）之前的记录，返回删除的数量
+func (a *App) PruneHistory(before string) (int, error) {
+	to, err := parseSince(before)
+	if err != nil {
+		return 0, err
+	}
+	if to.IsZero() {
+		return 0, apperr.New(apperr.NoPruneTime)
+	}
+	return a.history.Prune(to)
+}
+
+// GetCleanProfiles 获取自动清理方案及其运行状态
+func (a *App) GetCleanProfiles() ([]scheduler.ProfileStatus, error) {
+	return a.scheduler.Profiles()
+}
+
+// SaveCleanProfile 保存自动清理方案（ID 为空时新建）
+func (a *App) SaveCleanProfile(profile scheduler.Profile) (*scheduler.Profile, error) {
+	return a.scheduler.SaveProfile(&profile)
+}
+
+// DeleteCleanProfile 删除自动清理方案
+func (a *App) DeleteCleanProfile(id string) error {
+	return a.scheduler.DeleteProfile(id)
+}
+
+// RunCleanProfile 立即运行自动清理方案（opID 用于取消）
+func (a *App) RunCleanProfile(id string, opID string) (*scheduler.Run, error) {
+	ctx, done := a.beginOperation(opID)
+	defer done()
+	return a.scheduler.RunNow(ctx, id)
+}
+
+// GetScheduleHistory 获取自动清理的运行记录（最新的在前），profileID 为空时返回全部
+func (a *App) GetScheduleHistory(profileID string) ([]*scheduler.Run, error) {
+	return a.scheduler.History(profileID)
+}
+
+// GetPendingAdminItems 获取自动清理时因需要管理员权限而排队的清理项
+func (a *App) GetPendingAdminItems() ([]scheduler.PendingItem, error) {
+	return a.scheduler.Pending()
+}
+
+// ClearPendingAdminItems 从队列中移除已清理或忽略的清理项，itemIDs 为空时清空队列
+func (a *App) ClearPendingAdminItems(itemIDs []string) error {
+	return a.scheduler.ClearPending(itemIDs)
+}
+
+// ClearSizeIndex 清空目录大小索引，下次扫描完整遍历所有目录（"完整重新扫描"）
+func (a *App) ClearSizeIndex() error {
+	a.sizeIndex.Reset()
+	return a.sizeIndex.Save()
+}
+
+// saveSizeIndex 保存目录大小索引（失败只影响下次扫描速度）
+func (a *App) saveSizeIndex() {
+	if err := a.sizeIndex.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Failed to save size index: %v\n", err)
+	}
+}
 
-		// 统一处理：使用扫描结果中的路径
-		fmt.Printf("[DEBUG] Using %d paths from scan results\n", len(item.Paths))
-		var paths []string
+// invalidateItems 清理后删除清理项路径的索引（包括辅助程序清理的路径）
+func (a *App) invalidateItems(items []*models.CleanItem) {
+	var paths []string
+	for _, item := range items {
+		if !item.Checked {
+			continue
+		}
 		for _, pathDetail := range item.Paths {
 			paths = append(paths, pathDetail.Path)
 		}
+	}
+	a.sizeIndex.Invalidate(paths...)
+	a.saveSizeIndex()
+}
 
-		if len(paths) == 0 {
-			fmt.Printf("[DEBUG] No paths to clean for item %s\n", item.ID)
-			item.Status = "completed"
-			continue
+// invalidateManifest 按清单清理后删除相关目录的索引
+func (a *App) invalidateManifest(manifest *models.CleanManifest) {
+	roots := make(map[string]bool)
+	var paths []string
+	for _, entry := range manifest.Entries {
+		root := entry.Root
+		if root == "" {
+			root = filepath.Dir(entry.Path)
 		}
-
-		// 清理所有路径
-		var totalCleaned int64
-		hasError := false
-		for i, path := range paths {
-			fmt.Printf("[DEBUG] Cleaning path [%d/%d]: %s\n", i+1, len(paths), path)
-			cleaned, err := a.cleanService.CleanFolderSafe(path)
-			if err != nil {
-				fmt.Printf("[DEBUG] Failed to clean %s: %v\n", path, err)
-				hasError = true
-			} else {
-				fmt.Printf("[DEBUG] Cleaned %s: %d bytes\n", path, cleaned)
-				totalCleaned += cleaned
-			}
+		if !roots[root] {
+			roots[root] = true
+			paths = append(paths, root)
 		}
+	}
+	a.sizeIndex.Invalidate(paths...)
+	a.saveSizeIndex()
+}
 
-		if hasError {
-			item.Status = "error"
-			item.Error = "部分路径清理失败（文件可能正在使用）"
-		} else {
-			item.Status = "completed"
+// NewCleanRunID 生成清理批次 ID（隔离模式下 CleanItems 与 CleanItemsElevated 共用同一批次）
+func (a *App) NewCleanRunID() string {
+	return quarantine.NewRunID()
+}
+
+// ListCleanRuns 列出隔离区中可恢复的清理批次
+func (a *App) ListCleanRuns() []models.QuarantineRun {
+	return a.quarantine.Runs()
+}
+
+// DeleteCleanRun 永久删除隔离区中的清理批次（管理员隔离区中的部分交给辅助程序删除）
+func (a *App) DeleteCleanRun(runID string) error {
+	if err := a.quarantine.Delete(runID); err != nil {
+		return err
+	}
+	if !a.quarantine.NeedsAdmin(runID) {
+		return nil
+	}
+	result, err := a.runElevatedTask(context.Background(), &ipc.TaskFile{Task: "delete-run", QuarantineRun: runID})
+	if err != nil {
+		return err
+	}
+	return result.err()
+}
+
+// RestoreCleanRun 将清理批次中的文件恢复到原位置（管理员隔离区中的文件交给辅助程序恢复）
+func (a *App) RestoreCleanRun(runID string) (*ElevatedResult, error) {
+	restored, err := a.quarantine.Restore(runID)
+	if err != nil {
+		return failedResult(err), nil
+	}
+	fmt.Fprintf(os.Stderr, "[DEBUG] RestoreCleanRun %s: restored=%d, conflicts=%d, failed=%d, elevated=%d, untrusted=%d\n", runID, restored.Count, restored.Conflicts, restored.Failed, restored.Elevated, restored.Untrusted)
+
+	result := &ElevatedResult{
+		Success:      true,
+		CleanedSize:  restored.Size,
+		CleanedCount: restored.Count,
+		RunID:        runID,
+	}
+
+	if restored.Elevated > 0 {
+		elevated, err := a.runElevatedTask(context.Background(), &ipc.TaskFile{Task: "restore-run", QuarantineRun: runID})
+		if err != nil {
+			result.setError(err)
+			return result, nil
+		}
+		result.CleanedSize += elevated.CleanedSize
+		result.CleanedCount += elevated.CleanedCount
+		if !elevated.Success {
+			result.setError(elevated.err())
+			return result, nil
 		}
+	}
 
-		fmt.Printf("[DEBUG] Item %s cleaned: %d bytes total\n", item.ID, totalCleaned)
+	// 以管理员身份运行时不恢复普通隔离区中的文件
+	if n := restored.Conflicts + restored.Failed + restored.Untrusted; n > 0 {
+		result.setError(apperr.New(apperr.RestoreIncomplete, "count", n))
 	}
+	return result, nil
+}
 
-	fmt.Printf("[DEBUG] CleanItems completed\n")
-	return nil
+// planItems 生成选中项目的删除清单
+func (a *App) planItems(ctx context.Context, items []*models.CleanItem, includeAdmin bool) *models.CleanManifest {
+	manifest := services.NewCleanManifest()
+	for _, item := range items {
+		if !item.Checked {
+			continue
+		}
+		rule, ok := a.catalog.Rule(item.ID)
+		if !ok || (rule.NeedsAdmin && !includeAdmin) {
+			continue
+		}
+		a.cleanService.PlanItem(ctx, item, manifest)
+	}
+	return manifest
 }
 
-// GetInstalledSoftware 获取已安装软件
-func (a *App) GetInstalledSoftware() ([]*models.SoftwareInfo, error) {
-	return a.softwareService.GetInstalledSoftware()
+// GetInstalledSoftware 获取已安装软件（opID 用于取消）
+func (a *App) GetInstalledSoftware(opID string) ([]*models.SoftwareInfo, error) {
+	ctx, done := a.beginOperation(opID)
+	defer done()
+	defer a.saveSizeIndex()
+	software, err := a.softwareService.GetInstalledSoftware(ctx)
+	a.rememberScan(report.SectionSoftware, err, func(in *report.Input) { in.Software = software })
+	return software, err
 }
 
-// DetectWeChat 检测微信
-func (a *App) DetectWeChat() (*models.WeChatData, error) {
-	return a.wechatService.DetectWeChat()
+// DetectWeChat 检测微信（opID 用于取消）
+func (a *App) DetectWeChat(opID string) (*models.WeChatData, error) {
+	ctx, done := a.beginOperation(opID)
+	defer done()
+	data, err := a.wechatService.DetectWeChat(ctx)
+	a.rememberScan(report.SectionWeChat, err, func(in *report.Input) { in.WeChat = data })
+	return data, err
 }
 
 // OpenWeChat 打开微信
@@ -324,9 +1055,14 @@ func (a *App) OpenFolder(path string) error {
 	return a.cleanService.OpenFolder(path)
 }
 
-// ScanLargeFiles 扫描C盘大文件
-func (a *App) ScanLargeFiles() (*services.ScanResult, error) {
-	return a.largeFileService.ScanCDrive()
+// ScanLargeFiles 扫描分区中的大文件，drive 为空时扫描系统盘（opID 用于取消，取消时返回已扫描部分）
+func (a *App) ScanLargeFiles(opID string, drive string) (*services.ScanResult, error) {
+	ctx, done := a.beginOperation(opID)
+	defer done()
+	defer a.saveSizeIndex()
+	result, err := a.largeFileService.ScanDrive(ctx, drive)
+	a.rememberScan(report.SectionLargeFiles, err, func(in *report.Input) { in.LargeFiles = result })
+	return result, err
 }
 
 // DeleteLargeFile 删除大文件
@@ -339,14 +1075,84 @@ func (a *App) OpenLargeFileLocation(path string) error {
 	return a.largeFileService.OpenFileLocation(path)
 }
 
-// SetLargeFileMinSize 设置大文件最小大小（MB）
-func (a *App) SetLargeFileMinSize(sizeInMB int64) {
-	a.largeFileService.SetMinSize(sizeInMB)
+// SetLargeFileMinSize 设置并保存大文件最小大小（MB），单独设置了阈值的分类不变
+func (a *App) SetLargeFileMinSize(sizeInMB int64) error {
+	current, err := a.settings.Get()
+	if err != nil {
+		return err
+	}
+	current.LargeFiles.MinSize = sizeInMB * 1024 * 1024
+	current.Profiles = nil
+	_, err = a.UpdateSettings(*current)
+	return err
+}
+
+// GetSettings 返回全部用户设置
+func (a *App) GetSettings() (*settings.Settings, error) {
+	return a.settings.Get()
+}
+
+// UpdateSettings 校验并保存全部用户设置（先用 GetSettings 获取再修改），立即生效。
+// profiles 为 null、exclusions 为空对象时不修改自动清理方案和受保护路径
+func (a *App) UpdateSettings(s settings.Settings) (*settings.Settings, error) {
+	updated, err := a.settings.Update(&s)
+	if err != nil {
+		return nil, err
+	}
+	a.applySettings(a.settings.Current())
+	return updated, nil
 }
 
 // ScanSystemOptimize 扫描系统优化项
 func (a *App) ScanSystemOptimize() (*services.SystemOptimizeResult, error) {
-	return a.optimizeService.Scan()
+	result, err := a.optimizeService.Scan()
+	a.rememberScan(report.SectionOptimize, err, func(in *report.Input) { in.Optimize = result })
+	return result, err
+}
+
+// rememberScan 保存扫描结果供 ExportReport 使用（扫描失败时记录原因，保留上一次的结果）
+func (a *App) rememberScan(section string, err error, update func(in *report.Input)) {
+	a.lastScanMutex.Lock()
+	defer a.lastScanMutex.Unlock()
+	if err != nil {
+		if a.lastScan.Errors == nil {
+			a.lastScan.Errors = make(map[string]string)
+		}
+		a.lastScan.Errors[section] = err.Error()
+		return
+	}
+	delete(a.lastScan.Errors, section)
+	update(&a.lastScan)
+}
+
+// ExportReport 把最近一次各项扫描（清理项、大文件、系统优化、已安装软件、微信）的结果导出为报告，
+// format 为 json、csv 或 html；csv 每个部分一个文件。返回写入的文件
+func (a *App) ExportReport(format string, path string) ([]string, error) {
+	a.lastScanMutex.Lock()
+	in := a.lastScan
+	in.Errors = make(map[string]string, len(a.lastScan.Errors))
+	for section, err := range a.lastScan.Errors {
+		in.Errors[section] = err
+	}
+	a.lastScanMutex.Unlock()
+
+	if in.Empty() {
+		return nil, apperr.New(apperr.NoScanResults)
+	}
+	if info, err := a.cleanService.GetDiskInfo(); err == nil {
+		in.Disk = info
+	}
+	return report.Write(a.fs, report.Build(&in, time.Now()), format, path)
+}
+
+// SelectReportPath 打开保存对话框选择报告位置，用户取消时返回空字符串
+func (a *App) SelectReportPath(format string) (string, error) {
+	name := fmt.Sprintf("ccooler-report-%s.%s", time.Now().Format("20060102-150405"), format)
+	return runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           report.ExportTitle(),
+		DefaultFilename: name,
+		Filters:         []runtime.FileFilter{{DisplayName: strings.ToUpper(format), Pattern: "*." + format}},
+	})
 }
 
 // CleanSystemOptimizeItem 清理系统优化项
@@ -360,53 +1166,36 @@ func (a *App) CleanSystemOptimizeItem(itemType string) error {
 	// 需要提升权限，使用辅助程序
 	exePath, err := os.Executable()
 	if err != nil {
-		return fmt.Errorf("无法获取程序路径: %v", err)
+		return apperr.Wrap(apperr.ExePath, err)
 	}
 
 	exeDir := filepath.Dir(exePath)
-	helperPath := filepath.Join(exeDir, "CCoolerElevated.exe")
+	helperPath := filepath.Join(exeDir, helperName)
 
 	if _, err := os.Stat(helperPath); err != nil {
-		return fmt.Errorf("辅助程序 CCoolerElevated.exe 不存在")
+		return apperr.New(apperr.HelperMissing, "helper", helperName)
 	}
 
-	// 创建结果通道
-	resultChan := make(chan *ElevatedResult, 1)
-	resultID := fmt.Sprintf("optimize-%s-%d", itemType, time.Now().Unix())
-
-	a.resultsMutex.Lock()
-	a.elevatedResults[resultID] = resultChan
-	a.resultsMutex.Unlock()
-
-	defer func() {
-		a.resultsMutex.Lock()
-		delete(a.elevatedResults, resultID)
-		a.resultsMutex.Unlock()
-	}()
-
-	// 构造命令行参数（系统优化任务不需要 paths，但提供空值以满足参数解析）
-	args := fmt.Sprintf("-task=optimize-%s -port=%s -paths=\"\"", itemType, a.httpPort)
+	// 登记任务
+	task := a.beginElevatedTask("optimize-" + itemType)
+	defer a.endElevatedTask(task)
 
 	// 启动提升的辅助程序
 	runtime.LogInfof(a.ctx, "使用管理员权限执行系统优化: %s", itemType)
-	err = a.shellExecuteElevated(helperPath, args)
+	process, err := a.launchElevated(context.Background(), helperPath, task, &ipc.TaskFile{Task: "optimize-" + itemType})
+	if errors.Is(err, ErrUACDeclined) {
+		return err
+	}
 	if err != nil {
-		return fmt.Errorf("启动辅助程序失败: %v", err)
+		return apperr.Wrap(apperr.HelperStartFailed, err)
 	}
 
 	// 等待结果
-	timeout := time.NewTimer(30 * time.Second)
-	defer timeout.Stop()
-
-	select {
-	case result := <-resultChan:
-		if !result.Success {
-			return fmt.Errorf(result.Error)
-		}
-		return nil
-	case <-timeout.C:
-		return fmt.Errorf("操作超时")
+	result, err := a.waitElevated(context.Background(), task, process)
+	if err != nil {
+		return err
 	}
+	return result.err()
 }
 
 // ScanDesktop 扫描桌面文件
@@ -425,451 +1214,540 @@ func (a *App) SelectFolder() (string, error) {
 }
 
 // CleanItemsElevated 批量以管理员权限清理多个项目（单次UAC提示）
-func (a *App) CleanItemsElevated(items []*models.CleanItem) (*ElevatedResult, error) {
+func (a *App) CleanItemsElevated(items []*models.CleanItem, opts models.CleanOptions) (*ElevatedResult, error) {
 	if len(items) == 0 {
 		return &ElevatedResult{Success: true}, nil
 	}
+	if opts.Quarantine && opts.RunID == "" {
+		opts.RunID = quarantine.NewRunID()
+	}
+
+	ctx, done := a.beginOperation(opts.OpID)
+	defer done()
+	var record *history.Record
+	if !opts.DryRun {
+		defer a.invalidateItems(items)
+		record = a.beginHistory(history.KindClean, true)
+	}
+	result, err := a.cleanItemsElevated(ctx, items, opts)
+	result = markCancelled(ctx, result)
+	a.recordClean(ctx, record, items, result, err)
+	return result, err
+}
 
+// cleanItemsElevated 批量以管理员权限清理多个项目（ctx 取消时停止）
+func (a *App) cleanItemsElevated(ctx context.Context, items []*models.CleanItem, opts models.CleanOptions) (*ElevatedResult, error) {
 	// 1. 检查是否已经提升了权限
 	isElevated := a.IsElevated()
-	fmt.Printf("[DEBUG] CleanItemsElevated: %d items, isElevated=%v\n", len(items), isElevated)
+	fmt.Fprintf(os.Stderr, "[DEBUG] CleanItemsElevated: %d items, isElevated=%v, dryRun=%v\n", len(items), isElevated, opts.DryRun)
+
+	if isElevated && opts.DryRun {
+		// 已经提升了权限，直接生成删除清单
+		return &ElevatedResult{Success: true, Manifest: a.planItems(ctx, items, true)}, nil
+	}
 
 	if isElevated {
 		// 已经提升了权限，直接执行所有项目
-		fmt.Println("[DEBUG] Already elevated, executing all items directly")
-		totalResult := &ElevatedResult{Success: true}
+		fmt.Fprintln(os.Stderr, "[DEBUG] Already elevated, executing all items directly")
+		run, err := a.beginQuarantine(&opts)
+		if err != nil {
+			return failedResult(err), nil
+		}
+		if run != nil {
+			defer run.Close()
+		}
+
+		ctx, _, finish := a.beginCleanProgress(ctx, items, true)
+		defer finish()
+
+		totalResult := &ElevatedResult{Success: true, RunID: opts.RunID}
 		for _, item := range items {
-			result, err := a.cleanItemDirect(item)
-			if err != nil {
-				return &ElevatedResult{Success: false, Error: err.Error()}, err
+			if ctx.Err() != nil {
+				break
 			}
+			result := a.cleanItemDirect(ctx, item, run)
 			if !result.Success {
 				totalResult.Success = false
 				totalResult.Error += result.Error + "; "
+				if totalResult.ErrorDetail == nil {
+					totalResult.ErrorDetail = result.ErrorDetail
+				}
 			}
-			totalResult.CleanedSize += result.CleanedSize
-			totalResult.CleanedCount += result.CleanedCount
+			mergeResult(totalResult, result)
 		}
 		return totalResult, nil
 	}
 
-	// 2. 收集所有需要清理的路径
-	allPaths := []string{}
+	// 2. 收集所有需要清理的路径（以及路径所属的清理项，辅助程序据此应用过滤条件）
+	var allPaths []ipc.TaskPath
 	for _, item := range items {
 		// 特殊处理：回收站和日志文件不通过辅助程序
-		if item.ID == "3" || item.ID == "7" {
+		if !a.isFolderItem(item) {
 			continue
 		}
 		for _, pathDetail := range item.Paths {
-			allPaths = append(allPaths, pathDetail.Path)
+			allPaths = append(allPaths, ipc.TaskPath{Path: pathDetail.Path, Item: item.ID})
 		}
 	}
 
+	if len(allPaths) == 0 && opts.DryRun {
+		// 所有项目都是特殊处理项，直接生成删除清单
+		return &ElevatedResult{Success: true, Manifest: a.planItems(ctx, items, true)}, nil
+	}
+
 	if len(allPaths) == 0 {
 		// 所有项目都是特殊处理项，直接执行
-		totalResult := &ElevatedResult{Success: true}
+		ctx, _, finish := a.beginCleanProgress(ctx, items, true)
+		defer finish()
+
+		totalResult := &ElevatedResult{Success: true, RunID: opts.RunID}
 		for _, item := range items {
-			result, _ := a.CleanItemElevated(item)
-			totalResult.CleanedSize += result.CleanedSize
-			totalResult.CleanedCount += result.CleanedCount
+			if ctx.Err() != nil {
+				break
+			}
+			result, _ := a.cleanItemElevated(ctx, item, opts)
+			mergeResult(totalResult, result)
 		}
 		return totalResult, nil
 	}
 
-	// 3. 获取辅助程序路径
-	exePath, err := os.Executable()
-	if err != nil {
-		return &ElevatedResult{
-			Success: false,
-			Error:   fmt.Sprintf("无法获取程序路径: %v", err),
-		}, nil
+	// 3. 构造任务文件
+	spec := &ipc.TaskFile{
+		Task:    "clean-batch",
+		Paths:   allPaths,
+		Filters: a.taskFilters(allPaths),
+		DryRun:  opts.DryRun,
 	}
-
-	exeDir := filepath.Dir(exePath)
-	helperPath := filepath.Join(exeDir, "CCoolerElevated.exe")
-	absHelperPath, _ := filepath.Abs(helperPath)
-
-	runtime.LogDebugf(a.ctx, "Looking for helper: %s", absHelperPath)
-
-	if _, err := os.Stat(helperPath); err != nil {
-		return &ElevatedResult{
-			Success: false,
-			Error:   "辅助程序 CCoolerElevated.exe 不存在，请确保它与主程序在同一目录",
-		}, nil
+	if opts.Quarantine {
+		spec.QuarantineRun = opts.RunID
 	}
 
-	runtime.LogInfof(a.ctx, "✓ Found helper at: %s", absHelperPath)
-
-	// 4. 创建结果和进度通道
-	resultChan := make(chan *ElevatedResult, 1)
-	progressChan := make(chan *ElevatedProgress, 10)
-	resultID := fmt.Sprintf("clean-batch-%d", time.Now().Unix())
-
-	a.resultsMutex.Lock()
-	a.elevatedResults[resultID] = resultChan
-	a.elevatedProgress[resultID] = progressChan
-	a.resultsMutex.Unlock()
-
-	defer func() {
-		a.resultsMutex.Lock()
-		delete(a.elevatedResults, resultID)
-		delete(a.elevatedProgress, resultID)
-		a.resultsMutex.Unlock()
-	}()
-
-	// 5. 构造命令行参数（使用|分隔路径）
-	pathsStr := strings.Join(allPaths, "|")
-	args := fmt.Sprintf("-task=clean-batch -port=%s -paths=\"%s\"", a.httpPort, pathsStr)
-
-	// 6. 使用ShellExecute启动提升的辅助程序（只启动一次）
+	// 4. 启动提升的辅助程序（只启动一次）并等待结果
 	runtime.LogInfof(a.ctx, "批量清理 %d 个项目，共 %d 个路径", len(items), len(allPaths))
-	err = a.shellExecuteElevated(helperPath, args)
+	result, err := a.runElevatedTask(ctx, spec)
 	if err != nil {
-		return &ElevatedResult{
-			Success: false,
-			Error:   fmt.Sprintf("启动辅助程序失败: %v", err),
-		}, nil
+		return failedResult(err), nil
 	}
 
-	runtime.LogInfo(a.ctx, "等待批量清理完成...")
-
-	// 7. 等待结果
-	timeout := time.NewTimer(60 * time.Second) // 批量清理延长超时
-	defer timeout.Stop()
-
-	for {
-		select {
-		case result := <-resultChan:
-			runtime.LogInfo(a.ctx, "批量清理完成")
-
-			// 处理特殊项目（回收站、日志文件）
-			for _, item := range items {
-				if item.ID == "3" || item.ID == "7" {
-					specialResult, _ := a.CleanItemElevated(item)
-					result.CleanedSize += specialResult.CleanedSize
-					result.CleanedCount += specialResult.CleanedCount
-				}
-			}
-
-			return result, nil
+	runtime.LogInfo(a.ctx, "批量清理完成")
+	result.RunID = opts.RunID
 
-		case progress := <-progressChan:
-			timeout.Reset(60 * time.Second)
-			runtime.EventsEmit(a.ctx, "clean-progress", progress)
-
-		case <-timeout.C:
-			return &ElevatedResult{
-				Success: false,
-				Error:   "清理超时（60秒无响应）",
-			}, nil
-		}
+	if opts.DryRun && result.Manifest == nil {
+		result.Manifest = services.NewCleanManifest()
 	}
-}
 
-// CleanItemElevated 以管理员权限清理项目
-func (a *App) CleanItemElevated(item *models.CleanItem) (*ElevatedResult, error) {
-	itemID := item.ID
-
-	// itemID=3 (回收站) 使用特殊API
-	if itemID == "3" {
-		fmt.Printf("[DEBUG] Emptying recycle bin...\n")
-		err := a.cleanService.EmptyRecycleBin()
-		if err != nil {
-			return &ElevatedResult{
-				Success: false,
-				Error:   err.Error(),
-			}, nil
-		}
-		return &ElevatedResult{
-			Success: true,
-		}, nil
-	}
-
-	// itemID=7 (应用日志文件) 使用特殊处理，不通过辅助程序
-	if itemID == "7" {
-		// 使用扫描结果中的路径
-		fmt.Printf("[DEBUG] Using %d log paths from scan results\n", len(item.Paths))
-		var totalCleaned int64
-		var totalCount int
-		for _, pathDetail := range item.Paths {
-			cleaned, count := a.cleanLogFilesInPath(pathDetail.Path)
-			totalCleaned += cleaned
-			totalCount += count
+	// 处理特殊项目（回收站、日志文件）
+	for _, item := range items {
+		if a.isFolderItem(item) || ctx.Err() != nil {
+			continue
 		}
-		return &ElevatedResult{
-			Success:      true,
-			CleanedSize:  totalCleaned,
-			CleanedCount: totalCount,
-		}, nil
-	}
-
-	// 1. 检查是否已经提升了权限
-	isAdmin := a.IsAdmin()
-	isElevated := a.IsElevated()
-	fmt.Printf("[DEBUG] CleanItemElevated: itemID=%s, isAdmin=%v, isElevated=%v\n", itemID, isAdmin, isElevated)
-
-	if isElevated {
-		// 已经提升了权限，直接执行（不会弹UAC）
-		fmt.Println("[DEBUG] Already elevated, executing directly")
-		return a.cleanItemDirect(item)
+		if opts.DryRun {
+			a.cleanService.PlanItem(ctx, item, result.Manifest)
+			continue
+		}
+		specialResult, _ := a.cleanItemElevated(ctx, item, opts)
+		mergeResult(result, specialResult)
 	}
 
-	// 2. 获取要清理的路径列表
-	fmt.Printf("[DEBUG] Using %d paths from scan results\n", len(item.Paths))
-	var paths []string
-	for _, pathDetail := range item.Paths {
-		paths = append(paths, pathDetail.Path)
-	}
+	return result, nil
+}
 
-	if len(paths) == 0 {
-		return &ElevatedResult{
-			Success: false,
-			Error:   "没有找到要清理的路径",
-		}, nil
+// taskFilters 任务中各清理项的过滤条件（辅助程序据此检查规则是否一致）
+func (a *App) taskFilters(paths []ipc.TaskPath) map[string]*catalog.Filter {
+	filters := make(map[string]*catalog.Filter)
+	for _, p := range paths {
+		if filter := a.catalog.PathFilter(p.Item); filter != nil {
+			filters[p.Item] = filter
+		}
 	}
+	return filters
+}
 
-	// 3. 获取辅助程序路径
+// runElevatedTask 启动辅助程序执行任务并等待结果（见 waitElevated）。
+// 用户拒绝 UAC 提示时返回 Status 为 "uac-declined" 的结果
+func (a *App) runElevatedTask(ctx context.Context, spec *ipc.TaskFile) (*ElevatedResult, error) {
+	// 1. 获取辅助程序路径
 	exePath, err := os.Executable()
 	if err != nil {
-		return &ElevatedResult{
-			Success: false,
-			Error:   fmt.Sprintf("无法获取程序路径: %v", err),
-		}, nil
+		return nil, apperr.Wrap(apperr.ExePath, err)
 	}
 
 	exeDir := filepath.Dir(exePath)
-	runtime.LogDebugf(a.ctx, "Executable dir: %s", exeDir)
-
-	// 辅助程序必须与主程序在同一目录
-	helperPath := filepath.Join(exeDir, "CCoolerElevated.exe")
+	helperPath := filepath.Join(exeDir, helperName)
 	absHelperPath, _ := filepath.Abs(helperPath)
 
 	runtime.LogDebugf(a.ctx, "Looking for helper: %s", absHelperPath)
 
 	if _, err := os.Stat(helperPath); err != nil {
-		errMsg := fmt.Sprintf("辅助程序 CCoolerElevated.exe 不存在\n\n期望位置: %s\n当前程序目录: %s\n\n请确保 CCoolerElevated.exe 与 CCooler.exe 在同一目录下", absHelperPath, exeDir)
-		runtime.LogError(a.ctx, errMsg)
-		return &ElevatedResult{
-			Success: false,
-			Error:   "辅助程序 CCoolerElevated.exe 不存在，请确保它与主程序在同一目录",
-		}, nil
+		return nil, apperr.New(apperr.HelperMissing, "helper", helperName)
 	}
 
 	runtime.LogInfof(a.ctx, "✓ Found helper at: %s", absHelperPath)
 
-	// 4. 创建结果和进度通道
-	resultChan := make(chan *ElevatedResult, 1)
-	progressChan := make(chan *ElevatedProgress, 10)
-	resultID := fmt.Sprintf("clean-%s-%d", itemID, time.Now().Unix())
-
-	a.resultsMutex.Lock()
-	a.elevatedResults[resultID] = resultChan
-	a.elevatedProgress[resultID] = progressChan
-	a.resultsMutex.Unlock()
+	// 2. 登记任务（只接受该任务签名的结果和进度）
+	task := a.beginElevatedTask(spec.Task)
+	defer a.endElevatedTask(task)
 
-	defer func() {
-		a.resultsMutex.Lock()
-		delete(a.elevatedResults, resultID)
-		delete(a.elevatedProgress, resultID)
-		a.resultsMutex.Unlock()
-	}()
-
-	// 5. 构造命令行参数（使用|分隔路径）
-	pathsStr := ""
-	for i, path := range paths {
-		if i > 0 {
-			pathsStr += "|"
-		}
-		pathsStr += path
+	// 3. 写入任务文件，使用ShellExecute启动提升的辅助程序
+	process, err := a.launchElevated(ctx, helperPath, task, spec)
+	if err != nil && ctx.Err() != nil {
+		// 等待常驻辅助程序时取消
+		return markCancelled(ctx, &ElevatedResult{Success: true}), nil
+	}
+	if errors.Is(err, ErrUACDeclined) {
+		result := failedResult(err)
+		result.Status = StatusUACDeclined
+		return result, nil
 	}
-	args := fmt.Sprintf("-task=clean-item-%s -port=%s -paths=\"%s\"", itemID, a.httpPort, pathsStr)
-
-	// 6. 使用ShellExecute启动提升的辅助程序
-	helperLogPath := filepath.Join(filepath.Dir(helperPath), "CCoolerElevated.log")
-	runtime.LogInfof(a.ctx, "辅助程序日志: %s", helperLogPath)
-	runtime.LogDebugf(a.ctx, "Launching: %s", helperPath)
-	runtime.LogDebugf(a.ctx, "Args: %s", args)
-
-	err = a.shellExecuteElevated(helperPath, args)
 	if err != nil {
-		runtime.LogErrorf(a.ctx, "ShellExecute failed: %v", err)
-		return &ElevatedResult{
-			Success: false,
-			Error:   fmt.Sprintf("启动辅助程序失败: %v", err),
-		}, nil
+		return nil, apperr.Wrap(apperr.HelperStartFailed, err)
 	}
 
-	runtime.LogInfo(a.ctx, "ShellExecute succeeded, waiting for result...")
-	runtime.LogInfo(a.ctx, "如果看到UAC窗口，请点击\"是\"以继续")
+	// 4. 等待结果
+	runtime.LogInfo(a.ctx, "等待辅助程序完成...")
+	return a.waitElevated(ctx, task, process)
+}
 
-	// 7. 等待结果（动态超时：收到进度就重置超时）
-	timeout := time.NewTimer(30 * time.Second)
-	defer timeout.Stop()
+// 辅助程序存活检测
+const (
+	helperStartTimeout     = 30 * time.Second // 启动后（UAC 已确认）等待 started 消息
+	helperHeartbeatTimeout = 30 * time.Second // 执行期间超过该时间没有任何消息视为无响应
+	helperResultGrace      = 2 * time.Second  // 进程退出后等待仍在传输的结果
+)
 
+// waitElevated 等待辅助程序的结果，转发进度。根据辅助程序的生命周期消息和进程状态判断它是否仍在运行，
+// 而不是按固定时间超时：单个目录耗时很长时辅助程序仍会定期发送心跳。
+// ctx 取消时通知辅助程序停止，并等待它返回已完成的部分结果
+func (a *App) waitElevated(ctx context.Context, task *elevatedTask, process windows.Handle) (*ElevatedResult, error) {
+	exited := watchProcess(process)
+	check := time.NewTicker(time.Second)
+	defer check.Stop()
+
+	state := ""
+	lastSeen := time.Now()
+	cancelled := ctx.Done()
 	for {
 		select {
-		case result := <-resultChan:
-			runtime.LogInfo(a.ctx, "收到清理结果")
+		case result := <-task.result:
 			return result, nil
 
-		case progress := <-progressChan:
-			// 收到进度，重置超时
-			timeout.Reset(30 * time.Second)
-			runtime.LogDebugf(a.ctx, "进度: %d/%d, 已清理: %d MB",
-				progress.ProcessedPaths, progress.TotalPaths, progress.CleanedSize/1024/1024)
-
-			// 通知前端更新进度
+		case progress := <-task.progress:
+			lastSeen = time.Now()
 			runtime.EventsEmit(a.ctx, "clean-progress", progress)
 
-		case <-timeout.C:
-			runtime.LogError(a.ctx, "清理超时！可能原因：")
-			runtime.LogError(a.ctx, "1. UAC 窗口被取消（点击了\"否\"）")
-			runtime.LogError(a.ctx, "2. UAC 窗口在后台等待确认（请检查任务栏）")
-			runtime.LogError(a.ctx, "3. 辅助程序启动失败")
-			return &ElevatedResult{
-				Success: false,
-				Error:   "清理超时（30秒无响应）。请确认是否在UAC窗口中点击了\"是\"",
-			}, nil
+		case status := <-task.status:
+			lastSeen = time.Now()
+			if status.State != ipc.StateHeartbeat {
+				fmt.Fprintf(os.Stderr, "[DEBUG] Elevated task %s: %s (pid=%d)\n", task.id, status.State, status.PID)
+			}
+			if state != ipc.StateDone {
+				state = status.State
+			}
+
+		case code := <-exited:
+			// 辅助程序已退出，结果可能仍在传输
+			select {
+			case result := <-task.result:
+				return result, nil
+			case <-time.After(helperResultGrace):
+			}
+			if ctx.Err() != nil {
+				return markCancelled(ctx, &ElevatedResult{Success: true}), nil
+			}
+			switch state {
+			case "":
+				return nil, apperr.New(apperr.HelperStartExited, "exitCode", code)
+			case ipc.StateDone:
+				return nil, apperr.New(apperr.HelperNoResult)
+			}
+			return nil, apperr.New(apperr.HelperExited, "exitCode", code)
+
+		case <-cancelled:
+			// 辅助程序通过 /elevated-cancel 得知取消，停止后仍会返回部分结果
+			runtime.LogInfo(a.ctx, "已请求辅助程序取消任务")
+			a.resultsMutex.Lock()
+			a.elevatedCancel[task.id] = true
+			a.resultsMutex.Unlock()
+			cancelled = nil
+
+		case <-check.C:
+			limit := helperHeartbeatTimeout
+			if state == "" {
+				limit = helperStartTimeout
+			}
+			if time.Since(lastSeen) < limit {
+				continue
+			}
+			if ctx.Err() != nil {
+				return markCancelled(ctx, &ElevatedResult{Success: true}), nil
+			}
+			switch state {
+			case "":
+				return nil, apperr.New(apperr.HelperNotStarted, "seconds", int(limit.Seconds()))
+			case ipc.StateDone:
+				return nil, apperr.New(apperr.HelperNoResult)
+			}
+			return nil, apperr.New(apperr.HelperTimeout, "seconds", int(limit.Seconds()))
 		}
 	}
 }
 
-func (a *App) shellExecuteElevated(exePath, args string) error {
-	verb, _ := syscall.UTF16PtrFromString("runas")
-	exe, _ := syscall.UTF16PtrFromString(exePath)
-	params, _ := syscall.UTF16PtrFromString(args)
-
-	var showCmd int32 = windows.SW_HIDE // 隐藏窗口
-
-	// ShellExecute 返回值 > 32 表示成功，<= 32 表示错误
-	ret, _, _ := syscall.NewLazyDLL("shell32.dll").NewProc("ShellExecuteW").Call(
-		0, // hwnd
-		uintptr(unsafe.Pointer(verb)),
-		uintptr(unsafe.Pointer(exe)),
-		uintptr(unsafe.Pointer(params)),
-		0, // dir
-		uintptr(showCmd),
-	)
-
-	fmt.Printf("[DEBUG] ShellExecute return value: %d\n", ret)
-	runtime.LogDebugf(a.ctx, "ShellExecute return value: %d", ret)
-
-	if ret <= 32 {
-		// 返回值 <= 32 表示错误
-		// 常见错误码：
-		// 0 = 内存不足
-		// 2 = 文件未找到
-		// 3 = 路径未找到
-		// 5 = 访问被拒绝
-		// 8 = 内存不足
-		// 26 = 共享冲突
-		// 27 = 文件关联不完整
-		// 28 = DDE 超时
-		// 29 = DDE 失败
-		// 30 = DDE 忙
-		// 31 = 没有关联
-		// 32 = DLL 未找到
-		return fmt.Errorf("ShellExecute failed with code %d", ret)
+// watchProcess 进程退出时发送退出码并关闭句柄，process 为 0 时返回 nil（永不发送）
+func watchProcess(process windows.Handle) <-chan uint32 {
+	if process == 0 {
+		return nil
 	}
+	exited := make(chan uint32, 1)
+	go func() {
+		defer windows.CloseHandle(process)
+		windows.WaitForSingleObject(process, windows.INFINITE)
+		var code uint32
+		windows.GetExitCodeProcess(process, &code)
+		exited <- code
+	}()
+	return exited
+}
 
-	return nil
+// ExportCleanManifest 导出删除清单（试运行结果）到文件
+func (a *App) ExportCleanManifest(manifest *models.CleanManifest, path string) error {
+	return services.ExportCleanManifest(manifest, path)
 }
 
-// cleanLogFilesInPath 清理指定路径下的所有 .log 文件
-func (a *App) cleanLogFilesInPath(dirPath string) (int64, int) {
-	var cleanedSize int64
-	var cleanedCount int
+// ReplayCleanManifest 按导出的删除清单删除文件（只删除清单中且未变化的文件）
+func (a *App) ReplayCleanManifest(path string, opts models.CleanOptions) (*ElevatedResult, error) {
+	manifest, err := services.LoadCleanManifest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, done := a.beginOperation(opts.OpID)
+	defer done()
+	defer a.invalidateManifest(manifest)
 
-	filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // 跳过无权限的文件
+	// 清单中包含需要管理员权限的清理项时交给辅助程序重放
+	needsAdmin := false
+	for _, entry := range manifest.Entries {
+		if rule, ok := a.catalog.Rule(entry.ItemID); ok && rule.NeedsAdmin {
+			needsAdmin = true
+			break
 		}
+	}
 
-		if !info.IsDir() && filepath.Ext(path) == ".log" {
-			size := info.Size()
-			if err := os.Remove(path); err == nil {
-				cleanedSize += size
-				cleanedCount++
+	items := a.manifestItems(manifest)
+	if needsAdmin && !a.IsElevated() {
+		record := a.beginHistory(history.KindClean, true)
+		spec := &ipc.TaskFile{Task: "clean-manifest", Manifest: path}
+		if opts.Quarantine {
+			if opts.RunID == "" {
+				opts.RunID = quarantine.NewRunID()
 			}
+			spec.QuarantineRun = opts.RunID
 		}
-		return nil
-	})
+		runtime.LogInfof(a.ctx, "按清单清理 %d 个文件（管理员权限）", len(manifest.Entries))
+		result, err := a.runElevatedTask(ctx, spec)
+		if err != nil {
+			a.recordClean(ctx, record, items, nil, err)
+			return failedResult(err), nil
+		}
+		result.RunID = opts.RunID
+		result = markCancelled(ctx, result)
+		a.recordClean(ctx, record, items, result, nil)
+		return result, nil
+	}
+
+	run, err := a.beginQuarantine(&opts)
+	if err != nil {
+		return failedResult(err), nil
+	}
+	if run != nil {
+		defer run.Close()
+	}
+
+	record := a.beginHistory(history.KindClean, a.IsElevated())
+	report := services.NewCleanReport()
+	if _, _, err := a.cleanService.ReplayManifest(ctx, manifest, run, report); err != nil && !ops.Cancelled(ctx) {
+		a.recordClean(ctx, record, items, &ElevatedResult{Report: report}, err)
+		return failedResult(err), nil
+	}
+	result := markCancelled(ctx, newCleanResult(report, opts.RunID))
+	a.recordClean(ctx, record, items, result, nil)
+	return result, nil
+}
 
-	return cleanedSize, cleanedCount
+// manifestItems 删除清单中的清理项（用于清理记录）
+func (a *App) manifestItems(manifest *models.CleanManifest) []*models.CleanItem {
+	var items []*models.CleanItem
+	seen := make(map[string]bool)
+	for _, entry := range manifest.Entries {
+		if seen[entry.ItemID] {
+			continue
+		}
+		seen[entry.ItemID] = true
+		item := &models.CleanItem{ID: entry.ItemID}
+		if rule, ok := a.catalog.Rule(entry.ItemID); ok {
+			item.Name = rule.Name
+		}
+		items = append(items, item)
+	}
+	return items
 }
 
-// cleanItemDirect 直接清理（已有管理员权限）
-func (a *App) cleanItemDirect(item *models.CleanItem) (*ElevatedResult, error) {
-	result := &ElevatedResult{Success: true}
+// CleanItemElevated 以管理员权限清理项目；试运行时不删除任何文件，返回将要删除的文件清单
+func (a *App) CleanItemElevated(item *models.CleanItem, opts models.CleanOptions) (*ElevatedResult, error) {
+	ctx, done := a.beginOperation(opts.OpID)
+	defer done()
+	var record *history.Record
+	if !opts.DryRun {
+		defer a.invalidateItems([]*models.CleanItem{item})
+		record = a.beginHistory(history.KindClean, true)
+	}
+	result, err := a.cleanItemElevated(ctx, item, opts)
+	result = markCancelled(ctx, result)
+	a.recordClean(ctx, record, []*models.CleanItem{item}, result, err)
+	return result, err
+}
+
+// cleanItemElevated 以管理员权限清理项目（ctx 取消时停止）
+func (a *App) cleanItemElevated(ctx context.Context, item *models.CleanItem, opts models.CleanOptions) (*ElevatedResult, error) {
 	itemID := item.ID
+	if opts.Quarantine && opts.RunID == "" {
+		opts.RunID = quarantine.NewRunID()
+	}
 
-	// 使用扫描结果中的路径
-	fmt.Printf("[DEBUG] cleanItemDirect: Using %d paths from scan results\n", len(item.Paths))
-	var paths []string
+	rule, ok := a.catalog.Rule(itemID)
+	if !ok {
+		return failedResult(apperr.New(apperr.UnknownItem, "id", itemID)), nil
+	}
+
+	// 1. 检查是否已经提升了权限
+	isAdmin := a.IsAdmin()
+	isElevated := a.IsElevated()
+	fmt.Fprintf(os.Stderr, "[DEBUG] CleanItemElevated: itemID=%s, isAdmin=%v, isElevated=%v, dryRun=%v\n", itemID, isAdmin, isElevated, opts.DryRun)
+
+	if opts.DryRun && (isElevated || rule.Handler != catalog.HandlerFolder) {
+		// 本进程可以读取，直接生成删除清单，不删除任何文件
+		manifest := services.NewCleanManifest()
+		a.cleanService.PlanItem(ctx, item, manifest)
+		return &ElevatedResult{Success: true, Manifest: manifest}, nil
+	}
+
+	// 已经提升了权限，或者是不通过辅助程序的特殊项（回收站、日志文件），直接执行（不会弹UAC）
+	if isElevated || rule.Handler != catalog.HandlerFolder {
+		fmt.Fprintln(os.Stderr, "[DEBUG] Executing directly")
+		run, err := a.beginQuarantine(&opts)
+		if err != nil {
+			return failedResult(err), nil
+		}
+		if run != nil {
+			defer run.Close()
+		}
+		ctx, _, finish := a.beginCleanProgress(ctx, []*models.CleanItem{item}, true)
+		defer finish()
+
+		result := a.cleanItemDirect(ctx, item, run)
+		result.RunID = opts.RunID
+		return result, nil
+	}
+
+	// 2. 获取要清理的路径列表
+	fmt.Fprintf(os.Stderr, "[DEBUG] Using %d paths from scan results\n", len(item.Paths))
+	var paths []ipc.TaskPath
 	for _, pathDetail := range item.Paths {
-		paths = append(paths, pathDetail.Path)
+		paths = append(paths, ipc.TaskPath{Path: pathDetail.Path, Item: itemID})
 	}
 
 	if len(paths) == 0 {
-		return &ElevatedResult{
-			Success: false,
-			Error:   fmt.Sprintf("没有找到要清理的路径 (itemID: %s)", itemID),
-		}, nil
+		return failedResult(apperr.New(apperr.NoPaths, "id", itemID)), nil
 	}
 
-	// 清理所有路径
-	for _, path := range paths {
-		size, count := a.cleanFolderAndCount(path)
-		result.CleanedSize += size
-		result.CleanedCount += count
+	// 3. 构造任务文件
+	spec := &ipc.TaskFile{Task: "clean-item-" + itemID, Paths: paths, Filters: a.taskFilters(paths), DryRun: opts.DryRun}
+	if opts.Quarantine {
+		spec.QuarantineRun = opts.RunID
 	}
 
+	// 4. 启动提升的辅助程序并等待结果（如果看到UAC窗口，请点击"是"以继续）
+	runtime.LogDebugf(a.ctx, "Task: %s, %d paths", spec.Task, len(paths))
+	result, err := a.runElevatedTask(ctx, spec)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "清理失败: %v", err)
+		return failedResult(err), nil
+	}
+
+	runtime.LogInfo(a.ctx, "收到清理结果")
+	result.RunID = opts.RunID
+	if opts.DryRun && result.Manifest == nil {
+		result.Manifest = services.NewCleanManifest()
+	}
 	return result, nil
 }
 
-// getCleanPaths 获取清理路径列表（统一从CleanService获取，避免硬编码）
-func (a *App) getCleanPaths(itemID string) []string {
-	switch itemID {
-	case "1": // 系统临时文件
-		return []string{
-			a.cleanService.GetTempPath(),
-			a.cleanService.GetSystemTempPath(),
-		}
-	case "2": // 浏览器缓存
-		return a.cleanService.GetBrowserCachePaths()
-	case "3": // 回收站
-		return []string{a.cleanService.GetRecycleBinPath()}
-	case "4": // Windows更新缓存
-		return a.cleanService.GetWindowsUpdateCachePaths()
-	case "5": // 系统文件清理
-		return a.cleanService.GetSystemFilePaths()
-	case "6": // 应用缓存
-		return a.cleanService.GetAppCachePaths()
-	case "7": // 应用日志文件
-		// 日志文件通过 CleanLogFiles 特殊处理，不使用路径清理
-		return []string{}
-	default:
-		return []string{}
-	}
-}
-
-// cleanFolderAndCount 清理文件夹并统计
-func (a *App) cleanFolderAndCount(path string) (int64, int) {
-	var totalSize int64
-	var count int
-
-	filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
+// shellExecuteInfo SHELLEXECUTEINFOW
+type shellExecuteInfo struct {
+	cbSize       uint32
+	fMask        uint32
+	hwnd         uintptr
+	lpVerb       *uint16
+	lpFile       *uint16
+	lpParameters *uint16
+	lpDirectory  *uint16
+	nShow        int32
+	hInstApp     uintptr
+	lpIDList     uintptr
+	lpClass      *uint16
+	hkeyClass    uintptr
+	dwHotKey     uint32
+	hIcon        uintptr
+	hProcess     windows.Handle
+}
 
-		if !info.IsDir() {
-			totalSize += info.Size()
-			count++
-			os.Remove(filePath)
+const (
+	seeMaskNoCloseProcess = 0x00000040 // 返回进程句柄
+	seeMaskNoAsync        = 0x00000100
+	seeMaskFlagNoUI       = 0x00000400 // 不显示错误对话框
+)
+
+// shellExecuteElevated 以管理员权限启动程序（弹出 UAC 提示，用户确认或拒绝后返回），返回进程句柄。
+// 用户拒绝时返回 ErrUACDeclined
+func (a *App) shellExecuteElevated(exePath, args string) (windows.Handle, error) {
+	verb, _ := syscall.UTF16PtrFromString("runas")
+	exe, _ := syscall.UTF16PtrFromString(exePath)
+	params, _ := syscall.UTF16PtrFromString(args)
+
+	info := shellExecuteInfo{
+		fMask:        seeMaskNoCloseProcess | seeMaskNoAsync | seeMaskFlagNoUI,
+		lpVerb:       verb,
+		lpFile:       exe,
+		lpParameters: params,
+		nShow:        windows.SW_HIDE, // 隐藏窗口
+	}
+	info.cbSize = uint32(unsafe.Sizeof(info))
+
+	ret, _, callErr := syscall.NewLazyDLL("shell32.dll").NewProc("ShellExecuteExW").Call(uintptr(unsafe.Pointer(&info)))
+	fmt.Fprintf(os.Stderr, "[DEBUG] ShellExecuteEx return value: %d, error: %v\n", ret, callErr)
+
+	if ret == 0 {
+		if errors.Is(callErr, windows.ERROR_CANCELLED) {
+			return 0, ErrUACDeclined
 		}
-		return nil
-	})
+		return 0, apperr.Wrap(apperr.ElevateFailed, callErr)
+	}
+	return info.hProcess, nil
+}
 
-	return totalSize, count
+// isFolderItem 判断清理项是否按目录清理（回收站、日志文件等特殊项不通过辅助程序）
+func (a *App) isFolderItem(item *models.CleanItem) bool {
+	rule, ok := a.catalog.Rule(item.ID)
+	return ok && rule.Handler == catalog.HandlerFolder
+}
+
+// cleanItemDirect 在本进程中按清理项的处理方式清理（回收站、日志文件或按目录清理，
+// run 不为空时移入隔离区），与应用内清理使用同一个 CleanService.CleanItem
+func (a *App) cleanItemDirect(ctx context.Context, item *models.CleanItem, run *quarantine.Run) *ElevatedResult {
+	fmt.Fprintf(os.Stderr, "[DEBUG] cleanItemDirect: %s, %d paths from scan results\n", item.ID, len(item.Paths))
+	report := services.NewCleanReport()
+	if err := a.cleanService.CleanItem(ctx, item, run, report); err != nil {
+		return failedResult(err)
+	}
+	return newCleanResult(report, "")
 }
@@ -0,0 +1,303 @@
+// Package apperr 结构化错误：错误码、严重程度、参数和原始错误。提示文字不写在代码里，
+// 而是按错误码从各语言的消息表中生成，前端可以按错误码处理，非中文用户也能看懂。
+// 程序窗口的所有方法（经 Wails 的 ErrorFormatter）、辅助程序的结果都以这种形式返回错误。
+package apperr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Code 错误码
+type Code string
+
+const (
+	Failed            Code = "failed"              // 未分类的错误，提示文字为原始错误
+	Cancelled         Code = "cancelled"           // 操作被取消
+	NotFound          Code = "not-found"           // 文件或目录不存在
+	AccessDenied      Code = "access-denied"       // 权限不足
+	Protected         Code = "protected"           // 受保护的路径
+	UnknownItem       Code = "unknown-item"        // 未知的清理项（id）
+	InvalidTime       Code = "invalid-time"        // 无效的时间（value）
+	NoScanResults     Code = "no-scan-results"     // 还没有扫描结果
+	NoPaths           Code = "no-paths"            // 清理项没有找到要清理的路径（id）
+	NeedsAdmin        Code = "needs-admin"         // 需要管理员权限
+	AlreadyElevated   Code = "already-elevated"    // 已以管理员身份运行，不需要辅助程序
+	ExePath           Code = "exe-path"            // 无法获取程序路径
+	NoPruneTime       Code = "no-prune-time"       // 删除记录时没有指定时间
+	HelperMissing     Code = "helper-missing"      // 辅助程序不存在（helper）
+	HelperStartFailed Code = "helper-start-failed" // 启动辅助程序失败
+	UACDeclined       Code = "uac-declined"        // 用户拒绝了 UAC 提示
+	HelperNotStarted  Code = "helper-not-started"  // 辅助程序启动后没有响应（seconds）
+	HelperStartExited Code = "helper-start-exited" // 辅助程序启动后立即退出（exitCode，未知时没有）
+	HelperTimeout     Code = "helper-timeout"      // 辅助程序执行期间没有心跳（seconds）
+	HelperExited      Code = "helper-exited"       // 辅助程序意外退出（exitCode）
+	HelperNoResult    Code = "helper-no-result"    // 辅助程序已退出但没有收到结果
+	RulesMismatch     Code = "rules-mismatch"      // 辅助程序与主程序的清理规则不一致（id）
+	TaskVersion       Code = "task-version"        // 任务文件版本不一致（version、expected）
+	UnknownTask       Code = "unknown-task"        // 辅助程序不支持的任务（task）
+	CommandFailed     Code = "command-failed"      // 系统命令执行失败（output）
+	RecycleBinBusy    Code = "recycle-bin-busy"    // 回收站被占用
+	RestoreIncomplete Code = "restore-incomplete"  // 部分文件未能从隔离区恢复（count）
+
+	// 文件读写（file 为文件路径）
+	ReadFailed   Code = "read-failed"    // 无法读取文件或目录（file）
+	FileInvalid  Code = "file-invalid"   // 文件格式错误（file）
+	FileVersion  Code = "file-version"   // 不支持的文件格式版本（file、version）
+	SaveFailed   Code = "save-failed"    // 保存文件失败（file）
+	DeleteFailed Code = "delete-failed"  // 删除失败（path）
+	PathNotFound Code = "path-not-found" // 路径不存在（path）
+	OpenFailed   Code = "open-failed"    // 无法在资源管理器中打开（path）
+	ExpandFailed Code = "expand-failed"  // 无法展开环境变量（path）
+	OutsideRoot  Code = "outside-root"   // 路径超出根目录
+	InvalidDrive Code = "invalid-drive"  // 无效的分区（drive）
+	NotSupported Code = "not-supported"  // 仅支持 Windows 的功能
+	DiskInfo     Code = "disk-info"      // 无法获取磁盘信息
+	UserProfile  Code = "user-profile"   // 无法获取用户配置文件路径
+
+	// 清理和系统优化
+	RecycleBinFailed    Code = "recycle-bin-failed"    // 清空回收站失败
+	RecycleBinQuery     Code = "recycle-bin-query"     // 查询回收站失败
+	PartialClean        Code = "partial-clean"         // 部分路径清理失败
+	ElevateFailed       Code = "elevate-failed"        // 以管理员身份重新启动失败
+	UnknownOptimizeItem Code = "unknown-optimize-item" // 未知的优化项类型（type）
+	HibernateFailed     Code = "hibernate-failed"      // 禁用休眠失败（output）
+	RestorePointsFailed Code = "restore-points-failed" // 清理系统还原点失败（output）
+	PagefileFailed      Code = "pagefile-failed"       // 禁用虚拟内存失败（output）
+	WeChatNotFound      Code = "wechat-not-found"      // 未检测到微信
+	InvalidSize         Code = "invalid-size"          // 无效的大小（value）
+
+	// 清理规则和过滤条件
+	RuleMissingID      Code = "rule-missing-id"      // 规则缺少 id 或 name（index，从 1 开始）
+	DuplicateRule      Code = "duplicate-rule"       // 规则 id 重复（id）
+	UnknownHandler     Code = "unknown-handler"      // 未知的处理方式（id、handler）
+	UnknownRisk        Code = "unknown-risk"         // 未知的风险等级（id、risk）
+	FilterNotSupported Code = "filter-not-supported" // 清理项不支持过滤条件（id）
+	InvalidFilter      Code = "invalid-filter"       // 清理项的过滤条件无效（id）
+	InvalidDuration    Code = "invalid-duration"     // 无效的时长（value）
+	UnknownAgeBy       Code = "unknown-age-by"       // 未知的年龄依据（value）
+	NegativeDepth      Code = "negative-depth"       // maxDepth 为负数
+	InvalidGlob        Code = "invalid-glob"         // 无效的通配符（pattern）
+
+	// 用户设置
+	SettingsNewer     Code = "settings-newer"      // 设置文件由更新版本的程序创建
+	SettingsReadOnly  Code = "settings-read-only"  // 设置文件由更新版本的程序创建，不能保存
+	LargeFileMinSize  Code = "large-file-min-size" // 大文件最小大小小于 1 MB
+	UnknownCategory   Code = "unknown-category"    // 未知的大文件分类（category）
+	CategoryMinSize   Code = "category-min-size"   // 大文件分类的最小大小小于 1 MB（category）
+	UnknownHelperMode Code = "unknown-helper-mode" // 未知的辅助程序启动方式（mode）
+	UnsupportedLocale Code = "unsupported-locale"  // 不支持的语言（locale）
+
+	// 自动清理方案
+	ProfileNotFound      Code = "profile-not-found"      // 方案不存在
+	ProfileInvalid       Code = "profile-invalid"        // 方案无效（name），原因为具体的错误
+	DuplicateProfile     Code = "duplicate-profile"      // 方案 ID 重复（id）
+	ProfileNoName        Code = "profile-no-name"        // 方案名称为空
+	ProfileNoItems       Code = "profile-no-items"       // 方案没有清理项
+	NegativeFreeBelow    Code = "negative-free-below"    // 剩余空间阈值为负数
+	UnknownAdminMode     Code = "unknown-admin-mode"     // 未知的管理员权限处理方式（mode）
+	DryRunQuarantine     Code = "dry-run-quarantine"     // 试运行不能使用隔离区
+	InvalidSchedule      Code = "invalid-schedule"       // 计划不是 5 个字段（schedule）
+	InvalidScheduleField Code = "invalid-schedule-field" // 计划的字段无效（schedule、position、value、min、max）
+	ItemSkippedAdmin     Code = "item-skipped-admin"     // 需要管理员权限，已跳过
+	ItemQueuedAdmin      Code = "item-queued-admin"      // 需要管理员权限，已排队
+
+	// 剩余空间监控
+	IntervalTooShort     Code = "interval-too-short"     // 采样间隔太短（seconds）
+	ThresholdNotPositive Code = "threshold-not-positive" // 剩余空间阈值不大于 0
+	NegativeDrop         Code = "negative-drop"          // 下降提醒的大小为负数
+	DropWindowTooShort   Code = "drop-window-too-short"  // 下降提醒的时间范围小于采样间隔
+
+	// 其他
+	UnknownOperation   Code = "unknown-operation"    // 操作不存在或已经结束
+	SnapshotRunning    Code = "snapshot-running"     // 正在生成目录快照
+	QuarantineNoVolume Code = "quarantine-no-volume" // 路径不在本地分区上，无法隔离
+	QuarantineUnsafe   Code = "quarantine-unsafe"    // 管理员隔离区可以被普通用户修改
+	QuarantineFull     Code = "quarantine-full"      // 隔离区容量不足，文件未清理
+	InvalidRun         Code = "invalid-run"          // 无效的清理批次 ID（id）
+	RunNotFound        Code = "run-not-found"        // 隔离区中没有该清理批次（id）
+	UnknownTaskID      Code = "unknown-task-id"      // 未知的辅助程序任务 ID
+	BadSignature       Code = "bad-signature"        // 辅助程序请求签名无效
+	ReplayedRequest    Code = "replayed-request"     // 重复的辅助程序请求
+	RequestRejected    Code = "request-rejected"     // 主程序拒绝了辅助程序的请求（status）
+	TaskFileSignature  Code = "task-file-signature"  // 任务文件校验失败
+	UnsupportedFormat  Code = "unsupported-format"   // 不支持的报告格式（format）
+	ManifestVersion    Code = "manifest-version"     // 不支持的删除清单版本（version）
+)
+
+// Severity 严重程度
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"    // 不是故障，如用户取消
+	SeverityWarning Severity = "warning" // 部分完成或需要用户处理
+	SeverityError   Severity = "error"
+)
+
+// severities 各错误码的默认严重程度，未列出的为 error
+var severities = map[Code]Severity{
+	Cancelled:         SeverityInfo,
+	UACDeclined:       SeverityWarning,
+	NoScanResults:     SeverityWarning,
+	NeedsAdmin:        SeverityWarning,
+	AlreadyElevated:   SeverityInfo,
+	RestoreIncomplete: SeverityWarning,
+	PartialClean:      SeverityWarning,
+	ItemSkippedAdmin:  SeverityWarning,
+	ItemQueuedAdmin:   SeverityInfo,
+	SnapshotRunning:   SeverityInfo,
+	SettingsNewer:     SeverityWarning,
+}
+
+// Error 结构化错误
+type Error struct {
+	Code     Code
+	Severity Severity
+	Params   map[string]any // 提示文字中的参数，如 {id}
+	Cause    error          // 原始错误，提示文字后附上其内容
+}
+
+// New 创建错误，params 为成对的参数名和值，如 New(UnknownItem, "id", "3")
+func New(code Code, params ...any) *Error {
+	return Wrap(code, nil, params...)
+}
+
+// Wrap 创建带原始错误的错误
+func Wrap(code Code, cause error, params ...any) *Error {
+	e := &Error{Code: code, Severity: SeverityError, Cause: cause}
+	if severity, ok := severities[code]; ok {
+		e.Severity = severity
+	}
+	for i := 0; i+1 < len(params); i += 2 {
+		if e.Params == nil {
+			e.Params = make(map[string]any, len(params)/2)
+		}
+		e.Params[fmt.Sprint(params[i])] = params[i+1]
+	}
+	return e
+}
+
+// From 把任意错误转换为结构化错误：已经是结构化错误时直接返回，
+// 取消、文件不存在和权限不足按类型识别，其余为 Failed
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return Wrap(Cancelled, err)
+	case errors.Is(err, os.ErrNotExist):
+		return Wrap(NotFound, err)
+	case errors.Is(err, os.ErrPermission):
+		return Wrap(AccessDenied, err)
+	}
+	return Wrap(Failed, err)
+}
+
+// Error 当前语言的提示文字
+func (e *Error) Error() string {
+	return e.Message(Locale())
+}
+
+// Message 指定语言的提示文字
+func (e *Error) Message(locale string) string {
+	template, ok := catalog(locale)[e.Code]
+	if !ok {
+		template, ok = messages[DefaultLocale][e.Code]
+	}
+	if !ok {
+		template = string(e.Code)
+	}
+
+	cause := ""
+	if e.Cause != nil {
+		cause = e.Cause.Error()
+	}
+	replacements := []string{"{cause}", cause}
+	for name, value := range e.Params {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	message := strings.NewReplacer(replacements...).Replace(template)
+	if cause != "" && !strings.Contains(template, "{cause}") {
+		message += ": " + cause
+	}
+	return message
+}
+
+// Unwrap 原始错误
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is 错误码相同即视为同一错误
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// payload 错误的传输形式（前端和辅助程序协议），message 为当前语言的提示文字
+type payload struct {
+	Code     Code           `json:"code"`
+	Severity Severity       `json:"severity"`
+	Params   map[string]any `json:"params,omitempty"`
+	Message  string         `json:"message"`
+	Cause    string         `json:"cause,omitempty"`
+}
+
+// MarshalJSON 输出错误码、参数和当前语言的提示文字
+func (e *Error) MarshalJSON() ([]byte, error) {
+	p := payload{Code: e.Code, Severity: e.Severity, Params: e.Params, Message: e.Error()}
+	if e.Cause != nil {
+		p.Cause = e.Cause.Error()
+	}
+	return json.Marshal(p)
+}
+
+// UnmarshalJSON 读取错误码和参数（原始错误只保留文字，提示文字按本程序的语言重新生成）
+func (e *Error) UnmarshalJSON(data []byte) error {
+	var p payload
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // 退出码等整数参数保持原样
+	if err := decoder.Decode(&p); err != nil {
+		return err
+	}
+	*e = Error{Code: p.Code, Severity: p.Severity, Params: p.Params}
+	if p.Cause != "" {
+		e.Cause = errors.New(p.Cause)
+	}
+	return nil
+}
+
+// 当前语言
+var (
+	localeMu sync.RWMutex
+	locale   = DefaultLocale
+)
+
+// Locale 当前语言
+func Locale() string {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	return locale
+}
+
+// SetLocale 设置提示文字的语言
+func SetLocale(l string) error {
+	if _, ok := messages[l]; !ok {
+		return New(UnsupportedLocale, "locale", l)
+	}
+	localeMu.Lock()
+	locale = l
+	localeMu.Unlock()
+	return nil
+}
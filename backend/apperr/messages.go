@@ -0,0 +1,240 @@
+package apperr
+
+import "sort"
+
+// DefaultLocale 默认语言，其他语言缺少的提示文字也使用它
+const DefaultLocale = "zh-CN"
+
+// messages 各语言的提示文字，{name} 为参数，{cause} 为原始错误（不含 {cause} 时原始错误附在最后）
+var messages = map[string]map[Code]string{
+	"zh-CN": {
+		Failed:            "{cause}",
+		Cancelled:         "操作已取消",
+		NotFound:          "文件或目录不存在",
+		AccessDenied:      "权限不足，请以管理员身份运行",
+		Protected:         "{path} 是受保护的路径，不能删除",
+		UnknownItem:       "未知的清理项: {id}",
+		InvalidTime:       "无效的时间: {value}",
+		NoScanResults:     "还没有扫描结果，请先扫描后再导出报告",
+		NoPaths:           "没有找到要清理的路径（清理项 {id}）",
+		NeedsAdmin:        "需要管理员权限，请以管理员身份运行",
+		AlreadyElevated:   "程序已以管理员身份运行，不需要辅助程序",
+		ExePath:           "无法获取程序路径",
+		NoPruneTime:       "请指定删除哪个时间之前的记录",
+		HelperMissing:     "辅助程序 {helper} 不存在，请确保它与主程序在同一目录",
+		HelperStartFailed: "启动辅助程序失败",
+		UACDeclined:       "已取消管理员权限请求（UAC 提示被拒绝）",
+		HelperNotStarted:  "辅助程序未启动（{seconds} 秒内没有响应）",
+		HelperStartExited: "辅助程序启动后立即退出",
+		HelperTimeout:     "辅助程序无响应（{seconds} 秒内没有心跳）",
+		HelperExited:      "辅助程序意外退出（退出码 {exitCode}），未返回结果",
+		HelperNoResult:    "辅助程序已完成任务，但未收到结果",
+		RulesMismatch:     "清理项 {id} 的规则与主程序不一致，请确保辅助程序与主程序使用同一份规则",
+		TaskVersion:       "任务文件版本不一致（{version}，需要 {expected}），请确保辅助程序与主程序版本相同",
+		UnknownTask:       "辅助程序不支持的任务: {task}",
+		CommandFailed:     "命令执行失败（{cause}），输出: {output}",
+		RecycleBinBusy:    "回收站被占用或系统状态异常，请关闭资源管理器中的回收站窗口后重试",
+		RestoreIncomplete: "{count} 个文件未能恢复（原位置已有同名文件、权限不足，或需要以普通权限恢复），仍保留在隔离区",
+
+		ReadFailed:   "无法读取 {file}",
+		FileInvalid:  "{file} 格式错误",
+		FileVersion:  "{file} 的格式版本 {version} 不受支持，请更新程序",
+		SaveFailed:   "保存 {file} 失败",
+		DeleteFailed: "删除 {path} 失败",
+		PathNotFound: "{path} 不存在",
+		OpenFailed:   "无法打开 {path}",
+		ExpandFailed: "无法展开路径中的环境变量: {path}",
+		OutsideRoot:  "路径超出根目录",
+		InvalidDrive: "无效的分区: {drive}",
+		NotSupported: "该功能仅支持 Windows",
+		DiskInfo:     "无法获取磁盘信息",
+		UserProfile:  "无法获取用户配置文件路径",
+
+		RecycleBinFailed:    "清空回收站失败",
+		RecycleBinQuery:     "查询回收站失败",
+		PartialClean:        "部分路径清理失败（文件可能正在使用）",
+		ElevateFailed:       "请求管理员权限失败",
+		UnknownOptimizeItem: "未知的优化项类型: {type}",
+		HibernateFailed:     "禁用休眠失败（{cause}），输出: {output}",
+		RestorePointsFailed: "清理系统还原点失败（{cause}），输出: {output}",
+		PagefileFailed:      "禁用虚拟内存失败（{cause}），输出: {output}",
+		WeChatNotFound:      "未检测到微信安装",
+		InvalidSize:         "无效的大小: {value}（如 500MB、2GB）",
+
+		RuleMissingID:      "第 {index} 条规则缺少 id 或 name",
+		DuplicateRule:      "规则 id 重复: {id}",
+		UnknownHandler:     "规则 {id} 的处理方式未知: {handler}",
+		UnknownRisk:        "规则 {id} 的风险等级未知: {risk}",
+		FilterNotSupported: "清理项 {id} 不支持过滤条件",
+		InvalidFilter:      "清理项 {id} 的过滤条件无效",
+		InvalidDuration:    "无效的时长: {value}（如 \"30m\"、\"24h\"、\"7d\"）",
+		UnknownAgeBy:       "未知的年龄依据: {value}",
+		NegativeDepth:      "maxDepth 不能为负数",
+		InvalidGlob:        "无效的通配符: {pattern}",
+
+		SettingsNewer:     "设置文件由更新版本的程序创建，将使用默认设置且不会覆盖该文件",
+		SettingsReadOnly:  "设置文件由更新版本的程序创建，不能保存",
+		LargeFileMinSize:  "大文件最小大小不能小于 1 MB",
+		UnknownCategory:   "未知的大文件分类: {category}",
+		CategoryMinSize:   "大文件分类 {category} 的最小大小不能小于 1 MB",
+		UnknownHelperMode: "未知的辅助程序启动方式: {mode}",
+		UnsupportedLocale: "不支持的语言: {locale}",
+
+		ProfileNotFound:      "清理方案不存在",
+		ProfileInvalid:       "清理方案 {name}: {cause}",
+		DuplicateProfile:     "清理方案 ID 重复: {id}",
+		ProfileNoName:        "清理方案名称不能为空",
+		ProfileNoItems:       "清理方案至少需要一个清理项",
+		NegativeFreeBelow:    "剩余空间阈值不能为负数",
+		UnknownAdminMode:     "未知的管理员权限处理方式: {mode}",
+		DryRunQuarantine:     "试运行不删除文件，不能同时使用隔离区",
+		InvalidSchedule:      "无效的计划 {schedule}：需要 5 个字段（分 时 日 月 星期）",
+		InvalidScheduleField: "无效的计划 {schedule}：第 {position} 个字段中的 {value} 无效（取值范围 {min}-{max}）",
+		ItemSkippedAdmin:     "需要管理员权限，已跳过",
+		ItemQueuedAdmin:      "需要管理员权限，下次打开程序时提示清理",
+
+		IntervalTooShort:     "采样间隔不能小于 {seconds} 秒",
+		ThresholdNotPositive: "剩余空间阈值必须大于 0",
+		NegativeDrop:         "下降提醒的大小不能为负数",
+		DropWindowTooShort:   "下降提醒的时间范围不能小于采样间隔",
+
+		UnknownOperation:   "操作不存在或已经结束",
+		SnapshotRunning:    "正在生成目录快照",
+		QuarantineNoVolume: "路径不在本地分区上，无法移入隔离区",
+		QuarantineUnsafe:   "隔离区目录可以被普通用户修改，已拒绝使用",
+		QuarantineFull:     "隔离区容量不足，文件未清理",
+		InvalidRun:         "无效的清理批次: {id}",
+		RunNotFound:        "隔离区中没有找到清理批次: {id}",
+		UnknownTaskID:      "未知的辅助程序任务",
+		BadSignature:       "辅助程序请求签名无效",
+		ReplayedRequest:    "重复的辅助程序请求",
+		RequestRejected:    "主程序拒绝请求: {status}",
+		TaskFileSignature:  "任务文件校验失败",
+		UnsupportedFormat:  "不支持的报告格式: {format}（可用 json、csv、html）",
+		ManifestVersion:    "不支持的删除清单版本: {version}",
+	},
+	"en-US": {
+		Failed:            "{cause}",
+		Cancelled:         "The operation was cancelled",
+		NotFound:          "File or folder not found",
+		AccessDenied:      "Access denied, please run as administrator",
+		Protected:         "{path} is protected and cannot be deleted",
+		UnknownItem:       "Unknown clean item: {id}",
+		InvalidTime:       "Invalid time: {value}",
+		NoScanResults:     "Nothing has been scanned yet, scan before exporting a report",
+		NoPaths:           "No paths to clean were found (item {id})",
+		NeedsAdmin:        "Administrator rights are required, please run as administrator",
+		AlreadyElevated:   "CCooler is already running as administrator and does not need the helper",
+		ExePath:           "Cannot determine the program location",
+		NoPruneTime:       "Specify the time before which records are deleted",
+		HelperMissing:     "The helper {helper} was not found, make sure it is in the same folder as CCooler",
+		HelperStartFailed: "Failed to start the helper",
+		UACDeclined:       "The administrator request was cancelled (UAC prompt declined)",
+		HelperNotStarted:  "The helper did not start (no response within {seconds} seconds)",
+		HelperStartExited: "The helper exited right after starting",
+		HelperTimeout:     "The helper stopped responding (no heartbeat within {seconds} seconds)",
+		HelperExited:      "The helper exited unexpectedly (exit code {exitCode}) without a result",
+		HelperNoResult:    "The helper finished but no result was received",
+		RulesMismatch:     "The rules for clean item {id} differ from CCooler's, make sure the helper uses the same rules file",
+		TaskVersion:       "Task file version mismatch ({version}, expected {expected}), make sure the helper matches the CCooler version",
+		UnknownTask:       "Task not supported by the helper: {task}",
+		CommandFailed:     "Command failed ({cause}), output: {output}",
+		RecycleBinBusy:    "The Recycle Bin is in use, close any Recycle Bin windows and try again",
+		RestoreIncomplete: "{count} files could not be restored (a file with the same name exists, access was denied, or they must be restored without administrator rights) and remain in quarantine",
+
+		ReadFailed:   "Cannot read {file}",
+		FileInvalid:  "{file} is not valid",
+		FileVersion:  "{file} uses unsupported format version {version}, please update CCooler",
+		SaveFailed:   "Failed to save {file}",
+		DeleteFailed: "Failed to delete {path}",
+		PathNotFound: "{path} does not exist",
+		OpenFailed:   "Cannot open {path}",
+		ExpandFailed: "Cannot expand the environment variables in {path}",
+		OutsideRoot:  "The path is outside the root folder",
+		InvalidDrive: "Invalid drive: {drive}",
+		NotSupported: "This feature is only available on Windows",
+		DiskInfo:     "Cannot read disk information",
+		UserProfile:  "Cannot determine the user profile folder",
+
+		RecycleBinFailed:    "Failed to empty the Recycle Bin",
+		RecycleBinQuery:     "Failed to query the Recycle Bin",
+		PartialClean:        "Some paths could not be cleaned (files may be in use)",
+		ElevateFailed:       "Failed to request administrator rights",
+		UnknownOptimizeItem: "Unknown optimization type: {type}",
+		HibernateFailed:     "Failed to disable hibernation ({cause}), output: {output}",
+		RestorePointsFailed: "Failed to delete system restore points ({cause}), output: {output}",
+		PagefileFailed:      "Failed to disable virtual memory ({cause}), output: {output}",
+		WeChatNotFound:      "WeChat is not installed",
+		InvalidSize:         "Invalid size: {value} (for example 500MB or 2GB)",
+
+		RuleMissingID:      "Rule {index} has no id or name",
+		DuplicateRule:      "Duplicate rule id: {id}",
+		UnknownHandler:     "Rule {id} has an unknown handler: {handler}",
+		UnknownRisk:        "Rule {id} has an unknown risk level: {risk}",
+		FilterNotSupported: "Clean item {id} does not support filters",
+		InvalidFilter:      "The filter for clean item {id} is not valid",
+		InvalidDuration:    "Invalid duration: {value} (for example \"30m\", \"24h\" or \"7d\")",
+		UnknownAgeBy:       "Unknown age basis: {value}",
+		NegativeDepth:      "maxDepth cannot be negative",
+		InvalidGlob:        "Invalid wildcard pattern: {pattern}",
+
+		SettingsNewer:     "The settings file was created by a newer version of CCooler; default settings are used and the file will not be overwritten",
+		SettingsReadOnly:  "The settings file was created by a newer version of CCooler and cannot be saved",
+		LargeFileMinSize:  "The minimum large file size cannot be less than 1 MB",
+		UnknownCategory:   "Unknown large file category: {category}",
+		CategoryMinSize:   "The minimum size for category {category} cannot be less than 1 MB",
+		UnknownHelperMode: "Unknown helper mode: {mode}",
+		UnsupportedLocale: "Unsupported language: {locale}",
+
+		ProfileNotFound:      "The clean profile does not exist",
+		ProfileInvalid:       "Clean profile {name}: {cause}",
+		DuplicateProfile:     "Duplicate clean profile ID: {id}",
+		ProfileNoName:        "The clean profile needs a name",
+		ProfileNoItems:       "The clean profile needs at least one clean item",
+		NegativeFreeBelow:    "The free space threshold cannot be negative",
+		UnknownAdminMode:     "Unknown handling for administrator items: {mode}",
+		DryRunQuarantine:     "A dry run does not delete files and cannot use quarantine",
+		InvalidSchedule:      "Invalid schedule {schedule}: 5 fields are required (minute hour day month weekday)",
+		InvalidScheduleField: "Invalid schedule {schedule}: {value} in field {position} is not valid (allowed {min}-{max})",
+		ItemSkippedAdmin:     "Requires administrator rights, skipped",
+		ItemQueuedAdmin:      "Requires administrator rights, you will be asked to clean it next time CCooler opens",
+
+		IntervalTooShort:     "The sampling interval cannot be less than {seconds} seconds",
+		ThresholdNotPositive: "The free space threshold must be greater than 0",
+		NegativeDrop:         "The drop alert size cannot be negative",
+		DropWindowTooShort:   "The drop alert window cannot be shorter than the sampling interval",
+
+		UnknownOperation:   "The operation does not exist or has already finished",
+		SnapshotRunning:    "A folder snapshot is already being taken",
+		QuarantineNoVolume: "The path is not on a local drive and cannot be quarantined",
+		QuarantineUnsafe:   "The quarantine folder can be modified by standard users and was not used",
+		QuarantineFull:     "The quarantine is full, the file was not cleaned",
+		InvalidRun:         "Invalid clean run: {id}",
+		RunNotFound:        "Clean run {id} was not found in quarantine",
+		UnknownTaskID:      "Unknown helper task",
+		BadSignature:       "The helper request signature is not valid",
+		ReplayedRequest:    "Duplicate helper request",
+		RequestRejected:    "CCooler rejected the request: {status}",
+		TaskFileSignature:  "The task file failed verification",
+		UnsupportedFormat:  "Unsupported report format: {format} (use json, csv or html)",
+		ManifestVersion:    "Unsupported deletion list version: {version}",
+	},
+}
+
+// Locales 支持的语言
+func Locales() []string {
+	locales := make([]string, 0, len(messages))
+	for l := range messages {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// catalog 指定语言的提示文字，不支持的语言使用默认语言
+func catalog(locale string) map[Code]string {
+	if m, ok := messages[locale]; ok {
+		return m
+	}
+	return messages[DefaultLocale]
+}
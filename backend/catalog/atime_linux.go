@@ -0,0 +1,15 @@
+package catalog
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// accessTime 返回文件最后访问时间（无法获取时使用修改时间）
+func accessTime(info fs.FileInfo) time.Time {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Atim.Sec, st.Atim.Nsec)
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,13 @@
+//go:build !windows && !linux
+
+package catalog
+
+import (
+	"io/fs"
+	"time"
+)
+
+// accessTime 返回文件最后访问时间（该平台使用修改时间代替）
+func accessTime(info fs.FileInfo) time.Time {
+	return info.ModTime()
+}
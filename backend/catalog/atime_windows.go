@@ -0,0 +1,15 @@
+package catalog
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// accessTime 返回文件最后访问时间（无法获取时使用修改时间）
+func accessTime(info fs.FileInfo) time.Time {
+	if data, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return time.Unix(0, data.LastAccessTime.Nanoseconds())
+	}
+	return info.ModTime()
+}
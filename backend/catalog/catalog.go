@@ -0,0 +1,246 @@
+package catalog
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
+	"ccooler/backend/models"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SchemaVersion 当前支持的规则文件版本
+const SchemaVersion = 1
+
+// FileName 外部规则文件名（放在程序目录下即可覆盖内置规则）
+const FileName = "ccooler-rules.json"
+
+// HandlerKind 清理项的处理方式
+type HandlerKind string
+
+const (
+	HandlerFolder     HandlerKind = "folder"      // 清空目录内容（保留目录本身）
+	HandlerRecycleBin HandlerKind = "recycle-bin" // 调用系统 API 清空回收站
+	HandlerLogFiles   HandlerKind = "log-files"   // 按深度查找 .log 文件
+)
+
+// Risk 清理项风险等级
+type Risk string
+
+const (
+	RiskSafe    Risk = "safe"    // 安全，默认可清理
+	RiskCaution Risk = "caution" // 需要用户确认
+)
+
+// PathRule 路径模板
+type PathRule struct {
+	Path     string `json:"path"`               // 支持 %VAR% 环境变量和 * 通配符
+	MaxDepth int    `json:"maxDepth,omitempty"` // 扫描深度（log-files 使用）
+}
+
+// Rule 单个清理项规则
+type Rule struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Checked    bool        `json:"checked"`
+	Risk       Risk        `json:"risk"`
+	NeedsAdmin bool        `json:"needsAdmin"`
+	Handler    HandlerKind `json:"handler"`
+	Paths      []PathRule  `json:"paths"`
+	Filter     *Filter     `json:"filter,omitempty"` // 过滤条件（folder 处理方式使用）
+}
+
+// ResolvedPath 展开后的路径
+type ResolvedPath struct {
+	Path     string
+	MaxDepth int
+}
+
+// Catalog 清理项目录
+type Catalog struct {
+	Version int    `json:"version"`
+	Rules   []Rule `json:"rules"`
+}
+
+//go:embed rules.json
+var builtinRules []byte
+
+// Builtin 返回内置规则
+func Builtin() *Catalog {
+	c, err := Parse(builtinRules)
+	if err != nil {
+		panic(fmt.Sprintf("内置清理规则无效: %v", err))
+	}
+	return c
+}
+
+// Load 加载规则：dir 下存在 FileName 时使用外部文件，否则使用内置规则
+func Load(dir string) (*Catalog, error) {
+	path := filepath.Join(dir, FileName)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return Builtin(), nil
+	}
+	return LoadFile(path)
+}
+
+// LoadFile 从文件加载规则
+func LoadFile(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.ReadFailed, err, "file", path)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.FileInvalid, err, "file", path)
+	}
+	return c, nil
+}
+
+// Parse 解析并校验规则
+func Parse(data []byte) (*Catalog, error) {
+	var c Catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if c.Version < 1 || c.Version > SchemaVersion {
+		return nil, apperr.New(apperr.FileVersion, "file", FileName, "version", c.Version)
+	}
+
+	seen := make(map[string]bool)
+	for i, rule := range c.Rules {
+		if rule.ID == "" || rule.Name == "" {
+			return nil, apperr.New(apperr.RuleMissingID, "index", i+1)
+		}
+		if seen[rule.ID] {
+			return nil, apperr.New(apperr.DuplicateRule, "id", rule.ID)
+		}
+		seen[rule.ID] = true
+
+		switch rule.Handler {
+		case HandlerFolder, HandlerRecycleBin, HandlerLogFiles:
+		default:
+			return nil, apperr.New(apperr.UnknownHandler, "id", rule.ID, "handler", rule.Handler)
+		}
+		switch rule.Risk {
+		case RiskSafe, RiskCaution:
+		default:
+			return nil, apperr.New(apperr.UnknownRisk, "id", rule.ID, "risk", rule.Risk)
+		}
+		if rule.Filter != nil {
+			if err := rule.Filter.validate(); err != nil {
+				return nil, apperr.Wrap(apperr.InvalidFilter, err, "id", rule.ID)
+			}
+		}
+	}
+
+	return &c, nil
+}
+
+// Rule 按 ID 查找规则
+func (c *Catalog) Rule(id string) (*Rule, bool) {
+	for i := range c.Rules {
+		if c.Rules[i].ID == id {
+			return &c.Rules[i], true
+		}
+	}
+	return nil, false
+}
+
+// PathFilter 返回清理项的过滤条件（只有 folder 处理方式使用过滤条件）
+func (c *Catalog) PathFilter(id string) *Filter {
+	rule, ok := c.Rule(id)
+	if !ok || rule.Handler != HandlerFolder {
+		return nil
+	}
+	return rule.Filter
+}
+
+// WithFilters 返回替换了部分清理项过滤条件的副本（filters 为清理项 ID -> 过滤条件，只能用于 folder 处理方式）
+func (c *Catalog) WithFilters(filters map[string]*Filter) (*Catalog, error) {
+	copied := &Catalog{Version: c.Version, Rules: append([]Rule{}, c.Rules...)}
+	for id, filter := range filters {
+		rule, ok := copied.Rule(id)
+		if !ok {
+			return nil, apperr.New(apperr.UnknownItem, "id", id)
+		}
+		if rule.Handler != HandlerFolder {
+			return nil, apperr.New(apperr.FilterNotSupported, "id", id)
+		}
+		if filter != nil {
+			if err := filter.validate(); err != nil {
+				return nil, apperr.Wrap(apperr.InvalidFilter, err, "id", id)
+			}
+		}
+		rule.Filter = filter
+	}
+	return copied, nil
+}
+
+// WithChecked 返回替换了默认选中状态的副本：ids 中的清理项默认选中，其余不选中（忽略未知的清理项）
+func (c *Catalog) WithChecked(ids []string) *Catalog {
+	checked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		checked[id] = true
+	}
+	copied := &Catalog{Version: c.Version, Rules: append([]Rule{}, c.Rules...)}
+	for i := range copied.Rules {
+		copied.Rules[i].Checked = checked[copied.Rules[i].ID]
+	}
+	return copied
+}
+
+// NewItem 根据规则创建清理项
+func (r *Rule) NewItem(status string) *models.CleanItem {
+	return &models.CleanItem{
+		ID:         r.ID,
+		Name:       r.Name,
+		Checked:    r.Checked,
+		Safe:       r.Risk == RiskSafe,
+		NeedsAdmin: r.NeedsAdmin,
+		Status:     status,
+	}
+}
+
+// ResolvePaths 展开路径模板（环境变量未定义的模板会被跳过，通配符在 filesystem 上匹配）
+func (r *Rule) ResolvePaths(filesystem fsys.FS) []ResolvedPath {
+	var resolved []ResolvedPath
+	for _, p := range r.Paths {
+		path, ok := ExpandPath(p.Path)
+		if !ok {
+			continue
+		}
+
+		if !strings.Contains(path, "*") {
+			resolved = append(resolved, ResolvedPath{Path: path, MaxDepth: p.MaxDepth})
+			continue
+		}
+
+		matches, _ := filesystem.Glob(path)
+		for _, match := range matches {
+			resolved = append(resolved, ResolvedPath{Path: match, MaxDepth: p.MaxDepth})
+		}
+	}
+	return resolved
+}
+
+var envPattern = regexp.MustCompile(`%([^%]+)%`)
+
+// ExpandPath 展开 %VAR% 形式的环境变量，任一变量未定义时返回 false
+func ExpandPath(template string) (string, bool) {
+	ok := true
+	path := envPattern.ReplaceAllStringFunc(template, func(m string) string {
+		value := os.Getenv(m[1 : len(m)-1])
+		if value == "" {
+			ok = false
+		}
+		return value
+	})
+	if !ok {
+		return "", false
+	}
+	return filepath.Clean(path), true
+}
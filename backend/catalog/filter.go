@@ -0,0 +1,173 @@
+package catalog
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
+	"encoding/json"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AgeBy 文件年龄的计算依据
+type AgeBy string
+
+const (
+	AgeByModTime    AgeBy = "mtime" // 修改时间（默认）
+	AgeByAccessTime AgeBy = "atime" // 访问时间
+)
+
+// Duration 规则文件中的时长，支持 "30m"、"24h"、"7d"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return apperr.Wrap(apperr.InvalidDuration, err, "value", string(data))
+	}
+	v, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// ParseDuration 解析时长，在 time.ParseDuration 的基础上支持天（d）
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, apperr.New(apperr.InvalidDuration, "value", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil || v < 0 {
+		return 0, apperr.New(apperr.InvalidDuration, "value", s)
+	}
+	return v, nil
+}
+
+// Filter 清理过滤条件（folder 处理方式使用），扫描、试运行和清理使用同一套条件，
+// 保证扫描显示的大小与实际清理的一致
+type Filter struct {
+	MinAge   Duration `json:"minAge,omitempty"`   // 只处理早于该时长的文件，如 "24h"
+	AgeBy    AgeBy    `json:"ageBy,omitempty"`    // 年龄依据：mtime（默认）或 atime
+	Include  []string `json:"include,omitempty"`  // 只处理匹配的文件（通配符，匹配文件名；含路径分隔符时匹配相对路径）
+	Exclude  []string `json:"exclude,omitempty"`  // 跳过匹配的文件或目录
+	MaxDepth int      `json:"maxDepth,omitempty"` // 最大深度（1 表示只处理直接子项），0 表示不限制
+}
+
+// validate 校验过滤条件
+func (f *Filter) validate() error {
+	switch f.AgeBy {
+	case "", AgeByModTime, AgeByAccessTime:
+	default:
+		return apperr.New(apperr.UnknownAgeBy, "value", f.AgeBy)
+	}
+	if f.MaxDepth < 0 {
+		return apperr.New(apperr.NegativeDepth)
+	}
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := filepath.Match(normalizePattern(pattern), ""); err != nil {
+			return apperr.New(apperr.InvalidGlob, "pattern", pattern)
+		}
+	}
+	return nil
+}
+
+// normalizePattern 统一使用小写和 / 分隔符（Windows 路径不区分大小写）
+func normalizePattern(s string) string {
+	return strings.ToLower(strings.ReplaceAll(s, `\`, "/"))
+}
+
+func matchAny(patterns []string, rel string) bool {
+	rel = normalizePattern(rel)
+	base := rel[strings.LastIndex(rel, "/")+1:]
+	for _, pattern := range patterns {
+		pattern = normalizePattern(pattern)
+		name := base
+		if strings.Contains(pattern, "/") {
+			name = rel
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// oldEnough 判断文件是否已超过最短保留时间
+func (f *Filter) oldEnough(info fs.FileInfo, now time.Time) bool {
+	if f.MinAge <= 0 {
+		return true
+	}
+	t := info.ModTime()
+	if f.AgeBy == AgeByAccessTime {
+		t = accessTime(info)
+	}
+	return now.Sub(t) >= time.Duration(f.MinAge)
+}
+
+// Walk 遍历 root 下符合过滤条件的文件和目录（不包括 root 本身）。
+// 排除的目录和超过深度的目录整体跳过；目录只检查排除规则和年龄，Include 只作用于文件。
+// 目录先于其内容回调（不跟随符号链接）；f 为 nil 时遍历全部内容
+func (f *Filter) Walk(filesystem fsys.FS, root string, fn func(path string, info fs.FileInfo) error) error {
+	now := time.Now()
+	return filesystem.Walk(root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			if info != nil && info.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return nil // 跳过无法访问的文件
+		}
+		if path == root {
+			return nil
+		}
+
+		if f == nil {
+			return fn(path, info)
+		}
+
+		rel := strings.TrimLeft(strings.TrimPrefix(path, root), `\/`)
+		depth := strings.Count(strings.ReplaceAll(rel, `\`, "/"), "/") + 1
+
+		if info.IsDir() {
+			if matchAny(f.Exclude, rel) {
+				return filepath.SkipDir
+			}
+			if f.MaxDepth > 0 && depth >= f.MaxDepth {
+				// 目录本身在深度内，其内容不在
+				if f.oldEnough(info, now) {
+					fn(path, info)
+				}
+				return filepath.SkipDir
+			}
+			if f.oldEnough(info, now) {
+				return fn(path, info)
+			}
+			return nil
+		}
+
+		if f.MaxDepth > 0 && depth > f.MaxDepth {
+			return nil
+		}
+		if matchAny(f.Exclude, rel) {
+			return nil
+		}
+		if len(f.Include) > 0 && !matchAny(f.Include, rel) {
+			return nil
+		}
+		if !f.oldEnough(info, now) {
+			return nil
+		}
+		return fn(path, info)
+	})
+}
@@ -0,0 +1,742 @@
+// Package cli 无窗口的命令行界面：直接调用 services 执行扫描和清理，
+// 输出表格或 JSON（--json），供脚本和远程会话使用。
+package cli
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
+	"ccooler/backend/history"
+	"ccooler/backend/models"
+	"ccooler/backend/ops"
+	"ccooler/backend/report"
+	"ccooler/backend/scheduler"
+	"ccooler/backend/services"
+	"ccooler/backend/walker"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 退出码
+const (
+	ExitOK        = 0   // 成功
+	ExitFailed    = 1   // 命令执行失败
+	ExitUsage     = 2   // 参数错误
+	ExitPartial   = 3   // 部分失败（有清理项出错或文件未能清理）
+	ExitCancelled = 130 // 被中断（Ctrl+C）
+)
+
+// Services 命令行使用的服务（与图形界面使用同一套配置：清理规则、受保护路径、目录大小索引）
+type Services struct {
+	Clean      *services.CleanService
+	LargeFiles *services.LargeFileService
+	Software   *services.SoftwareService
+	Optimize   *services.OptimizeService
+	WeChat     *services.WeChatService
+	Admin      *services.AdminService
+	Index      *walker.Index
+	Scheduler  *scheduler.Scheduler
+	History    *history.Store // 扫描和清理记录，为空时不记录
+}
+
+// command 一个子命令
+type command struct {
+	usage string // 参数
+	desc  string // 说明
+	run   func(ctx context.Context, c *cli, args []string) int
+}
+
+var commands = map[string]command{
+	"scan":       {"scan [--json]", "扫描所有清理项", runScan},
+	"clean":      {"clean [--items=1,2] [--dry-run] [--json]", "清理指定的清理项（默认为推荐项）", runClean},
+	"largefiles": {"largefiles [--drive=D:] [--min=500MB] [--limit=50] [--json]", "扫描分区中的大文件（默认为系统盘）", runLargeFiles},
+	"software":   {"software [--json]", "统计已安装软件占用的空间", runSoftware},
+	"optimize":   {"optimize list [--json]", "列出系统优化项", runOptimize},
+	"report":     {"report [--format=html|csv|json] [--out=PATH] [--sections=clean,largefiles,...] [--drive=D:]", "扫描并导出报告（csv 每个部分一个文件）", runReport},
+	"schedule":   {"schedule list|history|run|install|uninstall [--profile=ID] [--json]", "自动清理方案（run 运行到期的方案，install 在任务计划程序中注册定期运行 run 的任务）", runSchedule},
+}
+
+func init() {
+	commands["help"] = command{"help", "显示帮助", runHelp}
+}
+
+// IsCommand 参数是否为命令行子命令（否则启动图形界面）
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok || name == "--help" || name == "-h"
+}
+
+// cli 一次命令行调用
+type cli struct {
+	svc    *Services
+	stdout io.Writer
+	stderr io.Writer
+	json   bool
+}
+
+// Run 执行子命令，返回退出码。ctx 取消时（Ctrl+C）停止并输出已完成的部分
+func Run(ctx context.Context, svc *Services, args []string, stdout, stderr io.Writer) int {
+	c := &cli{svc: svc, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		return runHelp(ctx, c, nil)
+	}
+	name := args[0]
+	if name == "--help" || name == "-h" {
+		name = "help"
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "未知的命令: %s\n\n", name)
+		c.usage()
+		return ExitUsage
+	}
+	return cmd.run(ctx, c, args[1:])
+}
+
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "用法: ccooler <命令> [参数]")
+	fmt.Fprintln(c.stderr)
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := tabwriter.NewWriter(c.stderr, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  ccooler %s\t%s\n", commands[name].usage, commands[name].desc)
+	}
+	w.Flush()
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintf(c.stderr, "退出码: %d 成功，%d 失败，%d 参数错误，%d 部分失败，%d 被中断\n", ExitOK, ExitFailed, ExitUsage, ExitPartial, ExitCancelled)
+}
+
+// flags 创建子命令的参数解析（包含 --json）
+func (c *cli) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.BoolVar(&c.json, "json", false, "以 JSON 格式输出")
+	return fs
+}
+
+// parse 解析参数，失败时返回退出码
+func (c *cli) parse(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK, false
+		}
+		return ExitUsage, false
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(c.stderr, "多余的参数: %s\n", strings.Join(fs.Args(), " "))
+		return ExitUsage, false
+	}
+	return 0, true
+}
+
+// output 输出 JSON 或表格
+func (c *cli) output(v any, table func(w *tabwriter.Writer)) {
+	if c.json {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(v)
+		return
+	}
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	table(w)
+	w.Flush()
+}
+
+// fail 输出错误并返回退出码
+func (c *cli) fail(ctx context.Context, err error) int {
+	if ctx.Err() != nil {
+		fmt.Fprintln(c.stderr, "已中断")
+		return ExitCancelled
+	}
+	fmt.Fprintf(c.stderr, "错误: %v\n", err)
+	return ExitFailed
+}
+
+// exitCode 根据是否中断和是否部分失败返回退出码
+func exitCode(ctx context.Context, partial bool) int {
+	switch {
+	case ops.Cancelled(ctx):
+		return ExitCancelled
+	case partial:
+		return ExitPartial
+	}
+	return ExitOK
+}
+
+func runHelp(ctx context.Context, c *cli, args []string) int {
+	c.usage()
+	return ExitOK
+}
+
+// runScan 扫描所有清理项
+func runScan(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("scan")
+	if code, ok := c.parse(fs, args); !ok {
+		return code
+	}
+
+	record := c.beginHistory(history.KindScan)
+	items, err := c.svc.Clean.ScanCleanItems(ctx)
+	c.saveIndex()
+	record.SetScan(items)
+	c.addHistory(ctx, record, err)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+
+	partial := false
+	var total int64
+	for _, item := range items {
+		total += item.Size
+		partial = partial || item.Status == "error"
+	}
+	c.output(items, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "ID\t名称\t大小\t文件数\t推荐\t管理员\t状态")
+		for _, item := range items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n", item.ID, item.Name, FormatSize(item.Size), item.FileCount, yesNo(item.Checked), yesNo(item.NeedsAdmin), itemStatus(item))
+		}
+		fmt.Fprintf(w, "\t合计\t%s\t\t\t\t\n", FormatSize(total))
+	})
+	return exitCode(ctx, partial)
+}
+
+// cleanOutput clean 命令的 JSON 输出
+type cleanOutput struct {
+	DryRun   bool                  `json:"dryRun"`
+	Items    []*models.CleanItem   `json:"items"`
+	Manifest *models.CleanManifest `json:"manifest,omitempty"`
+	Report   *models.CleanReport   `json:"report,omitempty"`
+	Status   string                `json:"status,omitempty"`
+}
+
+// runClean 扫描并清理指定的清理项
+func runClean(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("clean")
+	ids := fs.String("items", "", "要清理的清理项 ID（逗号分隔），默认为推荐项")
+	dryRun := fs.Bool("dry-run", false, "只列出将要删除的文件，不删除")
+	if code, ok := c.parse(fs, args); !ok {
+		return code
+	}
+
+	items, err := c.selectItems(*ids)
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+		return ExitUsage
+	}
+
+	// 先扫描选中的项目，清理扫描结果中的路径
+	scan := c.beginHistory(history.KindScan)
+	for _, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		c.svc.Clean.ScanSingleItem(ctx, item)
+	}
+	c.saveIndex()
+	scan.SetScan(items)
+	c.addHistory(ctx, scan, nil)
+	if ctx.Err() != nil {
+		return c.fail(ctx, ctx.Err())
+	}
+
+	out := &cleanOutput{DryRun: *dryRun, Items: items}
+	if *dryRun {
+		out.Manifest = services.NewCleanManifest()
+		for _, item := range items {
+			c.svc.Clean.PlanItem(ctx, item, out.Manifest)
+		}
+	} else {
+		record := c.beginHistory(history.KindClean)
+		out.Report = c.clean(ctx, items)
+		record.SetClean(items, out.Report)
+		c.addHistory(ctx, record, nil)
+	}
+	if ops.Cancelled(ctx) {
+		out.Status = ops.StatusCancelled
+	}
+
+	partial := false
+	for _, item := range items {
+		partial = partial || item.Status == "error"
+	}
+	c.output(out, func(w *tabwriter.Writer) {
+		if *dryRun {
+			fmt.Fprintf(w, "试运行：将删除 %d 个文件，共 %s\n\n", out.Manifest.TotalFiles, FormatSize(out.Manifest.TotalSize))
+			fmt.Fprintln(w, "ID\t名称\t大小\t文件数\t状态")
+			for _, item := range items {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", item.ID, item.Name, FormatSize(item.Size), item.FileCount, itemStatus(item))
+			}
+			return
+		}
+		fmt.Fprintln(w, "ID\t名称\t状态")
+		for _, item := range items {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", item.ID, item.Name, itemStatus(item))
+		}
+		fmt.Fprintf(w, "\n已释放 %s（%d 个文件），跳过 %d 个文件（%s）\n", FormatSize(out.Report.FreedSize), out.Report.RemovedCount, out.Report.SkippedCount, FormatSize(out.Report.SkippedSize))
+		for reason, count := range out.Report.SkippedReasons {
+			fmt.Fprintf(w, "  %s\t%d\n", reason, count)
+		}
+	})
+	return exitCode(ctx, partial)
+}
+
+// selectItems 按 ID 选择清理项，ids 为空时选择推荐项
+func (c *cli) selectItems(ids string) ([]*models.CleanItem, error) {
+	all := c.svc.Clean.CatalogItems()
+	if ids == "" {
+		var items []*models.CleanItem
+		for _, item := range all {
+			if item.Checked {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+
+	byID := make(map[string]*models.CleanItem, len(all))
+	for _, item := range all {
+		byID[item.ID] = item
+	}
+	var items []*models.CleanItem
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.TrimSpace(id)
+		item, ok := byID[id]
+		if !ok {
+			return nil, apperr.New(apperr.UnknownItem, "id", id)
+		}
+		item.Checked = true
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// clean 清理选中的项目，需要管理员权限的项目在未提升权限时标记为出错
+func (c *cli) clean(ctx context.Context, items []*models.CleanItem) *models.CleanReport {
+	report := services.NewCleanReport()
+	elevated := c.svc.Admin.IsElevated()
+	var paths []string
+	for _, item := range items {
+		if ctx.Err() != nil {
+			item.Status = ops.StatusCancelled
+			continue
+		}
+		if item.NeedsAdmin && !elevated {
+			item.Status = "error"
+			item.Error = apperr.New(apperr.NeedsAdmin).Error()
+			continue
+		}
+		c.svc.Clean.CleanItem(ctx, item, nil, report)
+		for _, pathDetail := range item.Paths {
+			paths = append(paths, pathDetail.Path)
+		}
+	}
+
+	c.svc.Index.Invalidate(paths...)
+	c.saveIndex()
+	return report
+}
+
+// runLargeFiles 扫描分区中的大文件
+func runLargeFiles(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("largefiles")
+	drive := fs.String("drive", "", "要扫描的分区，如 D:（默认为系统盘）")
+	min := fs.String("min", "", "最小文件大小，如 500MB、2GB（默认使用程序设置）")
+	limit := fs.Int("limit", 50, "表格中最多列出的文件数（0 表示全部，JSON 输出不受限制）")
+	if code, ok := c.parse(fs, args); !ok {
+		return code
+	}
+	if *min != "" {
+		size, err := ParseSize(*min)
+		if err != nil {
+			fmt.Fprintln(c.stderr, err)
+			return ExitUsage
+		}
+		c.svc.LargeFiles.SetThresholds(max(size, 1), nil) // 所有分类使用同一个阈值
+	}
+
+	result, err := c.svc.LargeFiles.ScanDrive(ctx, *drive)
+	c.saveIndex()
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+
+	c.output(result, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "大小\t分类\t修改时间\t路径")
+		for i, file := range result.Files {
+			if *limit > 0 && i >= *limit {
+				fmt.Fprintf(w, "……\t另外 %d 个文件\t\t\n", len(result.Files)-i)
+				break
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", FormatSize(file.Size), file.Category, file.ModifiedTime, file.Path)
+		}
+		fmt.Fprintf(w, "\n%s 共 %d 个文件，%s\n", result.Drive, result.TotalFiles, FormatSize(result.TotalSize))
+		if result.ExcludedCount > 0 {
+			fmt.Fprintf(w, "受保护路径中另有 %d 个文件（%s）未列出\n", result.ExcludedCount, FormatSize(result.ExcludedSize))
+		}
+	})
+	return exitCode(ctx, false)
+}
+
+// runSoftware 统计已安装软件占用的空间
+func runSoftware(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("software")
+	if code, ok := c.parse(fs, args); !ok {
+		return code
+	}
+
+	list, err := c.svc.Software.GetInstalledSoftware(ctx)
+	c.saveIndex()
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+
+	c.output(list, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "大小\t名称\t路径")
+		var total int64
+		for _, software := range list {
+			total += software.Size
+			fmt.Fprintf(w, "%s\t%s\t%s\n", FormatSize(software.Size), software.Name, software.Path)
+		}
+		fmt.Fprintf(w, "\n共 %d 个软件，%s\n", len(list), FormatSize(total))
+	})
+	return exitCode(ctx, false)
+}
+
+// runOptimize 系统优化项（目前只支持 list）
+func runOptimize(ctx context.Context, c *cli, args []string) int {
+	if len(args) == 0 || args[0] != "list" {
+		fmt.Fprintln(c.stderr, "用法: ccooler optimize list [--json]")
+		return ExitUsage
+	}
+	fs := c.flags("optimize list")
+	if code, ok := c.parse(fs, args[1:]); !ok {
+		return code
+	}
+
+	result, err := c.svc.Optimize.Scan()
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+
+	c.output(result, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "类型\t名称\t大小\t已启用\t可禁用\t路径")
+		for _, item := range result.Items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", item.Type, item.Name, FormatSize(item.Size), yesNo(item.Enabled), yesNo(item.CanDisable), item.Path)
+		}
+		fmt.Fprintf(w, "\n合计 %s\n", FormatSize(result.TotalSize))
+	})
+	return ExitOK
+}
+
+// runReport 扫描选中的部分并导出报告
+func runReport(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("report")
+	format := fs.String("format", report.FormatHTML, "报告格式：html、csv 或 json")
+	out := fs.String("out", "", "保存位置（默认为当前目录下的 ccooler-report-时间.格式）")
+	sections := fs.String("sections", strings.Join(report.Sections, ","), "包括的部分（逗号分隔）："+strings.Join(report.Sections, "、"))
+	drive := fs.String("drive", "", "大文件扫描的分区（默认为系统盘）")
+	if code, ok := c.parse(fs, args); !ok {
+		return code
+	}
+	*format = strings.ToLower(*format)
+	if *format != report.FormatHTML && *format != report.FormatCSV && *format != report.FormatJSON {
+		fmt.Fprintf(c.stderr, "不支持的报告格式: %s（可用 html、csv、json）\n", *format)
+		return ExitUsage
+	}
+	selected := make(map[string]bool)
+	for _, section := range strings.Split(*sections, ",") {
+		section = strings.TrimSpace(section)
+		if !slices.Contains(report.Sections, section) {
+			fmt.Fprintf(c.stderr, "未知的部分: %s（可用 %s）\n", section, strings.Join(report.Sections, "、"))
+			return ExitUsage
+		}
+		selected[section] = true
+	}
+	path := *out
+	if path == "" {
+		path = fmt.Sprintf("ccooler-report-%s.%s", time.Now().Format("20060102-150405"), *format)
+	}
+
+	// 依次扫描各部分，某一部分失败时记录原因并继续
+	in := &report.Input{Errors: make(map[string]string)}
+	for _, section := range report.Sections {
+		if !selected[section] || ctx.Err() != nil {
+			continue
+		}
+		var err error
+		switch section {
+		case report.SectionClean:
+			record := c.beginHistory(history.KindScan)
+			in.CleanItems, err = c.svc.Clean.ScanCleanItems(ctx)
+			record.SetScan(in.CleanItems)
+			c.addHistory(ctx, record, err)
+		case report.SectionLargeFiles:
+			in.LargeFiles, err = c.svc.LargeFiles.ScanDrive(ctx, *drive)
+		case report.SectionOptimize:
+			in.Optimize, err = c.svc.Optimize.Scan()
+		case report.SectionSoftware:
+			in.Software, err = c.svc.Software.GetInstalledSoftware(ctx)
+		case report.SectionWeChat:
+			in.WeChat, err = c.svc.WeChat.DetectWeChat(ctx)
+		}
+		if err != nil && ctx.Err() == nil {
+			in.Errors[section] = err.Error()
+		}
+	}
+	c.saveIndex()
+	if ctx.Err() != nil {
+		return c.fail(ctx, ctx.Err())
+	}
+	if info, err := c.svc.Clean.GetDiskInfo(); err == nil {
+		in.Disk = info
+	}
+
+	files, err := report.Write(fsys.OS(), report.Build(in, time.Now()), *format, path)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+	c.output(map[string]any{"files": files, "errors": in.Errors}, func(w *tabwriter.Writer) {
+		for section, err := range in.Errors {
+			fmt.Fprintf(w, "%s 扫描失败: %s\n", section, err)
+		}
+		for _, file := range files {
+			fmt.Fprintf(w, "已保存 %s\n", file)
+		}
+	})
+	return exitCode(ctx, len(in.Errors) > 0)
+}
+
+// runSchedule 自动清理方案：list 列出方案，history 列出运行记录，run 运行到期的方案或指定的方案，
+// install、uninstall 注册或删除任务计划程序中的任务
+func runSchedule(ctx context.Context, c *cli, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(c.stderr, "用法: ccooler schedule list|history|run|install|uninstall [--profile=ID] [--json]")
+		return ExitUsage
+	}
+	sub := args[0]
+	fs := c.flags("schedule " + sub)
+	profileID := fs.String("profile", "", "方案 ID（history 只列出该方案的记录，run 立即运行该方案）")
+	if code, ok := c.parse(fs, args[1:]); !ok {
+		return code
+	}
+
+	switch sub {
+	case "list":
+		profiles, err := c.svc.Scheduler.Profiles()
+		if err != nil {
+			return c.fail(ctx, err)
+		}
+		c.output(profiles, func(w *tabwriter.Writer) {
+			fmt.Fprintln(w, "ID\t名称\t启用\t计划\t剩余空间低于\t上次运行\t下次运行")
+			for _, profile := range profiles {
+				threshold := ""
+				if profile.FreeBelow > 0 {
+					threshold = FormatSize(profile.FreeBelow)
+				}
+				last := profile.LastRun
+				if last != "" {
+					last += " " + profile.LastStatus
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", profile.ID, profile.Name, yesNo(profile.Enabled), profile.Schedule, threshold, last, profile.NextRun)
+			}
+		})
+		return ExitOK
+
+	case "history":
+		runs, err := c.svc.Scheduler.History(*profileID)
+		if err != nil {
+			return c.fail(ctx, err)
+		}
+		c.output(runs, c.runTable(runs))
+		return ExitOK
+
+	case "run":
+		var runs []*scheduler.Run
+		var err error
+		if *profileID != "" {
+			var run *scheduler.Run
+			if run, err = c.svc.Scheduler.RunNow(ctx, *profileID); run != nil {
+				runs = append(runs, run)
+			}
+		} else {
+			runs, err = c.svc.Scheduler.RunDue(ctx, time.Now())
+		}
+		if runs == nil {
+			runs = []*scheduler.Run{}
+		}
+		c.output(runs, c.runTable(runs))
+		if err != nil {
+			return c.fail(ctx, err)
+		}
+		partial := false
+		for _, run := range runs {
+			partial = partial || run.Status == scheduler.RunPartial || run.Status == scheduler.RunFailed
+		}
+		return exitCode(ctx, partial)
+
+	case "install":
+		exe, err := taskExe()
+		if err != nil {
+			return c.fail(ctx, err)
+		}
+		if err := scheduler.RegisterTask(exe); err != nil {
+			return c.fail(ctx, err)
+		}
+		c.output(map[string]any{"task": scheduler.TaskName, "exe": exe}, func(w *tabwriter.Writer) {
+			fmt.Fprintf(w, "已注册任务 %s：每 %d 分钟运行 %s schedule run\n", scheduler.TaskName, scheduler.TaskInterval, exe)
+		})
+		return ExitOK
+
+	case "uninstall":
+		if err := scheduler.UnregisterTask(); err != nil {
+			return c.fail(ctx, err)
+		}
+		c.output(map[string]any{"task": scheduler.TaskName}, func(w *tabwriter.Writer) {
+			fmt.Fprintf(w, "已删除任务 %s\n", scheduler.TaskName)
+		})
+		return ExitOK
+	}
+
+	fmt.Fprintf(c.stderr, "未知的子命令: schedule %s\n", sub)
+	return ExitUsage
+}
+
+// taskExe 任务计划程序运行的程序：从控制台版本注册时使用同目录下的窗口程序，
+// 避免每次运行都弹出控制台窗口
+func taskExe() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", apperr.Wrap(apperr.ExePath, err)
+	}
+	if strings.EqualFold(filepath.Base(exe), "CCoolerCli.exe") {
+		gui := filepath.Join(filepath.Dir(exe), "CCooler.exe")
+		if _, err := os.Stat(gui); err == nil {
+			return gui, nil
+		}
+	}
+	return exe, nil
+}
+
+// runTable 运行记录表格
+func (c *cli) runTable(runs []*scheduler.Run) func(w *tabwriter.Writer) {
+	return func(w *tabwriter.Writer) {
+		if len(runs) == 0 {
+			fmt.Fprintln(w, "没有运行记录")
+			return
+		}
+		fmt.Fprintln(w, "开始时间\t方案\t触发\t状态\t释放\t跳过或排队")
+		for _, run := range runs {
+			freed := FormatSize(run.FreedSize)
+			if run.DryRun {
+				freed = "试运行 " + FormatSize(run.PlannedSize)
+			}
+			var waiting []string
+			for _, item := range run.Items {
+				if item.Status == scheduler.ItemSkipped || item.Status == scheduler.ItemQueued {
+					waiting = append(waiting, item.Name)
+				}
+			}
+			status := run.Status
+			if run.Error != "" {
+				status += ": " + run.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", run.StartedAt.Local().Format("2006-01-02 15:04"), run.ProfileName, run.Trigger, status, freed, strings.Join(waiting, "、"))
+		}
+	}
+}
+
+// beginHistory 开始一条扫描或清理记录
+func (c *cli) beginHistory(kind string) *history.Record {
+	return history.Begin(kind, history.SourceCLI, c.svc.Admin.IsElevated(), c.freeSpace())
+}
+
+// addHistory 保存扫描或清理记录（保存失败时只输出错误，不影响退出码）
+func (c *cli) addHistory(ctx context.Context, record *history.Record, err error) {
+	if c.svc.History == nil {
+		return
+	}
+	record.Finish(ctx, c.freeSpace(), err)
+	if err := c.svc.History.Add(record); err != nil {
+		fmt.Fprintln(c.stderr, err)
+	}
+}
+
+// freeSpace 返回系统盘剩余空间，无法获取时返回 -1
+func (c *cli) freeSpace() int64 {
+	info, err := c.svc.Clean.GetDiskInfo()
+	if err != nil {
+		return -1
+	}
+	return info.Free
+}
+
+// saveIndex 保存目录大小索引
+func (c *cli) saveIndex() {
+	if err := c.svc.Index.Save(); err != nil {
+		fmt.Fprintf(c.stderr, "无法保存目录大小索引: %v\n", err)
+	}
+}
+
+func itemStatus(item *models.CleanItem) string {
+	if item.Error != "" {
+		return item.Status + ": " + item.Error
+	}
+	return item.Status
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "是"
+	}
+	return "否"
+}
+
+var sizeUnits = []string{"B", "KB", "MB", "GB", "TB"}
+
+// FormatSize 格式化文件大小，如 1.5 GB
+func FormatSize(size int64) string {
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(sizeUnits)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, sizeUnits[unit])
+}
+
+// ParseSize 解析文件大小，如 500MB、2GB、1048576（不区分大小写，单位为 1024 进制）
+func ParseSize(s string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for i := len(sizeUnits) - 1; i > 0; i-- {
+		if number, ok := strings.CutSuffix(text, sizeUnits[i]); ok {
+			text = number
+			multiplier = int64(1) << (10 * i)
+			break
+		}
+	}
+	text = strings.TrimSpace(strings.TrimSuffix(text, "B"))
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || value < 0 {
+		return 0, apperr.New(apperr.InvalidSize, "value", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows && !linux && !darwin
+
+package fsys
+
+import "errors"
+
+func diskFree(path string) (DiskUsage, error) {
+	return DiskUsage{}, errors.ErrUnsupported
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin
+
+package fsys
+
+import "golang.org/x/sys/unix"
+
+func diskFree(path string) (DiskUsage, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return DiskUsage{}, err
+	}
+
+	blockSize := int64(st.Bsize)
+	return DiskUsage{Total: int64(st.Blocks) * blockSize, Free: int64(st.Bfree) * blockSize}, nil
+}
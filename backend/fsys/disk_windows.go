@@ -0,0 +1,17 @@
+package fsys
+
+import "golang.org/x/sys/windows"
+
+func diskFree(path string) (DiskUsage, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return DiskUsage{}, err
+	}
+
+	var freeBytesAvailable, totalBytes, totalFreeBytes uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &freeBytesAvailable, &totalBytes, &totalFreeBytes); err != nil {
+		return DiskUsage{}, err
+	}
+
+	return DiskUsage{Total: int64(totalBytes), Free: int64(totalFreeBytes)}, nil
+}
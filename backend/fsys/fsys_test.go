@@ -0,0 +1,207 @@
+package fsys
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts []string
+		ok    bool
+	}{
+		{`C:\Windows\Temp`, []string{"C:", "Windows", "Temp"}, true},
+		{`C:/Windows/Temp/`, []string{"C:", "Windows", "Temp"}, true},
+		{`C:\Windows\.\Temp`, []string{"C:", "Windows", "Temp"}, true},
+		{`C:\Windows\..\Temp`, []string{"C:", "Temp"}, true},
+		{`C:\Windows\Temp\..\..`, []string{"C:"}, true},
+		{`/tmp/a/../b`, []string{"tmp", "b"}, true},
+		{`C:\..\Windows`, nil, false},
+		{`C:\Temp\..\..\..\etc\passwd`, nil, false},
+		{`/tmp/../../etc`, nil, false},
+		{`..\x`, nil, false},
+	}
+	for _, tt := range tests {
+		parts, ok := splitPath(tt.name)
+		if ok != tt.ok || !reflect.DeepEqual(parts, tt.parts) {
+			t.Errorf("splitPath(%q) = %q, %v; want %q, %v", tt.name, parts, ok, tt.parts, tt.ok)
+		}
+	}
+}
+
+func TestRootedRealPath(t *testing.T) {
+	root := t.TempDir()
+	r := NewRooted(root)
+	tests := []struct {
+		name string
+		want string
+		err  error
+	}{
+		{`C:\Windows\Temp\a.tmp`, filepath.Join(root, "C", "Windows", "Temp", "a.tmp"), nil},
+		{`C:/Users/me/../you`, filepath.Join(root, "C", "Users", "you"), nil},
+		{`D:\`, filepath.Join(root, "D"), nil},
+		{`C:\Temp\..\..\outside`, "", ErrOutsideRoot},
+		{`C:\Temp\..\..\..\..\etc\passwd`, "", ErrOutsideRoot},
+		{`/../etc`, "", ErrOutsideRoot},
+	}
+	for _, tt := range tests {
+		got, err := r.RealPath(tt.name)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("RealPath(%q) = %q, %v; want %q, %v", tt.name, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// filesystems 各实现的测试环境（Mem 和以临时目录为根目录的 Rooted）
+func filesystems(t *testing.T) map[string]FS {
+	return map[string]FS{
+		"Mem":    NewMem(1 << 30),
+		"Rooted": NewRooted(t.TempDir()),
+	}
+}
+
+func TestFileOperations(t *testing.T) {
+	for name, filesystem := range filesystems(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := filesystem.MkdirAll(`C:\Temp\sub`, 0755); err != nil {
+				t.Fatal(err)
+			}
+			steps := []struct {
+				desc string
+				run  func() error
+			}{
+				{"write", func() error { return filesystem.WriteFile(`C:\Temp\a.txt`, []byte("hello"), 0644) }},
+				{"write with backslash ..", func() error { return filesystem.WriteFile(`C:\Temp\sub\..\b.txt`, []byte("b"), 0644) }},
+				{"rename", func() error { return filesystem.Rename(`C:\Temp\a.txt`, `C:/Temp/sub/a.txt`) }},
+				{"remove", func() error { return filesystem.Remove(`C:\Temp\b.txt`) }},
+			}
+			for _, step := range steps {
+				if err := step.run(); err != nil {
+					t.Fatalf("%s: %v", step.desc, err)
+				}
+			}
+
+			data, err := filesystem.ReadFile(`C:\Temp\sub\a.txt`)
+			if err != nil || string(data) != "hello" {
+				t.Fatalf("ReadFile = %q, %v", data, err)
+			}
+			info, err := filesystem.Stat(`C:\Temp\sub\a.txt`)
+			if err != nil || info.Size() != 5 || info.IsDir() {
+				t.Fatalf("Stat = %v, %v", info, err)
+			}
+			if _, err := filesystem.Stat(`C:\Temp\b.txt`); !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("Stat removed file: %v", err)
+			}
+			entries, err := filesystem.ReadDir(`C:\Temp`)
+			if err != nil || len(entries) != 1 || entries[0].Name() != "sub" || !entries[0].IsDir() {
+				t.Fatalf("ReadDir = %v, %v", entries, err)
+			}
+
+			var walked []string
+			err = filesystem.Walk(`C:\Temp`, func(path string, info fs.FileInfo, err error) error {
+				walked = append(walked, info.Name())
+				return err
+			})
+			if err != nil || !reflect.DeepEqual(walked, []string{"Temp", "sub", "a.txt"}) {
+				t.Fatalf("Walk = %q, %v", walked, err)
+			}
+
+			matches, err := filesystem.Glob(`C:\Temp\sub\*.txt`)
+			if err != nil || len(matches) != 1 {
+				t.Fatalf("Glob = %q, %v", matches, err)
+			}
+
+			if err := filesystem.RemoveAll(`C:\Temp`); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := filesystem.Stat(`C:\Temp\sub\a.txt`); !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("Stat after RemoveAll: %v", err)
+			}
+		})
+	}
+}
+
+func TestOutsideRoot(t *testing.T) {
+	for name, filesystem := range filesystems(t) {
+		t.Run(name, func(t *testing.T) {
+			const escape = `C:\Temp\..\..\outside.txt`
+			if err := filesystem.MkdirAll(`C:\Temp`, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := filesystem.WriteFile(escape, []byte("x"), 0644); err == nil {
+				t.Fatalf("WriteFile(%q) succeeded", escape)
+			}
+			if err := filesystem.MkdirAll(`C:\..\outside`, 0755); !errors.Is(err, ErrOutsideRoot) {
+				t.Fatalf("MkdirAll outside root: %v", err)
+			}
+			if _, err := filesystem.Stat(escape); err == nil {
+				t.Fatalf("Stat(%q) succeeded", escape)
+			}
+			if err := filesystem.Rename(`C:\Temp`, escape); err == nil {
+				t.Fatalf("Rename to %q succeeded", escape)
+			}
+		})
+	}
+
+	// Rooted 不会在根目录之外创建文件
+	parent := t.TempDir()
+	r := NewRooted(filepath.Join(parent, "root"))
+	r.WriteFile(`C:\..\..\escaped.txt`, []byte("x"), 0644)
+	if _, err := os.Stat(filepath.Join(parent, "escaped.txt")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("file written outside root: %v", err)
+	}
+}
+
+func TestMemDirModTime(t *testing.T) {
+	m := NewMem(1 << 30)
+	old := time.Now().Add(-time.Hour)
+	m.AddFile(`C:\Temp\a.tmp`, 10, old)
+
+	if err := m.Remove(`C:\Temp\a.tmp`); err != nil {
+		t.Fatal(err)
+	}
+	info, err := m.Stat(`C:\Temp`)
+	if err != nil || !info.ModTime().After(old) {
+		t.Fatalf("directory modtime not updated after remove: %v, %v", info, err)
+	}
+
+	usage, err := m.DiskFree(`C:\`)
+	if err != nil || usage.Total != 1<<30 || usage.Free != 1<<30 {
+		t.Fatalf("DiskFree = %+v, %v", usage, err)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	for name, filesystem := range filesystems(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := filesystem.MkdirAll(`C:\Config`, 0755); err != nil {
+				t.Fatal(err)
+			}
+			unlock, err := Lock(filesystem, `C:\Config\a.json.lock`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer unlock()
+
+			for _, content := range []string{"old", "new"} {
+				if err := WriteFileAtomic(filesystem, `C:\Config\a.json`, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			data, err := filesystem.ReadFile(`C:\Config\a.json`)
+			if err != nil || string(data) != "new" {
+				t.Fatalf("ReadFile = %q, %v", data, err)
+			}
+			matches, err := filesystem.Glob(`C:\Config\*.tmp`)
+			if err != nil || len(matches) != 0 {
+				t.Fatalf("temporary files left: %q, %v", matches, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package fsys
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+// Locker 支持跨进程文件锁的文件系统
+type Locker interface {
+	// Lock 等待并获取 name 的独占锁（锁文件不存在时创建），返回释放锁的函数。
+	// 持有锁的进程退出时锁自动释放
+	Lock(name string) (unlock func(), err error)
+}
+
+// Lock 获取 name 的独占锁，用于多个进程读取、修改并写入同一个文件；
+// 文件系统不支持时直接返回
+func Lock(filesystem FS, name string) (func(), error) {
+	if locker, ok := filesystem.(Locker); ok {
+		return locker.Lock(name)
+	}
+	return func() {}, nil
+}
+
+// WriteFileAtomic 先写入同目录下的临时文件再重命名替换 name，
+// 其他进程不会读到写了一半的文件，写入失败时原文件不变
+func WriteFileAtomic(filesystem FS, name string, data []byte, perm fs.FileMode) error {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	tmp := name + "." + hex.EncodeToString(suffix) + ".tmp"
+
+	if err := filesystem.WriteFile(tmp, data, perm); err != nil {
+		filesystem.Remove(tmp)
+		return err
+	}
+	if err := filesystem.Rename(tmp, name); err != nil {
+		filesystem.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (osFS) Lock(name string) (func(), error) {
+	return lockFile(name)
+}
+
+func (r *Rooted) Lock(name string) (func(), error) {
+	real, err := r.realPath("lock", name)
+	if err != nil {
+		return nil, err
+	}
+	return lockFile(real)
+}
+
+// Lock 内存文件系统只有一个进程，用互斥锁模拟
+func (m *Mem) Lock(name string) (func(), error) {
+	parts, ok := splitPath(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "lock", Path: name, Err: ErrOutsideRoot}
+	}
+	key := strings.Join(parts, "/")
+
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := m.locks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.locks[key] = lock
+	}
+	m.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock, nil
+}
@@ -0,0 +1,14 @@
+//go:build !windows && !linux && !darwin
+
+package fsys
+
+import "os"
+
+// lockFile 其他系统不支持文件锁，只创建锁文件
+func lockFile(name string) (func(), error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return func() { f.Close() }, nil
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin
+
+package fsys
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockFile 打开（必要时创建）锁文件并用 flock 加独占锁，关闭文件时释放
+func lockFile(name string) (func(), error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		f.Close()
+		return nil, &os.PathError{Op: "lock", Path: name, Err: err}
+	}
+	return func() { f.Close() }, nil
+}
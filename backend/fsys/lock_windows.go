@@ -0,0 +1,24 @@
+package fsys
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile 打开（必要时创建）锁文件并用 LockFileEx 加独占锁，关闭文件时释放
+func lockFile(name string) (func(), error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	var overlapped windows.Overlapped
+	if err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &overlapped); err != nil {
+		f.Close()
+		return nil, &os.PathError{Op: "lock", Path: name, Err: err}
+	}
+	return func() {
+		windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &overlapped)
+		f.Close()
+	}, nil
+}
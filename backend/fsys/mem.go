@@ -0,0 +1,338 @@
+package fsys
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Mem 内存文件系统（AddFile 添加的文件只记录大小和修改时间，WriteFile 写入的文件保存内容）。
+// 与 NTFS 一致，删除、重命名和写入新文件时更新所在目录的修改时间
+type Mem struct {
+	mu       sync.Mutex
+	root     *memNode
+	capacity int64
+	locks    map[string]*sync.Mutex // Lock 使用的锁
+}
+
+type memNode struct {
+	name     string
+	dir      bool
+	size     int64
+	modTime  time.Time
+	data     []byte
+	children map[string]*memNode
+}
+
+// NewMem 创建内存文件系统，capacity 为模拟的磁盘总容量
+func NewMem(capacity int64) *Mem {
+	return &Mem{
+		root:     &memNode{dir: true, children: make(map[string]*memNode)},
+		capacity: capacity,
+	}
+}
+
+// AddFile 添加文件（自动创建父目录）
+func (m *Mem) AddFile(path string, size int64, modTime time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parts, ok := splitPath(path)
+	if !ok || len(parts) == 0 {
+		return
+	}
+	parent := m.mkdirAll(parts[:len(parts)-1], modTime)
+	name := parts[len(parts)-1]
+	parent.children[name] = &memNode{name: name, size: size, modTime: modTime}
+}
+
+// AddDir 添加目录（自动创建父目录）
+func (m *Mem) AddDir(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if parts, ok := splitPath(path); ok {
+		m.mkdirAll(parts, time.Now())
+	}
+}
+
+// Exists 判断路径是否存在
+func (m *Mem) Exists(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lookup(path) != nil
+}
+
+// Files 返回所有文件路径（按字典序）
+func (m *Mem) Files() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var files []string
+	var collect func(parts []string, node *memNode)
+	collect = func(parts []string, node *memNode) {
+		for name, child := range node.children {
+			childParts := append(parts[:len(parts):len(parts)], name)
+			if child.dir {
+				collect(childParts, child)
+			} else {
+				files = append(files, joinPath(childParts))
+			}
+		}
+	}
+	collect(nil, m.root)
+	sort.Strings(files)
+	return files
+}
+
+func (m *Mem) mkdirAll(parts []string, modTime time.Time) *memNode {
+	node := m.root
+	for _, part := range parts {
+		child, ok := node.children[part]
+		if !ok || !child.dir {
+			child = &memNode{name: part, dir: true, modTime: modTime, children: make(map[string]*memNode)}
+			node.children[part] = child
+		}
+		node = child
+	}
+	return node
+}
+
+func (m *Mem) lookup(path string) *memNode {
+	parts, ok := splitPath(path)
+	if !ok {
+		return nil
+	}
+	node := m.root
+	for _, part := range parts {
+		if !node.dir {
+			return nil
+		}
+		child, ok := node.children[part]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+	return node
+}
+
+func (m *Mem) Stat(name string) (fs.FileInfo, error) {
+	return m.Lstat(name)
+}
+
+func (m *Mem) Lstat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node := m.lookup(name)
+	if node == nil {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+	}
+	return node.info(), nil
+}
+
+func (m *Mem) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node := m.lookup(name)
+	if node == nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	if !node.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(node.children))
+	for _, child := range node.children {
+		entries = append(entries, fs.FileInfoToDirEntry(child.info()))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+func (m *Mem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, node, base := m.lookupParent(name)
+	if node == nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if node.dir && len(node.children) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+	}
+	delete(parent.children, base)
+	parent.modTime = time.Now()
+	return nil
+}
+
+func (m *Mem) RemoveAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, node, base := m.lookupParent(name)
+	if node == nil {
+		return nil
+	}
+	delete(parent.children, base)
+	parent.modTime = time.Now()
+	return nil
+}
+
+func (m *Mem) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldParent, node, oldBase := m.lookupParent(oldpath)
+	if node == nil {
+		return &fs.PathError{Op: "rename", Path: oldpath, Err: fs.ErrNotExist}
+	}
+	newParent, existing, newBase := m.lookupParent(newpath)
+	if newParent == nil {
+		return &fs.PathError{Op: "rename", Path: newpath, Err: fs.ErrNotExist}
+	}
+	if existing != nil && existing.dir {
+		return &fs.PathError{Op: "rename", Path: newpath, Err: fs.ErrExist}
+	}
+
+	delete(oldParent.children, oldBase)
+	node.name = newBase
+	newParent.children[newBase] = node
+	oldParent.modTime = time.Now()
+	newParent.modTime = oldParent.modTime
+	return nil
+}
+
+func (m *Mem) MkdirAll(path string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parts, ok := splitPath(path)
+	if !ok {
+		return &fs.PathError{Op: "mkdir", Path: path, Err: ErrOutsideRoot}
+	}
+	if node := m.lookup(path); node != nil && !node.dir {
+		return &fs.PathError{Op: "mkdir", Path: path, Err: errors.New("not a directory")}
+	}
+	m.mkdirAll(parts, time.Now())
+	return nil
+}
+
+func (m *Mem) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node := m.lookup(name)
+	if node == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if node.dir {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	return append([]byte(nil), node.data...), nil
+}
+
+func (m *Mem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, node, base := m.lookupParent(name)
+	if parent == nil {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if node != nil && node.dir {
+		return &fs.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+	}
+	parent.children[base] = &memNode{
+		name:    base,
+		size:    int64(len(data)),
+		modTime: time.Now(),
+		data:    append([]byte(nil), data...),
+	}
+	if node == nil {
+		parent.modTime = time.Now()
+	}
+	return nil
+}
+
+func (m *Mem) lookupParent(name string) (*memNode, *memNode, string) {
+	parts, ok := splitPath(name)
+	if !ok || len(parts) == 0 {
+		return nil, nil, ""
+	}
+	parent := m.root
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := parent.children[part]
+		if !ok || !child.dir {
+			return nil, nil, ""
+		}
+		parent = child
+	}
+	base := parts[len(parts)-1]
+	return parent, parent.children[base], base
+}
+
+func (m *Mem) Walk(root string, fn filepath.WalkFunc) error {
+	return walk(m, root, fn)
+}
+
+func (m *Mem) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return walkDir(m, root, fn)
+}
+
+func (m *Mem) Glob(pattern string) ([]string, error) {
+	return glob(m, pattern)
+}
+
+// DiskFree 返回模拟的磁盘空间（已用空间为所有文件大小之和）
+func (m *Mem) DiskFree(path string) (DiskUsage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var used int64
+	var sum func(node *memNode)
+	sum = func(node *memNode) {
+		for _, child := range node.children {
+			if child.dir {
+				sum(child)
+			} else {
+				used += child.size
+			}
+		}
+	}
+	sum(m.root)
+
+	return DiskUsage{Total: m.capacity, Free: m.capacity - used}, nil
+}
+
+func (n *memNode) info() fs.FileInfo {
+	return memInfo{name: n.name, dir: n.dir, size: n.size, modTime: n.modTime}
+}
+
+type memInfo struct {
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) ModTime() time.Time { return i.modTime }
+func (i memInfo) IsDir() bool        { return i.dir }
+func (i memInfo) Sys() any           { return nil }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
@@ -0,0 +1,159 @@
+package fsys
+
+import (
+	"ccooler/backend/apperr"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Rooted 将所有绝对路径映射到本机指定根目录下（如 C:\Windows\Temp -> <root>/C/Windows/Temp），
+// 回调和返回值中的路径仍保持原始形式
+type Rooted struct {
+	root string
+}
+
+// NewRooted 创建以 root 为根目录的文件系统
+func NewRooted(root string) *Rooted {
+	return &Rooted{root: root}
+}
+
+// ErrOutsideRoot 路径通过 .. 超出了盘符或根目录
+var ErrOutsideRoot = apperr.New(apperr.OutsideRoot)
+
+// RealPath 返回路径在本机上的实际位置（盘符去掉冒号作为一级目录），超出根目录时返回 ErrOutsideRoot
+func (r *Rooted) RealPath(name string) (string, error) {
+	parts, ok := splitPath(name)
+	if !ok {
+		return "", ErrOutsideRoot
+	}
+	if len(parts) > 0 {
+		parts[0] = strings.TrimSuffix(parts[0], ":")
+	}
+	real := filepath.Join(append([]string{r.root}, parts...)...)
+	if rel, err := filepath.Rel(r.root, real); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrOutsideRoot
+	}
+	return real, nil
+}
+
+// realPath 返回实际位置，超出根目录时返回 op 的 PathError
+func (r *Rooted) realPath(op, name string) (string, error) {
+	real, err := r.RealPath(name)
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return real, nil
+}
+
+func (r *Rooted) Stat(name string) (fs.FileInfo, error) {
+	real, err := r.realPath("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(real)
+	return info, r.rewrap(err, name)
+}
+
+func (r *Rooted) Lstat(name string) (fs.FileInfo, error) {
+	real, err := r.realPath("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(real)
+	return info, r.rewrap(err, name)
+}
+
+func (r *Rooted) ReadDir(name string) ([]fs.DirEntry, error) {
+	real, err := r.realPath("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(real)
+	return entries, r.rewrap(err, name)
+}
+
+func (r *Rooted) Remove(name string) error {
+	real, err := r.realPath("remove", name)
+	if err != nil {
+		return err
+	}
+	return r.rewrap(os.Remove(real), name)
+}
+
+func (r *Rooted) RemoveAll(name string) error {
+	real, err := r.realPath("removeall", name)
+	if err != nil {
+		return err
+	}
+	return r.rewrap(os.RemoveAll(real), name)
+}
+
+func (r *Rooted) Rename(oldpath, newpath string) error {
+	oldReal, err := r.realPath("rename", oldpath)
+	if err != nil {
+		return err
+	}
+	newReal, err := r.realPath("rename", newpath)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(oldReal, newReal)
+	var linkErr *os.LinkError
+	if errors.As(err, &linkErr) {
+		return &os.LinkError{Op: linkErr.Op, Old: oldpath, New: newpath, Err: linkErr.Err}
+	}
+	return err
+}
+
+func (r *Rooted) MkdirAll(path string, perm fs.FileMode) error {
+	real, err := r.realPath("mkdir", path)
+	if err != nil {
+		return err
+	}
+	return r.rewrap(os.MkdirAll(real, perm), path)
+}
+
+func (r *Rooted) ReadFile(name string) ([]byte, error) {
+	real, err := r.realPath("open", name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(real)
+	return data, r.rewrap(err, name)
+}
+
+func (r *Rooted) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	real, err := r.realPath("open", name)
+	if err != nil {
+		return err
+	}
+	return r.rewrap(os.WriteFile(real, data, perm), name)
+}
+
+func (r *Rooted) Walk(root string, fn filepath.WalkFunc) error {
+	return walk(r, root, fn)
+}
+
+func (r *Rooted) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return walkDir(r, root, fn)
+}
+
+func (r *Rooted) Glob(pattern string) ([]string, error) {
+	return glob(r, pattern)
+}
+
+func (r *Rooted) DiskFree(path string) (DiskUsage, error) {
+	return diskFree(r.root)
+}
+
+// rewrap 将错误中的实际路径替换回原始路径
+func (r *Rooted) rewrap(err error, name string) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return &fs.PathError{Op: pathErr.Op, Path: name, Err: pathErr.Err}
+	}
+	return err
+}
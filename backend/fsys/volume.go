@@ -0,0 +1,67 @@
+package fsys
+
+import (
+	"ccooler/backend/apperr"
+	"os"
+	"strings"
+)
+
+// 分区类型（Volume.Type）
+const (
+	VolumeFixed     = "fixed"
+	VolumeRemovable = "removable"
+	VolumeNetwork   = "network"
+	VolumeCDROM     = "cdrom"
+	VolumeRAMDisk   = "ramdisk"
+	VolumeUnknown   = "unknown"
+)
+
+// Volume 分区信息
+type Volume struct {
+	Root       string `json:"root"`       // 根目录，如 "D:\\"
+	Label      string `json:"label"`      // 卷标
+	FileSystem string `json:"fileSystem"` // 文件系统，如 NTFS
+	Type       string `json:"type"`
+	Total      int64  `json:"total"`
+	Free       int64  `json:"free"`
+	System     bool   `json:"system"` // Windows 所在的分区
+}
+
+// SystemDrive 返回 Windows 所在分区的根目录（%SystemDrive%，无法获取时为 C:\）
+func SystemDrive() string {
+	if drive, err := DriveRoot(os.Getenv("SystemDrive")); err == nil {
+		return drive
+	}
+	return "C:\\"
+}
+
+// DriveRoot 把 "d"、"D:"、"D:\" 等形式规范化为分区根目录 "D:\"
+func DriveRoot(drive string) (string, error) {
+	text := strings.TrimRight(strings.TrimSpace(drive), "\\/")
+	text = strings.TrimSuffix(text, ":")
+	if len(text) != 1 {
+		return "", apperr.New(apperr.InvalidDrive, "drive", drive)
+	}
+	letter := strings.ToUpper(text)[0]
+	if letter < 'A' || letter > 'Z' {
+		return "", apperr.New(apperr.InvalidDrive, "drive", drive)
+	}
+	return string(letter) + ":\\", nil
+}
+
+// Volumes 列出本机分区，includeAll 为 false 时只列出本地固定磁盘（不包括可移动磁盘、网络驱动器和光驱）
+func Volumes(includeAll bool) ([]Volume, error) {
+	all, err := listVolumes()
+	if err != nil {
+		return nil, err
+	}
+	system := SystemDrive()
+	volumes := []Volume{}
+	for _, volume := range all {
+		volume.System = strings.EqualFold(volume.Root, system)
+		if includeAll || volume.Type == VolumeFixed || volume.System {
+			volumes = append(volumes, volume)
+		}
+	}
+	return volumes, nil
+}
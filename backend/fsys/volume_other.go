@@ -0,0 +1,13 @@
+//go:build !windows
+
+package fsys
+
+// listVolumes 非 Windows 系统只列出根目录（用于开发调试）
+func listVolumes() ([]Volume, error) {
+	volume := Volume{Root: SystemDrive(), Type: VolumeFixed}
+	if usage, err := diskFree("/"); err == nil {
+		volume.Total = usage.Total
+		volume.Free = usage.Free
+	}
+	return []Volume{volume}, nil
+}
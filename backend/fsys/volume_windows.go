@@ -0,0 +1,52 @@
+package fsys
+
+import "golang.org/x/sys/windows"
+
+func listVolumes() ([]Volume, error) {
+	mask, err := windows.GetLogicalDrives()
+	if err != nil {
+		return nil, err
+	}
+
+	var volumes []Volume
+	for i := 0; i < 26; i++ {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		root := string(rune('A'+i)) + ":\\"
+		rootPtr, err := windows.UTF16PtrFromString(root)
+		if err != nil {
+			continue
+		}
+		volume := Volume{Root: root, Type: driveType(windows.GetDriveType(rootPtr))}
+
+		// 光驱没有光盘、网络驱动器断开时无法读取卷信息和空间，仍然列出
+		var label, fileSystem [windows.MAX_PATH + 1]uint16
+		if err := windows.GetVolumeInformation(rootPtr, &label[0], uint32(len(label)), nil, nil, nil, &fileSystem[0], uint32(len(fileSystem))); err == nil {
+			volume.Label = windows.UTF16ToString(label[:])
+			volume.FileSystem = windows.UTF16ToString(fileSystem[:])
+		}
+		if usage, err := diskFree(root); err == nil {
+			volume.Total = usage.Total
+			volume.Free = usage.Free
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes, nil
+}
+
+func driveType(t uint32) string {
+	switch t {
+	case windows.DRIVE_FIXED:
+		return VolumeFixed
+	case windows.DRIVE_REMOVABLE:
+		return VolumeRemovable
+	case windows.DRIVE_REMOTE:
+		return VolumeNetwork
+	case windows.DRIVE_CDROM:
+		return VolumeCDROM
+	case windows.DRIVE_RAMDISK:
+		return VolumeRAMDisk
+	}
+	return VolumeUnknown
+}
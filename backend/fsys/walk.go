@@ -0,0 +1,177 @@
+package fsys
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// walk 基于 FS 的 Lstat/ReadDir 实现与 filepath.Walk 相同的语义
+func walk(fsys FS, root string, fn filepath.WalkFunc) error {
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkInfo(fsys, root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func walkInfo(fsys FS, path string, info fs.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	entries, err := fsys.ReadDir(path)
+	err1 := fn(path, info, err)
+	if err != nil || err1 != nil {
+		return err1
+	}
+
+	for _, entry := range entries {
+		filename := filepath.Join(path, entry.Name())
+		fileInfo, err := fsys.Lstat(filename)
+		if err != nil {
+			if err := fn(filename, fileInfo, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+
+		err = walkInfo(fsys, filename, fileInfo, fn)
+		if err != nil {
+			if !fileInfo.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walkDir 基于 FS 的 Lstat/ReadDir 实现与 filepath.WalkDir 相同的语义
+func walkDir(fsys FS, root string, fn fs.WalkDirFunc) error {
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkDirEntry(fsys, root, fs.FileInfoToDirEntry(info), fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func walkDirEntry(fsys FS, path string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(path, d, nil); err != nil || !d.IsDir() {
+		if err == filepath.SkipDir && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+
+	entries, err := fsys.ReadDir(path)
+	if err != nil {
+		err = fn(path, d, err)
+		if err != nil {
+			if err == filepath.SkipDir && d.IsDir() {
+				err = nil
+			}
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		if err := walkDirEntry(fsys, filepath.Join(path, entry.Name()), entry, fn); err != nil {
+			if err == filepath.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// glob 基于 FS 的 ReadDir 实现与 filepath.Glob 相同的匹配规则
+func glob(fsys FS, pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	if !hasMeta(pattern) {
+		if _, err := fsys.Lstat(pattern); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+
+	// 同时识别 / 和 \ 分隔符，便于在任意系统上匹配 Windows 路径
+	dir, file := ".", pattern
+	if i := strings.LastIndexAny(pattern, `/\`); i > 0 {
+		dir, file = pattern[:i], pattern[i+1:]
+	} else if i == 0 {
+		dir, file = pattern[:1], pattern[1:]
+	}
+
+	var dirs []string
+	if hasMeta(dir) {
+		matches, err := glob(fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+		dirs = matches
+	} else {
+		dirs = []string{dir}
+	}
+
+	var matches []string
+	for _, d := range dirs {
+		entries, err := fsys.ReadDir(d)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if ok, _ := filepath.Match(file, entry.Name()); ok {
+				matches = append(matches, filepath.Join(d, entry.Name()))
+			}
+		}
+	}
+	return matches, nil
+}
+
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[`)
+}
+
+// splitPath 将路径拆分为各级名称（同时识别 / 和 \，便于在任意系统上使用 Windows 路径），
+// 去掉 . 并处理 ..；ok 为 false 表示 .. 超出了盘符或根目录
+func splitPath(name string) (parts []string, ok bool) {
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' }) {
+		switch part {
+		case ".":
+		case "..":
+			if len(parts) == 0 || len(parts) == 1 && strings.HasSuffix(parts[0], ":") {
+				return nil, false
+			}
+			parts = parts[:len(parts)-1]
+		default:
+			parts = append(parts, part)
+		}
+	}
+	return parts, true
+}
+
+// joinPath 将各级名称还原为绝对路径（首级为盘符时不加前导分隔符）
+func joinPath(parts []string) string {
+	path := strings.Join(parts, string(filepath.Separator))
+	if len(parts) > 0 && strings.HasSuffix(parts[0], ":") {
+		if len(parts) == 1 {
+			return path + string(filepath.Separator)
+		}
+		return path
+	}
+	return string(filepath.Separator) + path
+}
@@ -0,0 +1,462 @@
+// Package history 扫描和清理记录：每次扫描、清理的时间、清理项、各路径释放的空间、未能清理的文件、
+// 是否以管理员权限执行、耗时以及前后的剩余空间。记录保存在用户配置目录，程序窗口、命令行和自动清理共用，
+// 可按时间和清理项查询、按清理项汇总（释放的空间、清理后重新增长的速度），以及删除旧记录。
+package history
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
+	"ccooler/backend/models"
+	"ccooler/backend/ops"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileVersion 记录文件格式版本
+const FileVersion = 1
+
+// MaxRecords 最多保留的记录，超出时删除最早的记录
+const MaxRecords = 2000
+
+// 记录类型（Record.Kind）
+const (
+	KindScan  = "scan"
+	KindClean = "clean"
+)
+
+// 记录来源（Record.Source）
+const (
+	SourceApp      = "app"      // 程序窗口
+	SourceCLI      = "cli"      // 命令行
+	SourceSchedule = "schedule" // 自动清理方案
+)
+
+// 记录状态（Record.Status）
+const (
+	StatusCompleted = "completed"
+	StatusPartial   = "partial" // 有清理项出错，或出错前已清理了部分文件
+	StatusFailed    = "failed"
+	StatusCancelled = ops.StatusCancelled
+)
+
+// Item 记录中的清理项
+type Item struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Size   int64  `json:"size"`             // 扫描：扫描到的大小；清理：释放的大小
+	Files  int    `json:"files"`            // 扫描：文件数；清理：删除的文件数
+	Failed int    `json:"failed,omitempty"` // 清理：未能清理的文件数
+}
+
+// Record 一次扫描或清理
+type Record struct {
+	ID         string    `json:"id"`
+	Kind       string    `json:"kind"`
+	Source     string    `json:"source"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Elevated   bool      `json:"elevated"` // 以管理员权限执行（包括通过辅助程序）
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	FreeBefore int64     `json:"freeBefore,omitempty"` // 开始前系统盘剩余空间（无法获取时为 0）
+	FreeAfter  int64     `json:"freeAfter,omitempty"`  // 结束后系统盘剩余空间
+	Items      []Item    `json:"items"`
+
+	// 清理结果
+	FreedSize      int64                     `json:"freedSize,omitempty"`
+	RemovedCount   int                       `json:"removedCount,omitempty"`
+	SkippedSize    int64                     `json:"skippedSize,omitempty"`
+	SkippedCount   int                       `json:"skippedCount,omitempty"`
+	SkippedReasons map[models.SkipReason]int `json:"skippedReasons,omitempty"`
+	Paths          []models.PathReport       `json:"paths,omitempty"`
+	QuarantineRun  string                    `json:"quarantineRun,omitempty"` // 隔离批次 ID
+}
+
+// Begin 开始一条记录，free 为开始前的剩余空间（小于 0 表示无法获取）
+func Begin(kind, source string, elevated bool, free int64) *Record {
+	var b [3]byte
+	rand.Read(b[:])
+	now := time.Now()
+	return &Record{
+		ID:         now.Format("20060102-150405") + "-" + hex.EncodeToString(b[:]),
+		Kind:       kind,
+		Source:     source,
+		StartedAt:  now,
+		Elevated:   elevated,
+		FreeBefore: max(free, 0),
+		Items:      []Item{},
+	}
+}
+
+// SetScan 记录扫描结果
+func (r *Record) SetScan(items []*models.CleanItem) {
+	r.Items = []Item{}
+	for _, item := range items {
+		r.Items = append(r.Items, Item{ID: item.ID, Name: item.Name, Status: item.Status, Error: item.Error, Size: item.Size, Files: item.FileCount})
+	}
+}
+
+// SetClean 记录清理结果：items 为要清理的清理项，report 为清理报告（可为空）。
+// 清理项释放的空间按报告中各路径所属的清理项统计，报告中有而 items 中没有的清理项也会列出
+func (r *Record) SetClean(items []*models.CleanItem, report *models.CleanReport) {
+	r.Items = []Item{}
+	index := make(map[string]int)
+	for _, item := range items {
+		index[item.ID] = len(r.Items)
+		r.Items = append(r.Items, Item{ID: item.ID, Name: item.Name, Status: item.Status, Error: item.Error})
+	}
+	if report == nil {
+		return
+	}
+
+	r.FreedSize = report.FreedSize
+	r.RemovedCount = report.RemovedCount
+	r.SkippedSize = report.SkippedSize
+	r.SkippedCount = report.SkippedCount
+	if len(report.SkippedReasons) > 0 {
+		r.SkippedReasons = report.SkippedReasons
+	}
+	r.Paths = append([]models.PathReport{}, report.Paths...)
+	for _, p := range report.Paths {
+		i, ok := index[p.ItemID]
+		if !ok {
+			i = len(r.Items)
+			index[p.ItemID] = i
+			r.Items = append(r.Items, Item{ID: p.ItemID})
+		}
+		r.Items[i].Size += p.FreedSize
+		r.Items[i].Files += p.RemovedCount
+		r.Items[i].Failed += p.SkippedCount
+	}
+	// 辅助程序清理的项目在主程序中没有更新状态
+	for i := range r.Items {
+		if status := r.Items[i].Status; status == "" || status == "scanned" {
+			r.Items[i].Status = "completed"
+		}
+	}
+}
+
+// Finish 记录耗时、结束后的剩余空间（小于 0 表示无法获取）和状态，err 为操作失败的原因
+func (r *Record) Finish(ctx context.Context, free int64, err error) {
+	r.DurationMs = time.Since(r.StartedAt).Milliseconds()
+	r.FreeAfter = max(free, 0)
+	switch {
+	case ops.Cancelled(ctx):
+		r.Status = StatusCancelled
+	case err != nil:
+		// 出错前已经删除了文件时为部分完成
+		r.Status = StatusFailed
+		if r.RemovedCount > 0 {
+			r.Status = StatusPartial
+		}
+		r.Error = err.Error()
+	default:
+		r.Status = StatusCompleted
+		for _, item := range r.Items {
+			if item.Status == "error" {
+				r.Status = StatusPartial
+				break
+			}
+		}
+	}
+}
+
+// Query 查询条件（零值表示不限）
+type Query struct {
+	Kind   string    // scan 或 clean
+	ItemID string    // 包含该清理项的记录
+	Since  time.Time // 开始时间不早于
+	Until  time.Time // 开始时间早于
+	Limit  int       // 最多返回的记录数（最新的优先）
+}
+
+// match 记录是否符合查询条件
+func (q Query) match(r *Record) bool {
+	if q.Kind != "" && r.Kind != q.Kind {
+		return false
+	}
+	if !q.Since.IsZero() && r.StartedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !r.StartedAt.Before(q.Until) {
+		return false
+	}
+	if q.ItemID != "" {
+		for _, item := range r.Items {
+			if item.ID == q.ItemID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// ItemStats 清理项的汇总
+type ItemStats struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Scans        int       `json:"scans"`
+	Cleans       int       `json:"cleans"`
+	Errors       int       `json:"errors"`       // 清理出错的次数
+	FreedSize    int64     `json:"freedSize"`    // 累计释放的空间
+	RemovedCount int       `json:"removedCount"` // 累计删除的文件数
+	FailedCount  int       `json:"failedCount"`  // 累计未能清理的文件数
+	LastScanSize int64     `json:"lastScanSize"` // 最近一次扫描到的大小
+	LastCleaned  time.Time `json:"lastCleaned,omitempty"`
+	GrowthPerDay int64     `json:"growthPerDay"` // 清理后重新增长的速度（字节/天），按清理后下一次扫描到的大小估算，没有数据时为 0
+}
+
+// Summary 汇总结果
+type Summary struct {
+	Scans        int         `json:"scans"`
+	Cleans       int         `json:"cleans"`
+	FreedSize    int64       `json:"freedSize"`
+	RemovedCount int         `json:"removedCount"`
+	SkippedCount int         `json:"skippedCount"`
+	Items        []ItemStats `json:"items"` // 按释放的空间从大到小排列
+}
+
+// minGrowthWindow 清理和扫描间隔太短时不估算增长速度
+const minGrowthWindow = time.Hour
+
+// Store 记录文件（并发安全；每次操作都重新读取文件，修改时对文件加跨进程锁，
+// 程序窗口和命令行可以同时使用）
+type Store struct {
+	fs   fsys.FS
+	path string
+	mu   sync.Mutex
+}
+
+// data 记录文件内容（按开始时间排列）
+type data struct {
+	Version int       `json:"version"`
+	Records []*Record `json:"records"`
+}
+
+// DefaultPath 默认的记录文件位置（用户配置目录，无法获取时使用程序目录）
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		exePath, _ := os.Executable()
+		dir = filepath.Dir(exePath)
+	}
+	return filepath.Join(dir, "CCooler", "history.json")
+}
+
+// New 创建记录存储
+func New(filesystem fsys.FS, file string) *Store {
+	return &Store{fs: filesystem, path: file}
+}
+
+// load 读取记录文件，文件不存在时返回空内容
+func (s *Store) load() (*data, error) {
+	d := &data{Version: FileVersion}
+	content, err := s.fs.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return d, nil
+		}
+		return nil, apperr.Wrap(apperr.ReadFailed, err, "file", s.path)
+	}
+	if err := json.Unmarshal(content, d); err != nil {
+		return nil, apperr.Wrap(apperr.FileInvalid, err, "file", s.path)
+	}
+	if d.Version != FileVersion {
+		return nil, apperr.New(apperr.FileVersion, "file", s.path, "version", d.Version)
+	}
+	return d, nil
+}
+
+// save 写入记录文件（先写临时文件再替换，其他进程不会读到写了一半的文件）
+func (s *Store) save(d *data) error {
+	content, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	if err := fsys.WriteFileAtomic(s.fs, s.path, content, 0644); err != nil {
+		return apperr.Wrap(apperr.SaveFailed, err, "file", s.path)
+	}
+	return nil
+}
+
+// lock 对记录文件加跨进程锁（记录文件旁边的 .lock 文件），读取、修改并写入期间持有，
+// 避免程序窗口和命令行同时保存时丢失记录
+func (s *Store) lock() (func(), error) {
+	if err := s.fs.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return nil, apperr.Wrap(apperr.SaveFailed, err, "file", s.path)
+	}
+	unlock, err := fsys.Lock(s.fs, s.path+".lock")
+	if err != nil {
+		return nil, apperr.Wrap(apperr.SaveFailed, err, "file", s.path)
+	}
+	return unlock, nil
+}
+
+// Add 保存一条记录，超过 MaxRecords 时删除最早的记录
+func (s *Store) Add(record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	d, err := s.load()
+	if err != nil {
+		return err
+	}
+	d.Records = append(d.Records, record)
+	sort.SliceStable(d.Records, func(i, j int) bool { return d.Records[i].StartedAt.Before(d.Records[j].StartedAt) })
+	if len(d.Records) > MaxRecords {
+		d.Records = append([]*Record{}, d.Records[len(d.Records)-MaxRecords:]...)
+	}
+	return s.save(d)
+}
+
+// List 返回符合条件的记录，最新的在前
+func (s *Store) List(q Query) ([]*Record, error) {
+	s.mu.Lock()
+	d, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	records := []*Record{}
+	for i := len(d.Records) - 1; i >= 0; i-- {
+		if q.Limit > 0 && len(records) >= q.Limit {
+			break
+		}
+		if q.match(d.Records[i]) {
+			records = append(records, d.Records[i])
+		}
+	}
+	return records, nil
+}
+
+// Aggregate 按清理项汇总符合条件的记录（忽略 Limit）
+func (s *Store) Aggregate(q Query) (*Summary, error) {
+	s.mu.Lock()
+	d, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{Items: []ItemStats{}}
+	stats := make(map[string]*ItemStats)
+	cleanedAt := make(map[string]time.Time) // 清理后还没有扫描过的清理项的清理结束时间
+	growth := make(map[string]*[2]float64)  // 清理后重新增长的大小和天数
+	q.Limit = 0
+	for _, r := range d.Records {
+		if !q.match(r) || r.Status == StatusFailed {
+			continue
+		}
+		if r.Kind == KindClean {
+			summary.Cleans++
+			summary.FreedSize += r.FreedSize
+			summary.RemovedCount += r.RemovedCount
+			summary.SkippedCount += r.SkippedCount
+		} else {
+			summary.Scans++
+		}
+
+		for _, item := range r.Items {
+			if q.ItemID != "" && item.ID != q.ItemID {
+				continue
+			}
+			st := stats[item.ID]
+			if st == nil {
+				st = &ItemStats{ID: item.ID}
+				stats[item.ID] = st
+			}
+			if item.Name != "" {
+				st.Name = item.Name
+			}
+			if r.Kind == KindScan {
+				if item.Status == "error" || item.Status == StatusCancelled {
+					continue
+				}
+				st.Scans++
+				st.LastScanSize = item.Size
+				// 清理后的第一次扫描：清理后重新增长的大小
+				if cleaned, ok := cleanedAt[item.ID]; ok {
+					if elapsed := r.StartedAt.Sub(cleaned); elapsed >= minGrowthWindow {
+						g := growth[item.ID]
+						if g == nil {
+							g = &[2]float64{}
+							growth[item.ID] = g
+						}
+						g[0] += float64(item.Size)
+						g[1] += elapsed.Hours() / 24
+					}
+					delete(cleanedAt, item.ID)
+				}
+				continue
+			}
+
+			st.Cleans++
+			st.FreedSize += item.Size
+			st.RemovedCount += item.Files
+			st.FailedCount += item.Failed
+			if item.Status == "error" {
+				st.Errors++
+			} else {
+				st.LastCleaned = r.StartedAt
+				cleanedAt[item.ID] = r.StartedAt.Add(time.Duration(r.DurationMs) * time.Millisecond)
+			}
+		}
+	}
+
+	for id, st := range stats {
+		if g := growth[id]; g != nil && g[1] > 0 {
+			st.GrowthPerDay = int64(g[0] / g[1])
+		}
+		summary.Items = append(summary.Items, *st)
+	}
+	sort.Slice(summary.Items, func(i, j int) bool {
+		if summary.Items[i].FreedSize != summary.Items[j].FreedSize {
+			return summary.Items[i].FreedSize > summary.Items[j].FreedSize
+		}
+		return summary.Items[i].ID < summary.Items[j].ID
+	})
+	return summary, nil
+}
+
+// Prune 删除开始时间早于 before 的记录，返回删除的数量
+func (s *Store) Prune(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	d, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	kept := []*Record{}
+	for _, r := range d.Records {
+		if !r.StartedAt.Before(before) {
+			kept = append(kept, r)
+		}
+	}
+	removed := len(d.Records) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	d.Records = kept
+	return removed, s.save(d)
+}
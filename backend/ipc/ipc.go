@@ -0,0 +1,223 @@
+// Package ipc 主程序与辅助程序 CCoolerElevated 之间的 HTTP 通信。
+//
+// 主程序每次启动生成随机密钥，每个辅助程序任务有独立的任务 ID，
+// 任务密钥由主程序密钥和任务 ID 派生，通过命令行传给辅助程序。
+// 辅助程序的每个请求带任务 ID、序号和 HMAC 签名（覆盖方法、路径和内容），
+// 主程序只接受已登记任务的、签名正确且序号未使用过的请求，结果只发给对应的任务。
+package ipc
+
+import (
+	"bytes"
+	"ccooler/backend/apperr"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 请求头
+const (
+	HeaderTask      = "X-CCooler-Task"
+	HeaderSeq       = "X-CCooler-Seq"
+	HeaderSignature = "X-CCooler-Signature"
+)
+
+// MaxBody 请求内容的最大长度（清理报告可能较大）
+const MaxBody = 64 << 20
+
+var (
+	ErrUnknownTask  = apperr.New(apperr.UnknownTaskID)
+	ErrBadSignature = apperr.New(apperr.BadSignature)
+	ErrReplay       = apperr.New(apperr.ReplayedRequest)
+)
+
+// NewTaskID 生成任务 ID（prefix 用于日志中区分任务类型）
+func NewTaskID(prefix string) string {
+	var b [8]byte
+	rand.Read(b[:])
+	return prefix + "-" + hex.EncodeToString(b[:])
+}
+
+// Sign 计算请求签名
+func Sign(key, taskID string, seq uint64, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	fmt.Fprintf(mac, "%s\n%d\n%s %s\n", taskID, seq, method, path)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Server 主程序一侧：登记任务并校验辅助程序的请求
+type Server struct {
+	secret []byte
+
+	mu    sync.Mutex
+	tasks map[string]map[uint64]bool // 任务 ID -> 已使用的序号
+}
+
+// NewServer 生成本次启动的随机密钥
+func NewServer() *Server {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("生成通信密钥失败: %v", err))
+	}
+	return &Server{secret: secret, tasks: make(map[string]map[uint64]bool)}
+}
+
+// Register 登记任务，返回传给辅助程序的任务密钥
+func (s *Server) Register(taskID string) string {
+	s.mu.Lock()
+	s.tasks[taskID] = make(map[uint64]bool)
+	s.mu.Unlock()
+	return s.key(taskID)
+}
+
+// Unregister 任务结束，之后该任务的请求都会被拒绝
+func (s *Server) Unregister(taskID string) {
+	s.mu.Lock()
+	delete(s.tasks, taskID)
+	s.mu.Unlock()
+}
+
+func (s *Server) key(taskID string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(taskID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 读取并校验请求，返回任务 ID 和请求内容
+func (s *Server) Verify(r *http.Request) (string, []byte, error) {
+	taskID := r.Header.Get(HeaderTask)
+	seq, err := strconv.ParseUint(r.Header.Get(HeaderSeq), 10, 64)
+	if taskID == "" || err != nil {
+		return "", nil, ErrBadSignature
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBody))
+	if err != nil {
+		return "", nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen, ok := s.tasks[taskID]
+	if !ok {
+		return "", nil, ErrUnknownTask
+	}
+	expected := Sign(s.key(taskID), taskID, seq, r.Method, r.URL.Path, body)
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(HeaderSignature))) {
+		return "", nil, ErrBadSignature
+	}
+	if seen[seq] {
+		return "", nil, ErrReplay
+	}
+	seen[seq] = true
+	return taskID, body, nil
+}
+
+// Client 辅助程序一侧：向主程序发送签名的请求
+type Client struct {
+	base   string
+	taskID string
+	key    string
+	seq    atomic.Uint64
+	http   *http.Client
+}
+
+// NewClient 创建客户端，port、taskID、key 由主程序通过命令行传入
+func NewClient(port, taskID, key string) *Client {
+	return &Client{
+		base:   "http://127.0.0.1:" + port,
+		taskID: taskID,
+		key:    key,
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Post 发送 JSON 内容
+func (c *Client) Post(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(http.MethodPost, path, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get 查询并解析 JSON 回复
+func (c *Client) Get(path string, v any) error {
+	resp, err := c.do(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *Client) do(method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.base+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	seq := c.seq.Add(1)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTask, c.taskID)
+	req.Header.Set(HeaderSeq, strconv.FormatUint(seq, 10))
+	req.Header.Set(HeaderSignature, Sign(c.key, c.taskID, seq, method, path, body))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, apperr.New(apperr.RequestRejected, "status", resp.Status)
+	}
+	return resp, nil
+}
+
+// SessionTask 交给常驻辅助程序的任务（任务 ID、任务密钥和任务文件，与单次启动时的命令行参数相同）
+type SessionTask struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	File string `json:"file"`
+}
+
+// SessionReply 常驻辅助程序领取任务的回复：有任务、需要退出或都没有（继续等待）
+type SessionReply struct {
+	Task *SessionTask `json:"task,omitempty"`
+	Exit bool         `json:"exit,omitempty"`
+}
+
+// SessionHeartbeat 常驻辅助程序的心跳
+type SessionHeartbeat struct {
+	Task string `json:"task,omitempty"` // 正在执行的任务 ID
+}
+
+// 辅助程序任务的生命周期（TaskStatus.State），结果本身通过 /elevated-result 返回
+const (
+	StateStarted   = "started"   // 辅助程序已启动，读取任务前发送
+	StateElevated  = "elevated"  // 已确认以管理员权限运行
+	StateHeartbeat = "heartbeat" // 执行期间定期发送（与进度无关，单个目录耗时很长时也会发送）
+	StateDone      = "done"      // 任务已完成，接着发送结果
+)
+
+// HeartbeatInterval 辅助程序发送心跳的间隔
+const HeartbeatInterval = 5 * time.Second
+
+// TaskStatus 辅助程序任务的生命周期消息
+type TaskStatus struct {
+	State    string `json:"state"`
+	PID      int    `json:"pid,omitempty"`
+	Elevated bool   `json:"elevated,omitempty"`
+}
@@ -0,0 +1,122 @@
+package ipc
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/catalog"
+	"ccooler/backend/fsys"
+	"crypto/hmac"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// TaskFileVersion 任务文件格式版本，主程序和辅助程序不一致时拒绝执行
+const TaskFileVersion = 1
+
+// TaskPath 要处理的路径及其所属的清理项
+type TaskPath struct {
+	Path string `json:"path"`
+	Item string `json:"item,omitempty"`
+}
+
+// TaskFile 辅助程序任务文件：主程序写入任务的全部参数，命令行只传文件位置，
+// 避免路径中的引号、命令行长度限制，也不会在进程列表中暴露路径
+type TaskFile struct {
+	Version       int                        `json:"version"`
+	TaskID        string                     `json:"taskId"`
+	Task          string                     `json:"task"`                    // 任务类型，如 clean-batch
+	Paths         []TaskPath                 `json:"paths,omitempty"`         // 要清理的路径
+	Filters       map[string]*catalog.Filter `json:"filters,omitempty"`       // 主程序使用的各清理项过滤条件
+	DryRun        bool                       `json:"dryRun,omitempty"`        // 只生成删除清单
+	Manifest      string                     `json:"manifest,omitempty"`      // clean-manifest 任务使用的删除清单
+	QuarantineRun string                     `json:"quarantineRun,omitempty"` // 隔离批次 ID，不为空时文件移入隔离区
+	Protected     string                     `json:"protected,omitempty"`     // 受保护路径文件
+	Settings      string                     `json:"settings,omitempty"`      // 用户设置文件（辅助程序使用其中的过滤条件）
+}
+
+// signedTaskFile 任务文件内容及其签名（使用任务密钥）
+type signedTaskFile struct {
+	Task      json.RawMessage `json:"task"`
+	Signature string          `json:"signature"`
+}
+
+// TaskDir 任务文件目录（当前用户的临时目录，辅助程序以同一用户运行）
+func TaskDir() string {
+	return filepath.Join(os.TempDir(), "CCooler", "tasks")
+}
+
+// WriteTaskFile 写入签名的任务文件，返回文件位置（任务结束后由调用方删除）
+func WriteTaskFile(filesystem fsys.FS, dir, key string, task *TaskFile) (string, error) {
+	task.Version = TaskFileVersion
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", err
+	}
+	signed, err := json.Marshal(signedTaskFile{
+		Task:      data,
+		Signature: Sign(key, task.TaskID, 0, "FILE", "", data),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := filesystem.MkdirAll(dir, 0700); err != nil {
+		return "", apperr.Wrap(apperr.SaveFailed, err, "file", dir)
+	}
+	file := filepath.Join(dir, task.TaskID+".json")
+	if err := filesystem.WriteFile(file, signed, 0600); err != nil {
+		return "", apperr.Wrap(apperr.SaveFailed, err, "file", file)
+	}
+	return file, nil
+}
+
+// ReadTaskFile 读取并校验任务文件（签名、任务 ID 和版本），读取后删除
+func ReadTaskFile(filesystem fsys.FS, file, taskID, key string) (*TaskFile, error) {
+	data, err := filesystem.ReadFile(file)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.ReadFailed, err, "file", file)
+	}
+	filesystem.Remove(file)
+
+	var signed signedTaskFile
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, apperr.Wrap(apperr.FileInvalid, err, "file", file)
+	}
+	expected := Sign(key, taskID, 0, "FILE", "", signed.Task)
+	if !hmac.Equal([]byte(expected), []byte(signed.Signature)) {
+		return nil, apperr.New(apperr.TaskFileSignature)
+	}
+
+	var task TaskFile
+	if err := json.Unmarshal(signed.Task, &task); err != nil {
+		return nil, apperr.Wrap(apperr.FileInvalid, err, "file", file)
+	}
+	if task.TaskID != taskID {
+		return nil, apperr.New(apperr.TaskFileSignature)
+	}
+	if task.Version != TaskFileVersion {
+		return nil, apperr.New(apperr.TaskVersion, "version", task.Version, "expected", TaskFileVersion)
+	}
+	return &task, nil
+}
+
+// CheckFilters 检查辅助程序的清理规则与主程序使用的过滤条件是否一致
+func (t *TaskFile) CheckFilters(cat *catalog.Catalog) error {
+	for itemID, filter := range t.Filters {
+		expected, _ := json.Marshal(filter)
+		actual, _ := json.Marshal(cat.PathFilter(itemID))
+		if string(expected) != string(actual) {
+			return apperr.New(apperr.RulesMismatch, "id", itemID)
+		}
+	}
+	return nil
+}
+
+// SplitPaths 返回路径列表和每个路径所属的清理项（一一对应）
+func (t *TaskFile) SplitPaths() (paths, items []string) {
+	for _, p := range t.Paths {
+		paths = append(paths, p.Path)
+		items = append(items, p.Item)
+	}
+	return paths, items
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // PathDetail 路径详细信息
 type PathDetail struct {
 	Path        string `json:"path"`
@@ -10,30 +12,37 @@ type PathDetail struct {
 
 // CleanItem 清理项
 type CleanItem struct {
-	ID        string       `json:"id"`
-	Name      string       `json:"name"`
-	Size      int64        `json:"size"`
-	FileCount int          `json:"fileCount"`
-	Checked   bool         `json:"checked"`
-	Safe      bool         `json:"safe"`
-	Status    string       `json:"status"`
-	Error     string       `json:"error,omitempty"`
-	Paths     []PathDetail `json:"paths,omitempty"`
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	Size       int64        `json:"size"`
+	FileCount  int          `json:"fileCount"`
+	Checked    bool         `json:"checked"`
+	Safe       bool         `json:"safe"`
+	NeedsAdmin bool         `json:"needsAdmin"`
+	Status     string       `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	Paths      []PathDetail `json:"paths,omitempty"`
+
+	// 受保护路径中的内容（不计入 Size、FileCount，清理时跳过）
+	ExcludedSize  int64 `json:"excludedSize,omitempty"`
+	ExcludedCount int   `json:"excludedCount,omitempty"`
 }
 
 // DiskInfo 磁盘信息
 type DiskInfo struct {
-	Total int64 `json:"total"`
-	Used  int64 `json:"used"`
-	Free  int64 `json:"free"`
+	Drive string `json:"drive"` // 分区根目录，如 "C:\\"
+	Total int64  `json:"total"`
+	Used  int64  `json:"used"`
+	Free  int64  `json:"free"`
 }
 
 // SoftwareInfo 软件信息
 type SoftwareInfo struct {
-	Name string `json:"name"`
-	Path string `json:"path"`
-	Size int64  `json:"size"`
-	Icon string `json:"icon"` // 图标路径或base64
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Icon   string `json:"icon"`             // 图标路径或base64
+	Status string `json:"status,omitempty"` // 统计被取消时为 "cancelled"，Size 只包含已统计部分
 }
 
 // WeChatData 微信数据
@@ -45,23 +54,29 @@ type WeChatData struct {
 	MediaSize   int64  `json:"mediaSize"`
 	OtherSize   int64  `json:"otherSize"`
 	Total       int64  `json:"total"`
+	Status      string `json:"status,omitempty"` // 扫描被取消时为 "cancelled"，大小只包含已扫描部分
 }
 
-// ScanProgress 扫描进度
+// ScanProgress 扫描进度（scan-progress 事件）
 type ScanProgress struct {
-	Current int    `json:"current"`
-	Total   int    `json:"total"`
-	Item    string `json:"item"`
-	Size    int64  `json:"size"`
+	Scan    string `json:"scan"`    // 扫描类型：clean、largefiles、software
+	Current int    `json:"current"` // 已完成的项目数
+	Total   int    `json:"total"`   // 项目总数（未知时为 0）
+	Item    string `json:"item"`    // 当前项目
+	Path    string `json:"path"`    // 当前目录
+	Size    int64  `json:"size"`    // 已统计的大小
+	Files   int    `json:"files"`   // 已统计的文件数
+	Done    bool   `json:"done"`    // 扫描结束（最后一个事件）
 }
 
-// CleanProgress 清理进度
+// CleanProgress 清理进度（clean-progress 事件，应用内清理和辅助程序清理相同）
 type CleanProgress struct {
-	Current   int    `json:"current"`
-	Total     int    `json:"total"`
-	Item      string `json:"item"`
-	Cleaned   int64  `json:"cleaned"`
-	TotalSize int64  `json:"totalSize"`
+	Item           string `json:"item,omitempty"` // 当前清理项 ID
+	ProcessedPaths int    `json:"processedPaths"` // 已完成的路径数
+	TotalPaths     int    `json:"totalPaths"`     // 路径总数
+	CleanedSize    int64  `json:"cleanedSize"`    // 实际释放的大小
+	CleanedCount   int    `json:"cleanedCount"`   // 实际删除的文件数
+	CurrentPath    string `json:"currentPath"`    // 当前路径
 }
 
 // DesktopFileInfo 桌面文件信息
@@ -73,3 +88,89 @@ type DesktopFileInfo struct {
 	Size         int64  `json:"size"`
 	ModifiedTime string `json:"modifiedTime"`
 }
+
+// CleanOptions 清理选项
+type CleanOptions struct {
+	DryRun     bool   `json:"dryRun"`          // 只生成删除清单，不删除任何文件
+	Quarantine bool   `json:"quarantine"`      // 移入隔离区而不是直接删除
+	RunID      string `json:"runId,omitempty"` // 隔离批次 ID，同一次清理的多个调用共用
+	OpID       string `json:"opId,omitempty"`  // 操作 ID，可用 CancelOperation 取消
+}
+
+// ManifestEntry 删除清单条目
+type ManifestEntry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	ItemID  string    `json:"itemId"`
+	Root    string    `json:"root"`   // 所属清理路径
+	Reason  string    `json:"reason"` // 删除原因（清理项的处理方式）
+}
+
+// CleanManifest 删除清单（试运行结果，可导出后按清单重放删除）
+type CleanManifest struct {
+	Version    int             `json:"version"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	Entries    []ManifestEntry `json:"entries"`
+	TotalSize  int64           `json:"totalSize"`
+	TotalFiles int             `json:"totalFiles"`
+}
+
+// ProtectedRules 用户设置的受保护路径，匹配的文件和目录（包括其下所有内容）不会被清理或删除
+type ProtectedRules struct {
+	Paths      []string `json:"paths"`      // 目录或文件，支持 %LOCALAPPDATA% 等环境变量
+	Globs      []string `json:"globs"`      // 通配符，匹配文件名或目录名；含路径分隔符时匹配完整路径
+	Extensions []string `json:"extensions"` // 扩展名，如 ".psd"
+}
+
+// QuarantineRun 隔离区中的一次清理批次
+type QuarantineRun struct {
+	RunID     string    `json:"runId"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Size      int64     `json:"size"`
+	FileCount int       `json:"fileCount"`
+}
+
+// SkipReason 文件未能清理的原因
+type SkipReason string
+
+const (
+	SkipInUse        SkipReason = "in-use"        // 文件正在使用
+	SkipAccessDenied SkipReason = "access-denied" // 权限不足
+	SkipProtected    SkipReason = "protected"     // 只读或受保护的文件
+	SkipVanished     SkipReason = "vanished"      // 清理前文件已不存在
+	SkipChanged      SkipReason = "changed"       // 按清单清理时文件已变化
+	SkipFailed       SkipReason = "failed"        // 其他错误
+	SkipExcluded     SkipReason = "excluded"      // 用户设置的受保护路径
+	SkipQuarantine   SkipReason = "quarantine"    // 隔离区容量不足，未移入隔离区
+)
+
+// SkippedFile 未能清理的文件
+type SkippedFile struct {
+	Path   string     `json:"path"`
+	Size   int64      `json:"size"`
+	Reason SkipReason `json:"reason"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// PathReport 单个清理路径的结果
+type PathReport struct {
+	Path         string `json:"path"`
+	ItemID       string `json:"itemId,omitempty"`
+	FreedSize    int64  `json:"freedSize"`
+	RemovedCount int    `json:"removedCount"`
+	SkippedSize  int64  `json:"skippedSize"`
+	SkippedCount int    `json:"skippedCount"`
+}
+
+// CleanReport 清理报告（只统计实际删除成功的文件）
+type CleanReport struct {
+	FreedSize      int64              `json:"freedSize"`
+	RemovedCount   int                `json:"removedCount"`
+	SkippedSize    int64              `json:"skippedSize"`
+	SkippedCount   int                `json:"skippedCount"`
+	SkippedReasons map[SkipReason]int `json:"skippedReasons"`
+	Skipped        []SkippedFile      `json:"skipped"` // 最多保留 MaxSkippedFiles 条明细
+	Paths          []PathReport       `json:"paths"`
+}
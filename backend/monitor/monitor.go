@@ -0,0 +1,332 @@
+// Package monitor 剩余空间监控：按固定间隔采样系统盘剩余空间，剩余空间跨过用户设置的阈值
+// 或在短时间内下降过快时产生事件。最近的采样和事件保存在内存中，程序最小化期间发生的情况
+// 可在恢复窗口后查看。监控设置保存在用户配置目录。
+package monitor
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
+	"ccooler/backend/models"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 事件类型（Event.Type）
+const (
+	EventBelow     = "below"      // 剩余空间降到阈值以下
+	EventRecovered = "recovered"  // 剩余空间回到阈值以上
+	EventRapidDrop = "rapid-drop" // 剩余空间下降过快
+)
+
+const (
+	SampleRetention = 24 * time.Hour // 保留最近 24 小时的采样
+	maxEvents       = 200            // 最多保留的事件
+	minInterval     = 10             // 最短采样间隔（秒）
+)
+
+// Config 监控设置
+type Config struct {
+	Enabled         bool    `json:"enabled"`
+	IntervalSeconds int     `json:"intervalSeconds"` // 采样间隔
+	Thresholds      []int64 `json:"thresholds"`      // 剩余空间阈值（字节）
+	DropBytes       int64   `json:"dropBytes"`       // 在 DropMinutes 内下降超过该值时产生事件，0 表示不检查
+	DropMinutes     int     `json:"dropMinutes"`
+}
+
+// DefaultConfig 默认设置：每分钟采样，剩余 10 GB 和 5 GB 时提醒，一小时内下降 5 GB 时提醒
+func DefaultConfig() Config {
+	return Config{
+		Enabled:         true,
+		IntervalSeconds: 60,
+		Thresholds:      []int64{10 << 30, 5 << 30},
+		DropBytes:       5 << 30,
+		DropMinutes:     60,
+	}
+}
+
+// Sample 一次采样
+type Sample struct {
+	Time  time.Time `json:"time"`
+	Total int64     `json:"total"`
+	Free  int64     `json:"free"`
+}
+
+// Event 监控事件
+type Event struct {
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	Free      int64     `json:"free"`                // 产生事件时的剩余空间
+	Threshold int64     `json:"threshold,omitempty"` // below / recovered：跨过的阈值
+	Drop      int64     `json:"drop,omitempty"`      // rapid-drop：下降的大小
+	Since     time.Time `json:"since,omitempty"`     // rapid-drop：从何时开始下降
+}
+
+// Monitor 剩余空间监控（并发安全）
+type Monitor struct {
+	fs       fsys.FS
+	path     string
+	disk     func() (*models.DiskInfo, error)
+	onEvent  func(Event)
+	onSample func(Sample)
+
+	mu        sync.Mutex
+	config    Config
+	samples   []Sample
+	events    []Event
+	lastDrop  time.Time     // 上次 rapid-drop 事件的时间
+	reconfig  chan struct{} // 修改采样间隔后通知 Start
+	lastError error
+}
+
+// DefaultPath 默认的设置文件位置（用户配置目录，无法获取时使用程序目录）
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		exePath, _ := os.Executable()
+		dir = filepath.Dir(exePath)
+	}
+	return filepath.Join(dir, "CCooler", "monitor.json")
+}
+
+// Open 读取设置文件，文件不存在时使用默认设置；文件无效时使用默认设置并返回错误。disk 返回当前磁盘信息
+func Open(filesystem fsys.FS, file string, disk func() (*models.DiskInfo, error)) (*Monitor, error) {
+	m := &Monitor{
+		fs:       filesystem,
+		path:     file,
+		disk:     disk,
+		config:   DefaultConfig(),
+		reconfig: make(chan struct{}, 1),
+	}
+
+	content, err := filesystem.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return m, err
+	}
+	var config Config
+	if err := json.Unmarshal(content, &config); err != nil {
+		return m, apperr.Wrap(apperr.FileInvalid, err, "file", file)
+	}
+	if err := normalize(&config); err != nil {
+		return m, err
+	}
+	m.config = config
+	return m, nil
+}
+
+// SetOnEvent 设置产生事件时的回调
+func (m *Monitor) SetOnEvent(onEvent func(Event)) {
+	m.onEvent = onEvent
+}
+
+// SetOnSample 设置每次采样成功后的回调
+func (m *Monitor) SetOnSample(onSample func(Sample)) {
+	m.onSample = onSample
+}
+
+// Config 返回当前设置
+func (m *Monitor) Config() Config {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	config := m.config
+	config.Thresholds = append([]int64{}, m.config.Thresholds...)
+	return config
+}
+
+// SetConfig 校验并保存设置，立即生效
+func (m *Monitor) SetConfig(config Config) error {
+	if err := normalize(&config); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := m.fs.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return apperr.Wrap(apperr.SaveFailed, err, "file", m.path)
+	}
+	if err := m.fs.WriteFile(m.path, content, 0644); err != nil {
+		return apperr.Wrap(apperr.SaveFailed, err, "file", m.path)
+	}
+
+	m.mu.Lock()
+	m.config = config
+	m.mu.Unlock()
+	select {
+	case m.reconfig <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// normalize 校验设置，阈值去重并从大到小排列
+func normalize(config *Config) error {
+	if config.IntervalSeconds < minInterval {
+		return apperr.New(apperr.IntervalTooShort, "seconds", minInterval)
+	}
+	seen := make(map[int64]bool)
+	thresholds := []int64{}
+	for _, threshold := range config.Thresholds {
+		if threshold <= 0 {
+			return apperr.New(apperr.ThresholdNotPositive)
+		}
+		if !seen[threshold] {
+			seen[threshold] = true
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	sort.Slice(thresholds, func(i, j int) bool { return thresholds[i] > thresholds[j] })
+	config.Thresholds = thresholds
+
+	if config.DropBytes < 0 {
+		return apperr.New(apperr.NegativeDrop)
+	}
+	if config.DropBytes > 0 && config.DropMinutes*60 < config.IntervalSeconds {
+		return apperr.New(apperr.DropWindowTooShort)
+	}
+	return nil
+}
+
+// Start 按采样间隔采样，直到 ctx 取消
+func (m *Monitor) Start(ctx context.Context) {
+	for {
+		config := m.Config()
+		if config.Enabled {
+			m.Sample(time.Now())
+		}
+		timer := time.NewTimer(time.Duration(config.IntervalSeconds) * time.Second)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-m.reconfig:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Sample 采样一次并检查阈值和下降速度，返回产生的事件
+func (m *Monitor) Sample(now time.Time) []Event {
+	info, err := m.disk()
+	m.mu.Lock()
+	if err != nil {
+		m.lastError = err
+		m.mu.Unlock()
+		return nil
+	}
+	m.lastError = nil
+	sample := Sample{Time: now, Total: info.Total, Free: info.Free}
+	events := m.check(sample)
+	m.samples = append(m.samples, sample)
+	m.prune(now)
+	m.events = append(m.events, events...)
+	if len(m.events) > maxEvents {
+		m.events = append([]Event{}, m.events[len(m.events)-maxEvents:]...)
+	}
+	m.mu.Unlock()
+
+	if m.onSample != nil {
+		m.onSample(sample)
+	}
+	if m.onEvent != nil {
+		for _, event := range events {
+			m.onEvent(event)
+		}
+	}
+	return events
+}
+
+// check 与上一次采样比较（调用方持有 mu）
+func (m *Monitor) check(sample Sample) []Event {
+	var events []Event
+	if len(m.samples) == 0 {
+		// 第一次采样时已低于阈值：只提醒最接近的一个阈值
+		for i := len(m.config.Thresholds) - 1; i >= 0; i-- {
+			if threshold := m.config.Thresholds[i]; sample.Free < threshold {
+				events = append(events, Event{Type: EventBelow, Time: sample.Time, Free: sample.Free, Threshold: threshold})
+				break
+			}
+		}
+	} else {
+		previous := m.samples[len(m.samples)-1]
+		for _, threshold := range m.config.Thresholds {
+			switch {
+			case previous.Free >= threshold && sample.Free < threshold:
+				events = append(events, Event{Type: EventBelow, Time: sample.Time, Free: sample.Free, Threshold: threshold})
+			case previous.Free < threshold && sample.Free >= threshold:
+				events = append(events, Event{Type: EventRecovered, Time: sample.Time, Free: sample.Free, Threshold: threshold})
+			}
+		}
+	}
+
+	// 时间范围内剩余空间最多的采样与当前比较，同一时间范围内只提醒一次
+	if m.config.DropBytes > 0 {
+		window := time.Duration(m.config.DropMinutes) * time.Minute
+		if sample.Time.Sub(m.lastDrop) >= window {
+			var peak *Sample
+			for i := len(m.samples) - 1; i >= 0 && sample.Time.Sub(m.samples[i].Time) <= window; i-- {
+				if peak == nil || m.samples[i].Free > peak.Free {
+					peak = &m.samples[i]
+				}
+			}
+			if peak != nil && peak.Free-sample.Free >= m.config.DropBytes {
+				m.lastDrop = sample.Time
+				events = append(events, Event{Type: EventRapidDrop, Time: sample.Time, Free: sample.Free, Drop: peak.Free - sample.Free, Since: peak.Time})
+			}
+		}
+	}
+	return events
+}
+
+// prune 删除超过保留时间的采样（调用方持有 mu）
+func (m *Monitor) prune(now time.Time) {
+	cutoff := now.Add(-SampleRetention)
+	i := 0
+	for i < len(m.samples) && m.samples[i].Time.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		m.samples = append([]Sample{}, m.samples[i:]...)
+	}
+}
+
+// Samples 返回 since 之后的采样（since 为零值时返回全部）
+func (m *Monitor) Samples(since time.Time) []Sample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	samples := []Sample{}
+	for _, sample := range m.samples {
+		if sample.Time.After(since) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
+
+// Events 返回 since 之后的事件（since 为零值时返回全部）
+func (m *Monitor) Events(since time.Time) []Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	events := []Event{}
+	for _, event := range m.events {
+		if event.Time.After(since) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// LastError 最近一次采样失败的错误（成功后清除）
+func (m *Monitor) LastError() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastError
+}
@@ -0,0 +1,86 @@
+// Package ops 跟踪正在执行的长时间操作（扫描、清理），前端可按操作 ID 取消。
+// 操作通过 context 取消，服务在遍历时检查 context 并返回已完成的部分结果；
+// 调用方须在 done 之前用 Cancelled 判断操作是否被取消。
+package ops
+
+import (
+	"ccooler/backend/apperr"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// StatusCancelled 被取消的操作和清理项使用的状态
+const StatusCancelled = "cancelled"
+
+// ErrUnknown 操作不存在或已经结束
+var ErrUnknown = apperr.New(apperr.UnknownOperation)
+
+// Registry 正在执行的操作
+type Registry struct {
+	mu  sync.Mutex
+	ops map[string]*operation
+}
+
+type operation struct {
+	cancel context.CancelFunc
+}
+
+// NewRegistry 创建操作表
+func NewRegistry() *Registry {
+	return &Registry{ops: make(map[string]*operation)}
+}
+
+// NewID 生成操作 ID
+func NewID() string {
+	var b [3]byte
+	rand.Read(b[:])
+	return "op-" + time.Now().Format("150405") + "-" + hex.EncodeToString(b[:])
+}
+
+// Begin 开始一个操作，返回的 context 在 Cancel(opID) 或 done 调用后取消。
+// opID 为空时操作不可取消（仍返回可用的 context）；done 必须调用
+func (r *Registry) Begin(parent context.Context, opID string) (context.Context, func()) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	if opID == "" {
+		return ctx, cancel
+	}
+
+	op := &operation{cancel: cancel}
+	r.mu.Lock()
+	r.ops[opID] = op
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		if r.ops[opID] == op {
+			delete(r.ops, opID)
+		}
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// Cancel 取消操作
+func (r *Registry) Cancel(opID string) error {
+	r.mu.Lock()
+	op, ok := r.ops[opID]
+	r.mu.Unlock()
+
+	if !ok {
+		return ErrUnknown
+	}
+	op.cancel()
+	return nil
+}
+
+// Cancelled 判断操作是否被取消（而不是正常结束）
+func Cancelled(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
@@ -0,0 +1,64 @@
+// Package progress 把服务中的扫描、清理进度发送给前端：服务只依赖 Sink，
+// App 在启动后用 runtime.EventsEmit 转发；Throttle 限制发送频率，避免大量小事件阻塞界面。
+package progress
+
+import (
+	"sync"
+	"time"
+)
+
+// 事件名
+const (
+	EventScan  = "scan-progress"
+	EventClean = "clean-progress"
+)
+
+// DefaultInterval 两次进度事件的最小间隔
+const DefaultInterval = 150 * time.Millisecond
+
+// Sink 接收进度事件
+type Sink func(event string, data any)
+
+// Throttle 限频发送同一事件（并发安全）。sink 为空时不发送，nil 也可以直接使用
+type Throttle struct {
+	sink     Sink
+	event    string
+	interval time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewThrottle 创建限频发送器，sink 为空时返回 nil
+func NewThrottle(sink Sink, event string) *Throttle {
+	if sink == nil {
+		return nil
+	}
+	return &Throttle{sink: sink, event: event, interval: DefaultInterval}
+}
+
+// Emit 距上次发送超过间隔时发送，data 只在需要发送时调用
+func (t *Throttle) Emit(data func() any) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if now.Sub(t.last) < t.interval {
+		return
+	}
+	t.last = now
+	t.sink(t.event, data())
+}
+
+// Flush 立即发送（用于开始和结束时的进度）
+func (t *Throttle) Flush(data any) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = time.Now()
+	t.sink(t.event, data)
+}
@@ -0,0 +1,235 @@
+// Package protect 用户设置的受保护路径：匹配的文件和目录（包括其下所有内容）
+// 在扫描时单独统计，清理、删除大文件和桌面文件时跳过。规则保存在用户配置目录，
+// 辅助程序读取同一个文件再次检查。
+package protect
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
+	"ccooler/backend/models"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrProtected 删除受保护的路径，用于 errors.Is 判断（返回的错误带 path 参数）
+var ErrProtected = apperr.New(apperr.Protected)
+
+// List 受保护路径列表（并发安全；nil 表示没有受保护路径）
+type List struct {
+	fs   fsys.FS
+	path string
+
+	mu    sync.RWMutex
+	rules models.ProtectedRules
+	paths []string        // 规范化后的路径
+	globs []string        // 规范化后的通配符
+	exts  map[string]bool // 小写扩展名
+}
+
+// DefaultPath 默认的规则文件位置（用户配置目录，无法获取时使用程序目录）
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		exePath, _ := os.Executable()
+		dir = filepath.Dir(exePath)
+	}
+	return filepath.Join(dir, "CCooler", "protected.json")
+}
+
+// Load 读取规则文件，文件不存在时返回空列表；文件无效时返回空列表和错误
+func Load(filesystem fsys.FS, file string) (*List, error) {
+	l := &List{fs: filesystem, path: file}
+	l.compile(models.ProtectedRules{})
+
+	data, err := filesystem.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return l, apperr.Wrap(apperr.ReadFailed, err, "file", file)
+	}
+
+	var rules models.ProtectedRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return l, apperr.Wrap(apperr.FileInvalid, err, "file", file)
+	}
+	rules, err = normalizeRules(rules)
+	if err != nil {
+		return l, err
+	}
+	l.compile(rules)
+	return l, nil
+}
+
+// Path 规则文件位置
+func (l *List) Path() string {
+	return l.path
+}
+
+// Rules 返回当前规则
+func (l *List) Rules() models.ProtectedRules {
+	if l == nil {
+		return models.ProtectedRules{}
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return models.ProtectedRules{
+		Paths:      append([]string{}, l.rules.Paths...),
+		Globs:      append([]string{}, l.rules.Globs...),
+		Extensions: append([]string{}, l.rules.Extensions...),
+	}
+}
+
+// Set 校验并保存规则（先写临时文件再替换）
+func (l *List) Set(rules models.ProtectedRules) error {
+	rules, err := normalizeRules(rules)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := l.fs.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return apperr.Wrap(apperr.SaveFailed, err, "file", l.path)
+	}
+	if err := fsys.WriteFileAtomic(l.fs, l.path, data, 0644); err != nil {
+		return apperr.Wrap(apperr.SaveFailed, err, "file", l.path)
+	}
+	l.compile(rules)
+	return nil
+}
+
+// Empty 是否没有任何规则
+func (l *List) Empty() bool {
+	if l == nil {
+		return true
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.paths) == 0 && len(l.globs) == 0 && len(l.exts) == 0
+}
+
+// Protected 判断路径是否受保护（路径本身或任一上级目录匹配规则）
+func (l *List) Protected(p string) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if len(l.paths) == 0 && len(l.globs) == 0 && len(l.exts) == 0 {
+		return false
+	}
+
+	p = normalize(p)
+	for _, protected := range l.paths {
+		if p == protected || strings.HasPrefix(p, strings.TrimSuffix(protected, "/")+"/") {
+			return true
+		}
+	}
+	if l.exts[path.Ext(p)] {
+		return true
+	}
+	if len(l.globs) == 0 {
+		return false
+	}
+
+	// 逐级检查路径本身和各级上级目录
+	for dir := p; dir != "" && dir != "."; {
+		base := path.Base(dir)
+		for _, glob := range l.globs {
+			name := base
+			if strings.Contains(glob, "/") {
+				name = dir
+			}
+			if ok, _ := path.Match(glob, name); ok {
+				return true
+			}
+		}
+		parent := path.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return false
+}
+
+// compile 替换规则并生成匹配用的数据
+func (l *List) compile(rules models.ProtectedRules) {
+	var paths, globs []string
+	for _, p := range rules.Paths {
+		paths = append(paths, normalize(p))
+	}
+	for _, glob := range rules.Globs {
+		globs = append(globs, normalize(glob))
+	}
+	exts := make(map[string]bool, len(rules.Extensions))
+	for _, ext := range rules.Extensions {
+		exts[ext] = true
+	}
+
+	l.mu.Lock()
+	l.rules = rules
+	l.paths = paths
+	l.globs = globs
+	l.exts = exts
+	l.mu.Unlock()
+}
+
+// Validate 校验规则（与 Set 相同的检查，不保存）
+func Validate(rules models.ProtectedRules) error {
+	_, err := normalizeRules(rules)
+	return err
+}
+
+// normalizeRules 去掉空白项，扩展名统一为小写并带点，校验通配符
+func normalizeRules(rules models.ProtectedRules) (models.ProtectedRules, error) {
+	var result models.ProtectedRules
+	for _, p := range rules.Paths {
+		if p = strings.TrimSpace(p); p != "" {
+			result.Paths = append(result.Paths, p)
+		}
+	}
+	for _, glob := range rules.Globs {
+		if glob = strings.TrimSpace(glob); glob == "" {
+			continue
+		}
+		if _, err := path.Match(normalize(glob), ""); err != nil {
+			return result, apperr.New(apperr.InvalidGlob, "pattern", glob)
+		}
+		result.Globs = append(result.Globs, glob)
+	}
+	for _, ext := range rules.Extensions {
+		if ext = strings.ToLower(strings.TrimSpace(ext)); ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		result.Extensions = append(result.Extensions, ext)
+	}
+	return result, nil
+}
+
+var envVar = regexp.MustCompile(`%([^%]+)%`)
+
+// normalize 展开 %VAR% 环境变量，统一使用小写和 / 分隔符（Windows 路径不区分大小写）
+func normalize(p string) string {
+	p = envVar.ReplaceAllStringFunc(p, func(s string) string {
+		if v, ok := os.LookupEnv(s[1 : len(s)-1]); ok {
+			return v
+		}
+		return s
+	})
+	p = strings.ToLower(strings.ReplaceAll(p, `\`, "/"))
+	if len(p) > 1 && !strings.HasSuffix(p, ":/") {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package quarantine
+
+import "ccooler/backend/fsys"
+
+// createAdminDir 非 Windows 系统不设置权限（用于开发调试）
+func createAdminDir(filesystem fsys.FS, dir string) error {
+	return filesystem.MkdirAll(dir, 0755)
+}
+
+// checkAdminDir 非 Windows 系统不检查权限
+func checkAdminDir(filesystem fsys.FS, dir string) error {
+	return nil
+}
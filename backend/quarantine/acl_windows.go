@@ -0,0 +1,93 @@
+package quarantine
+
+import (
+	"ccooler/backend/fsys"
+	"errors"
+	"io/fs"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// adminSDDL 管理员隔离区的权限：所有者为 Administrators，SYSTEM 和 Administrators 完全控制，
+// 其他已登录用户只能读取（列出批次和大小），不继承分区根目录的权限
+const adminSDDL = "O:BAD:P(A;OICI;FA;;;SY)(A;OICI;FA;;;BA)(A;OICI;FRFX;;;AU)"
+
+// fileDeleteChild FILE_DELETE_CHILD，删除目录中的文件
+const fileDeleteChild = 0x40
+
+// writeRights 修改目录内容、属性或权限所需的权限
+const writeRights = windows.FILE_WRITE_DATA | windows.FILE_APPEND_DATA | windows.FILE_WRITE_EA |
+	windows.FILE_WRITE_ATTRIBUTES | fileDeleteChild | windows.DELETE | windows.WRITE_DAC | windows.WRITE_OWNER |
+	windows.GENERIC_WRITE | windows.GENERIC_ALL
+
+// createAdminDir 创建管理员隔离区目录（只有管理员可以写入），已存在时检查其权限
+func createAdminDir(filesystem fsys.FS, dir string) error {
+	if _, err := filesystem.Lstat(dir); err != nil {
+		sd, err := windows.SecurityDescriptorFromString(adminSDDL)
+		if err != nil {
+			return err
+		}
+		path, err := windows.UTF16PtrFromString(dir)
+		if err != nil {
+			return err
+		}
+		sa := &windows.SecurityAttributes{Length: uint32(unsafe.Sizeof(windows.SecurityAttributes{})), SecurityDescriptor: sd}
+		if err := windows.CreateDirectory(path, sa); err != nil && !errors.Is(err, windows.ERROR_ALREADY_EXISTS) {
+			return &fs.PathError{Op: "mkdir", Path: dir, Err: err}
+		}
+	}
+	return checkAdminDir(filesystem, dir)
+}
+
+// checkAdminDir 检查管理员隔离区目录：不是链接，所有者为 Administrators 或 SYSTEM，
+// 只有 Administrators 和 SYSTEM 可以修改（普通用户预先创建或修改过的目录不通过）
+func checkAdminDir(filesystem fsys.FS, dir string) error {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return err
+	}
+	attrs, err := windows.GetFileAttributes(path)
+	if err != nil {
+		return &fs.PathError{Op: "stat", Path: dir, Err: err}
+	}
+	if attrs&windows.FILE_ATTRIBUTE_REPARSE_POINT != 0 {
+		return ErrUnsafeDir
+	}
+
+	sd, err := windows.GetNamedSecurityInfo(dir, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION|windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return &fs.PathError{Op: "getsecurity", Path: dir, Err: err}
+	}
+	owner, _, err := sd.Owner()
+	if err != nil || owner == nil || !trustedSID(owner) {
+		return ErrUnsafeDir
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil || dacl == nil { // 没有 DACL 时所有人完全控制
+		return ErrUnsafeDir
+	}
+	for i := uint32(0); i < uint32(dacl.AceCount); i++ {
+		var ace *windows.ACCESS_ALLOWED_ACE
+		if err := windows.GetAce(dacl, i, &ace); err != nil {
+			return ErrUnsafeDir
+		}
+		switch ace.Header.AceType {
+		case windows.ACCESS_DENIED_ACE_TYPE:
+			continue
+		case windows.ACCESS_ALLOWED_ACE_TYPE:
+		default:
+			return ErrUnsafeDir // 对象或条件 ACE，无法判断授予的权限
+		}
+		sid := (*windows.SID)(unsafe.Pointer(&ace.SidStart))
+		if uint32(ace.Mask)&writeRights != 0 && !trustedSID(sid) {
+			return ErrUnsafeDir
+		}
+	}
+	return nil
+}
+
+// trustedSID 判断是否为 Administrators 或 SYSTEM
+func trustedSID(sid *windows.SID) bool {
+	return sid.IsWellKnown(windows.WinBuiltinAdministratorsSid) || sid.IsWellKnown(windows.WinLocalSystemSid)
+}
@@ -0,0 +1,577 @@
+// Package quarantine 隔离区：清理时把文件移动到同一分区的暂存目录并记录原路径，
+// 可按清理批次恢复；超过保留期或容量上限的批次会被永久删除。
+// 普通权限的进程使用分区根目录下的 CCooler.Quarantine（任何用户都可以写入）；以管理员身份运行的进程
+// 使用只有管理员可以写入的 CCooler.Quarantine.Admin，并且只从该目录恢复，避免按伪造的日志以管理员权限移动文件。
+package quarantine
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
+	"ccooler/backend/models"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 隔离区目录名（位于各分区根目录下）
+const (
+	DirName      = "CCooler.Quarantine"       // 普通权限的进程使用
+	AdminDirName = "CCooler.Quarantine.Admin" // 以管理员身份运行的进程使用，只有管理员可以写入
+)
+
+const (
+	DefaultRetention       = 7 * 24 * time.Hour // 默认保留 7 天
+	DefaultMaxSize   int64 = 10 << 30           // 默认最多占用 10 GB
+)
+
+// segmentSize 每个日志文件最多记录的文件数。移动每个文件之前先写入当前日志文件，异常退出时不会丢失原路径；
+// 日志分段保存，每次写入的内容不随批次增大
+const segmentSize = 100
+
+// ErrNoVolume 路径不在带盘符的分区上，无法隔离
+var ErrNoVolume = apperr.New(apperr.QuarantineNoVolume)
+
+// ErrFull 删除最早的批次后隔离区仍放不下该文件（文件不移动也不删除）
+var ErrFull = apperr.New(apperr.QuarantineFull)
+
+// ErrUnsafeDir 管理员隔离区目录可以被普通用户修改（可能是伪造的），不使用也不从中恢复
+var ErrUnsafeDir = apperr.New(apperr.QuarantineUnsafe)
+
+// Entry 隔离日志条目
+type Entry struct {
+	Original string    `json:"original"` // 原始路径
+	Stored   string    `json:"stored"`   // 隔离区内的文件名（相对批次目录）
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"modTime"`
+}
+
+// Journal 单个进程在某个分区上的隔离日志
+type Journal struct {
+	RunID     string    `json:"runId"`
+	CreatedAt time.Time `json:"createdAt"`
+	Entries   []Entry   `json:"entries"`
+	Next      int       `json:"next"` // 下一个隔离文件的编号（部分恢复后条目数会减少，不能用作编号）
+}
+
+// Store 隔离区
+type Store struct {
+	fs    fsys.FS
+	admin bool // 以管理员身份运行：写入管理员隔离区，只从管理员隔离区恢复
+
+	Retention time.Duration // 保留期，超过后永久删除
+	MaxSize   int64         // 所有批次合计的容量上限
+}
+
+// NewStore 创建普通权限的进程使用的隔离区（默认保留期和容量上限）
+func NewStore(filesystem fsys.FS) *Store {
+	return &Store{
+		fs:        filesystem,
+		Retention: DefaultRetention,
+		MaxSize:   DefaultMaxSize,
+	}
+}
+
+// NewAdminStore 创建以管理员身份运行的进程使用的隔离区
+func NewAdminStore(filesystem fsys.FS) *Store {
+	s := NewStore(filesystem)
+	s.admin = true
+	return s
+}
+
+// NewRunID 生成清理批次 ID
+func NewRunID() string {
+	var b [3]byte
+	rand.Read(b[:])
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b[:])
+}
+
+// ValidRunID 检查批次 ID 是否可以安全地用作目录名
+func ValidRunID(runID string) bool {
+	if runID == "" || len(runID) > 64 {
+		return false
+	}
+	for _, c := range runID {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// volumeRoot 返回路径所在分区的根目录（如 C:\）
+func volumeRoot(path string) (string, bool) {
+	if len(path) < 3 || path[1] != ':' || (path[2] != '\\' && path[2] != '/') {
+		return "", false
+	}
+	c := path[0]
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	if c < 'A' || c > 'Z' {
+		return "", false
+	}
+	return string(c) + `:\`, true
+}
+
+// base 分区上的隔离区目录
+type base struct {
+	root  string // 分区根目录，如 C:\
+	dir   string // 隔离区目录
+	admin bool   // 管理员隔离区
+}
+
+// dirName 隔离区目录名
+func dirName(admin bool) string {
+	if admin {
+		return AdminDirName
+	}
+	return DirName
+}
+
+// bases 返回存在的隔离区目录；writable 为 true 时只包括本进程可以修改的目录（普通权限的进程不包括管理员隔离区）
+func (s *Store) bases(writable bool) []base {
+	var bases []base
+	for c := 'A'; c <= 'Z'; c++ {
+		root := string(c) + `:\`
+		for _, admin := range []bool{false, true} {
+			if admin && writable && !s.admin {
+				continue
+			}
+			dir := filepath.Join(root, dirName(admin))
+			if info, err := s.fs.Stat(dir); err == nil && info.IsDir() {
+				bases = append(bases, base{root: root, dir: dir, admin: admin})
+			}
+		}
+	}
+	return bases
+}
+
+// runJournals 读取批次在某个分区上的全部日志（文件名 -> 日志）
+func (s *Store) runJournals(dir string) map[string]*Journal {
+	journals := make(map[string]*Journal)
+	entries, err := s.fs.ReadDir(dir)
+	if err != nil {
+		return journals
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), "journal-") || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := s.fs.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var j Journal
+		if err := json.Unmarshal(data, &j); err != nil {
+			continue
+		}
+		journals[e.Name()] = &j
+	}
+	return journals
+}
+
+// Runs 列出隔离区中的所有批次（按时间倒序）
+func (s *Store) Runs() []models.QuarantineRun {
+	return s.runs(false)
+}
+
+// runs 列出批次；writable 为 true 时只统计本进程可以修改的部分
+func (s *Store) runs(writable bool) []models.QuarantineRun {
+	byID := make(map[string]*models.QuarantineRun)
+	for _, b := range s.bases(writable) {
+		dirs, err := s.fs.ReadDir(b.dir)
+		if err != nil {
+			continue
+		}
+		for _, d := range dirs {
+			if !d.IsDir() {
+				continue
+			}
+			run, ok := byID[d.Name()]
+			if !ok {
+				run = &models.QuarantineRun{RunID: d.Name()}
+				byID[d.Name()] = run
+			}
+			journals := s.runJournals(filepath.Join(b.dir, d.Name()))
+			if len(journals) == 0 && run.CreatedAt.IsZero() {
+				// 没有日志（写入前异常退出）时按目录时间计算保留期
+				if info, err := d.Info(); err == nil {
+					run.CreatedAt = info.ModTime()
+				}
+			}
+			for _, j := range journals {
+				if run.CreatedAt.IsZero() || j.CreatedAt.Before(run.CreatedAt) {
+					run.CreatedAt = j.CreatedAt
+				}
+				for _, e := range j.Entries {
+					run.Size += e.Size
+					run.FileCount++
+				}
+			}
+		}
+	}
+
+	runs := make([]models.QuarantineRun, 0, len(byID))
+	for _, run := range byID {
+		if !run.CreatedAt.IsZero() {
+			run.ExpiresAt = run.CreatedAt.Add(s.Retention)
+		}
+		runs = append(runs, *run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+	return runs
+}
+
+// Purge 永久删除超过保留期的批次（普通权限的进程不删除管理员隔离区中的部分）
+func (s *Store) Purge() int {
+	deadline := time.Now().Add(-s.Retention)
+	purged := 0
+	for _, run := range s.runs(true) {
+		if run.CreatedAt.Before(deadline) {
+			s.Delete(run.RunID)
+			purged++
+		}
+	}
+	return purged
+}
+
+// Delete 永久删除批次（普通权限的进程只删除普通隔离区中的部分，见 NeedsAdmin）
+func (s *Store) Delete(runID string) error {
+	if !ValidRunID(runID) {
+		return apperr.New(apperr.InvalidRun, "id", runID)
+	}
+	var lastErr error
+	for _, b := range s.bases(true) {
+		if err := s.fs.RemoveAll(filepath.Join(b.dir, runID)); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// NeedsAdmin 批次在管理员隔离区中有本进程不能删除的部分（需要交给辅助程序）
+func (s *Store) NeedsAdmin(runID string) bool {
+	if s.admin || !ValidRunID(runID) {
+		return false
+	}
+	for _, b := range s.bases(false) {
+		if b.admin {
+			if _, err := s.fs.Stat(filepath.Join(b.dir, runID)); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RestoreResult 恢复结果
+type RestoreResult struct {
+	Size      int64 // 已恢复的大小
+	Count     int   // 已恢复的文件数
+	Conflicts int   // 原位置已有同名文件而未恢复的文件数
+	Failed    int   // 移动失败（通常是权限不足）或日志中路径无效的文件数
+	Elevated  int   // 管理员隔离区中的文件数（普通权限的进程不恢复，需要交给辅助程序）
+	Untrusted int   // 普通隔离区中的文件数（以管理员身份运行时不恢复，避免按伪造的日志移动文件）
+}
+
+// Restore 将批次中的文件移回原位置（原位置已有文件时跳过），全部恢复后删除批次，否则保留未恢复的条目。
+// 只恢复与本进程权限相同的隔离区中的文件；管理员隔离区的权限检查不通过时返回 ErrUnsafeDir
+func (s *Store) Restore(runID string) (*RestoreResult, error) {
+	if !ValidRunID(runID) {
+		return nil, apperr.New(apperr.InvalidRun, "id", runID)
+	}
+
+	result := &RestoreResult{}
+	found := false
+
+	for _, b := range s.bases(false) {
+		dir := filepath.Join(b.dir, runID)
+		journals := s.runJournals(dir)
+		if len(journals) == 0 {
+			continue
+		}
+		found = true
+
+		if b.admin != s.admin {
+			for _, j := range journals {
+				if b.admin {
+					result.Elevated += len(j.Entries)
+				} else {
+					result.Untrusted += len(j.Entries)
+				}
+			}
+			continue
+		}
+		if b.admin {
+			if err := checkAdminDir(s.fs, b.dir); err != nil {
+				return nil, err
+			}
+		}
+
+		kept := 0
+		for name, j := range journals {
+			var remaining []Entry
+			for _, e := range j.Entries {
+				if !validEntry(b.root, e) || s.admin && s.hasLink(filepath.Dir(e.Original)) {
+					remaining = append(remaining, e)
+					result.Failed++
+					continue
+				}
+				stored := filepath.Join(dir, e.Stored)
+				if _, err := s.fs.Lstat(stored); err != nil {
+					continue // 已被删除，无法恢复
+				}
+				if _, err := s.fs.Lstat(e.Original); err == nil {
+					remaining = append(remaining, e) // 原位置已有同名文件，不覆盖
+					result.Conflicts++
+					continue
+				}
+				s.fs.MkdirAll(filepath.Dir(e.Original), 0755)
+				if err := s.fs.Rename(stored, e.Original); err != nil {
+					remaining = append(remaining, e)
+					result.Failed++
+					continue
+				}
+				result.Size += e.Size
+				result.Count++
+			}
+
+			j.Entries = remaining
+			s.writeJournal(filepath.Join(dir, name), j)
+			kept += len(remaining)
+		}
+
+		if kept == 0 {
+			s.fs.RemoveAll(dir)
+		}
+	}
+
+	if !found {
+		return nil, apperr.New(apperr.RunNotFound, "id", runID)
+	}
+	return result, nil
+}
+
+// validEntry 检查日志条目：原路径在隔离区所在的分区上，隔离区内的文件名在 files 目录下
+func validEntry(root string, e Entry) bool {
+	original, ok := volumeRoot(e.Original)
+	return ok && original == root && filepath.IsLocal(e.Stored) &&
+		strings.HasPrefix(e.Stored, "files"+string(filepath.Separator))
+}
+
+// hasLink 判断目录或其上级目录是否为符号链接或目录联接（以管理员身份恢复时不跟随普通用户创建的链接）
+func (s *Store) hasLink(dir string) bool {
+	for {
+		if info, err := s.fs.Lstat(dir); err == nil && info.Mode()&(fs.ModeSymlink|fs.ModeIrregular) != 0 {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+func (s *Store) writeJournal(path string, j *Journal) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.fs.WriteFile(path, data, 0644)
+}
+
+// usage 统计除 exclude 外本进程可以删除的批次占用的空间
+func (s *Store) usage(exclude string) int64 {
+	var total int64
+	for _, run := range s.runs(true) {
+		if run.RunID != exclude {
+			total += run.Size
+		}
+	}
+	return total
+}
+
+// evictOldest 删除最早的一个批次（不包括 exclude），没有可删除的批次时返回 false
+func (s *Store) evictOldest(exclude string) bool {
+	runs := s.runs(true)
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].RunID != exclude {
+			return s.Delete(runs[i].RunID) == nil
+		}
+	}
+	return false
+}
+
+// Run 一次清理批次的隔离会话（同一批次可以由主程序和辅助程序分别写入，part 用于区分日志文件）
+type Run struct {
+	store *Store
+	id    string
+	part  string
+
+	mu      sync.Mutex
+	volumes map[string]*volume // 分区根目录 -> 当前日志
+	size    int64              // 本会话已隔离的大小
+	others  int64              // 开始时其他批次已占用的大小
+}
+
+// volume 批次在一个分区上的当前日志文件
+type volume struct {
+	dir     string // 批次目录
+	seq     int    // 当前日志文件的序号
+	journal *Journal
+}
+
+// Begin 开始一次隔离会话（会先清除过期的批次）
+func (s *Store) Begin(runID, part string) (*Run, error) {
+	if !ValidRunID(runID) || !ValidRunID(part) {
+		return nil, apperr.New(apperr.InvalidRun, "id", runID)
+	}
+
+	s.Purge()
+	return &Run{
+		store:   s,
+		id:      runID,
+		part:    part,
+		volumes: make(map[string]*volume),
+		others:  s.usage(runID),
+	}, nil
+}
+
+// Remove 删除文件，run 不为空时移入隔离区
+func Remove(filesystem fsys.FS, run *Run, path string) error {
+	if run == nil {
+		return filesystem.Remove(path)
+	}
+	return run.Remove(path)
+}
+
+// ID 返回批次 ID
+func (r *Run) ID() string {
+	return r.id
+}
+
+// Remove 将文件移入隔离区（先写日志再移动）。超出容量上限时先删除最早的批次，
+// 仍放不下时返回 ErrFull，文件保留在原位置
+func (r *Run) Remove(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filesystem := r.store.fs
+	info, err := filesystem.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return &fs.PathError{Op: "quarantine", Path: path, Err: errors.New("is a directory")}
+	}
+
+	root, ok := volumeRoot(path)
+	if !ok {
+		return ErrNoVolume
+	}
+
+	for r.others+r.size+info.Size() > r.store.MaxSize {
+		if !r.store.evictOldest(r.id) {
+			// 隔离区放不下，不能恢复的文件不删除
+			return ErrFull
+		}
+		r.others = r.store.usage(r.id)
+	}
+
+	v, err := r.volume(root)
+	if err != nil {
+		return err
+	}
+	if len(v.journal.Entries) >= segmentSize {
+		v.seq++
+		v.journal = &Journal{RunID: r.id, CreatedAt: v.journal.CreatedAt, Next: v.journal.Next}
+	}
+
+	j := v.journal
+	stored := filepath.Join("files", fmt.Sprintf("%s-%d", r.part, j.Next))
+	j.Next++
+	j.Entries = append(j.Entries, Entry{
+		Original: path,
+		Stored:   stored,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	})
+	if err := r.store.writeJournal(r.journalPath(v), j); err != nil {
+		j.Entries = j.Entries[:len(j.Entries)-1]
+		return err
+	}
+	if err := filesystem.Rename(path, filepath.Join(v.dir, stored)); err != nil {
+		j.Entries = j.Entries[:len(j.Entries)-1]
+		r.store.writeJournal(r.journalPath(v), j)
+		return err
+	}
+
+	r.size += info.Size()
+	return nil
+}
+
+// volume 返回分区上的当前日志。同一批次之前写入过时从新的日志文件开始，编号接着之前的最大编号
+func (r *Run) volume(root string) (*volume, error) {
+	if v, ok := r.volumes[root]; ok {
+		return v, nil
+	}
+
+	baseDir := filepath.Join(root, dirName(r.store.admin))
+	if r.store.admin {
+		if err := createAdminDir(r.store.fs, baseDir); err != nil {
+			return nil, err
+		}
+	}
+	dir := filepath.Join(baseDir, r.id)
+	if err := r.store.fs.MkdirAll(filepath.Join(dir, "files"), 0755); err != nil {
+		return nil, err
+	}
+
+	v := &volume{dir: dir, journal: &Journal{RunID: r.id, CreatedAt: time.Now()}}
+	for name, j := range r.store.runJournals(dir) {
+		if seq, ok := r.segment(name); ok {
+			v.seq = max(v.seq, seq+1)
+			v.journal.Next = max(v.journal.Next, j.Next)
+		}
+	}
+	r.volumes[root] = v
+	return v, nil
+}
+
+// segment 返回本会话的日志文件序号（journal-<part>-<序号>.json；之前版本的 journal-<part>.json 为 0）
+func (r *Run) segment(name string) (int, bool) {
+	rest, ok := strings.CutPrefix(name, "journal-"+r.part)
+	if !ok {
+		return 0, false
+	}
+	if rest == ".json" {
+		return 0, true
+	}
+	rest, ok = strings.CutPrefix(strings.TrimSuffix(rest, ".json"), "-")
+	if !ok {
+		return 0, false
+	}
+	seq, err := strconv.Atoi(rest)
+	return seq, err == nil
+}
+
+func (r *Run) journalPath(v *volume) string {
+	return filepath.Join(v.dir, fmt.Sprintf("journal-%s-%d.json", r.part, v.seq))
+}
+
+// Close 结束隔离会话（日志在移动每个文件之前已经写入）
+func (r *Run) Close() error {
+	return nil
+}
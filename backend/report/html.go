@@ -0,0 +1,258 @@
+package report
+
+import (
+	"bytes"
+	"ccooler/backend/apperr"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+// htmlLabels HTML 报告的文字，按语言区分（缺少的文字使用默认语言）
+var htmlLabels = map[string]map[string]string{
+	"zh-CN": {
+		"title":           "CCooler 扫描报告",
+		"exportTitle":     "导出报告",
+		"computer":        "计算机",
+		"os":              "系统",
+		"generated":       "生成时间",
+		"schema":          "格式",
+		"sep":             "　",
+		"colon":           "：",
+		"yes":             "是",
+		"no":              "否",
+		"disk":            "系统盘空间",
+		"drive":           "分区",
+		"total":           "总容量",
+		"used":            "已用",
+		"free":            "可用",
+		"usage":           "使用率",
+		"failed":          "%s扫描失败：%s",
+		"cleanTitle":      "清理项（共 %s，%d 个文件）",
+		"name":            "名称",
+		"size":            "大小",
+		"files":           "文件数",
+		"recommended":     "推荐",
+		"admin":           "管理员",
+		"status":          "状态",
+		"path":            "路径",
+		"pathSize":        "%s（%s）",
+		"largeFilesTitle": "大文件（%s，%d 个，共 %s%s）",
+		"category":        "分类",
+		"modified":        "修改时间",
+		"optimizeTitle":   "系统优化（共 %s）",
+		"enabled":         "已启用",
+		"softwareTitle":   "已安装软件（共 %s%s）",
+		"wechatTitle":     "微信（共 %s%s）",
+		"statusSuffix":    "，%s",
+		"chat":            "聊天记录",
+		"wechatFiles":     "文件",
+		"media":           "图片和视频",
+		"other":           "其他",
+		"dataPath":        "数据目录",
+
+		SectionClean:      "清理项",
+		SectionLargeFiles: "大文件",
+		SectionOptimize:   "系统优化",
+		SectionSoftware:   "已安装软件",
+		SectionWeChat:     "微信",
+	},
+	"en-US": {
+		"title":           "CCooler Scan Report",
+		"exportTitle":     "Export Report",
+		"computer":        "Computer",
+		"os":              "OS",
+		"generated":       "Generated",
+		"schema":          "Schema",
+		"sep":             "  ",
+		"colon":           ": ",
+		"yes":             "Yes",
+		"no":              "No",
+		"disk":            "System Drive",
+		"drive":           "Drive",
+		"total":           "Total",
+		"used":            "Used",
+		"free":            "Free",
+		"usage":           "Usage",
+		"failed":          "%s scan failed: %s",
+		"cleanTitle":      "Cleanup Items (%s in total, %d files)",
+		"name":            "Name",
+		"size":            "Size",
+		"files":           "Files",
+		"recommended":     "Recommended",
+		"admin":           "Admin",
+		"status":          "Status",
+		"path":            "Path",
+		"pathSize":        "%s (%s)",
+		"largeFilesTitle": "Large Files (%s, %d files, %s in total%s)",
+		"category":        "Category",
+		"modified":        "Modified",
+		"optimizeTitle":   "System Optimization (%s in total)",
+		"enabled":         "Enabled",
+		"softwareTitle":   "Installed Software (%s in total%s)",
+		"wechatTitle":     "WeChat (%s in total%s)",
+		"statusSuffix":    ", %s",
+		"chat":            "Chat History",
+		"wechatFiles":     "Files",
+		"media":           "Images and Videos",
+		"other":           "Other",
+		"dataPath":        "Data Directory",
+
+		SectionClean:      "Cleanup items",
+		SectionLargeFiles: "Large files",
+		SectionOptimize:   "System optimization",
+		SectionSoftware:   "Installed software",
+		SectionWeChat:     "WeChat",
+	},
+}
+
+// label 返回 locale 下的文字，有参数时按 fmt 格式化
+func label(locale, key string, args ...any) string {
+	text, ok := htmlLabels[locale][key]
+	if !ok {
+		text, ok = htmlLabels[apperr.DefaultLocale][key]
+	}
+	if !ok {
+		text = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// ExportTitle 导出报告对话框的标题（当前语言）
+func ExportTitle() string {
+	return label(apperr.Locale(), "exportTitle")
+}
+
+// htmlFuncs 与语言有关的模板函数，生成报告时按当前语言替换
+func htmlFuncs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"lang": func() string { return locale },
+		"t":    func(key string, args ...any) string { return label(locale, key, args...) },
+		"yesno": func(b bool) string {
+			if b {
+				return label(locale, "yes")
+			}
+			return label(locale, "no")
+		},
+		// status 非空时返回附加在标题后的状态文字
+		"status": func(status string) string {
+			if status == "" {
+				return ""
+			}
+			return label(locale, "statusSuffix", status)
+		},
+		"section": func(section string) string { return label(locale, section) },
+	}
+}
+
+// htmlTemplate 自包含的 HTML 报告（样式内联，不引用外部资源）
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"size":    formatSize,
+	"time":    func(t time.Time) string { return t.Local().Format("2006-01-02 15:04:05") },
+	"percent": func(used, total int64) string { return fmt.Sprintf("%.1f%%", float64(used)*100/float64(max(total, 1))) },
+}).Funcs(htmlFuncs(apperr.DefaultLocale)).Parse(`<!DOCTYPE html>
+<html lang="{{lang}}">
+<head>
+<meta charset="utf-8">
+<title>{{t "title"}} - {{.Computer}}</title>
+<style>
+body { font-family: "Microsoft YaHei", "Segoe UI", sans-serif; margin: 24px; color: #1f2937; font-size: 14px; }
+h1 { font-size: 22px; margin-bottom: 4px; }
+h2 { font-size: 17px; margin-top: 28px; border-bottom: 2px solid #3b82f6; padding-bottom: 4px; }
+.meta { color: #6b7280; }
+table { border-collapse: collapse; width: 100%; margin-top: 8px; }
+th, td { border: 1px solid #e5e7eb; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f3f4f6; }
+td.num { text-align: right; white-space: nowrap; }
+.path { font-family: Consolas, monospace; font-size: 12px; word-break: break-all; }
+.error { color: #b91c1c; }
+.alert { background: #fefce8; color: #854d0e; padding: 8px 12px; margin-top: 12px; }
+</style>
+</head>
+<body>
+<h1>{{t "title"}}</h1>
+<div class="meta">{{t "computer"}}{{t "colon"}}{{.Computer}}{{t "sep"}}{{t "os"}}{{t "colon"}}{{.OS}}{{t "sep"}}{{t "generated"}}{{t "colon"}}{{time .GeneratedAt}}{{t "sep"}}{{t "schema"}}{{t "colon"}}{{.Schema}}</div>
+{{with .Disk}}
+<h2>{{t "disk"}}</h2>
+<table>
+<tr><th>{{t "drive"}}</th><th>{{t "total"}}</th><th>{{t "used"}}</th><th>{{t "free"}}</th><th>{{t "usage"}}</th></tr>
+<tr><td>{{.Drive}}</td><td class="num">{{size .Total}}</td><td class="num">{{size .Used}}</td><td class="num">{{size .Free}}</td><td class="num">{{percent .Used .Total}}</td></tr>
+</table>
+{{end}}
+{{range $section, $err := .Errors}}<div class="alert">{{t "failed" (section $section) $err}}</div>
+{{end}}
+{{with .Clean}}
+<h2>{{t "cleanTitle" (size .TotalSize) .TotalFiles}}</h2>
+<table>
+<tr><th>ID</th><th>{{t "name"}}</th><th>{{t "size"}}</th><th>{{t "files"}}</th><th>{{t "recommended"}}</th><th>{{t "admin"}}</th><th>{{t "status"}}</th><th>{{t "path"}}</th></tr>
+{{range .Items}}<tr><td>{{.ID}}</td><td>{{.Name}}</td><td class="num">{{size .Size}}</td><td class="num">{{.Files}}</td><td>{{yesno .Recommended}}</td><td>{{yesno .NeedsAdmin}}</td><td>{{.Status}}{{with .Error}}<div class="error">{{.}}</div>{{end}}</td><td class="path">{{range .Paths}}<div>{{t "pathSize" .Path (size .Size)}}</div>{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+{{with .LargeFiles}}
+<h2>{{t "largeFilesTitle" .Drive .TotalFiles (size .TotalSize) (status .Status)}}</h2>
+<table>
+<tr><th>{{t "category"}}</th><th>{{t "size"}}</th><th>{{t "files"}}</th></tr>
+{{range .Categories}}<tr><td>{{.Category}}</td><td class="num">{{size .Size}}</td><td class="num">{{.Files}}</td></tr>
+{{end}}</table>
+<table>
+<tr><th>{{t "size"}}</th><th>{{t "category"}}</th><th>{{t "modified"}}</th><th>{{t "path"}}</th></tr>
+{{range .Files}}<tr><td class="num">{{size .Size}}</td><td>{{.Category}}</td><td>{{.Modified}}</td><td class="path">{{.Path}}</td></tr>
+{{end}}</table>
+{{end}}
+{{with .Optimize}}
+<h2>{{t "optimizeTitle" (size .TotalSize)}}</h2>
+<table>
+<tr><th>{{t "name"}}</th><th>{{t "size"}}</th><th>{{t "enabled"}}</th><th>{{t "path"}}</th></tr>
+{{range .Items}}<tr><td>{{.Name}}</td><td class="num">{{size .Size}}</td><td>{{yesno .Enabled}}</td><td class="path">{{.Path}}</td></tr>
+{{end}}</table>
+{{end}}
+{{with .Software}}
+<h2>{{t "softwareTitle" (size .TotalSize) (status .Status)}}</h2>
+<table>
+<tr><th>{{t "name"}}</th><th>{{t "size"}}</th><th>{{t "path"}}</th></tr>
+{{range .Items}}<tr><td>{{.Name}}</td><td class="num">{{size .Size}}</td><td class="path">{{.Path}}</td></tr>
+{{end}}</table>
+{{end}}
+{{with .WeChat}}
+<h2>{{t "wechatTitle" (size .TotalSize) (status .Status)}}</h2>
+<table>
+<tr><th>{{t "chat"}}</th><th>{{t "wechatFiles"}}</th><th>{{t "media"}}</th><th>{{t "other"}}</th><th>{{t "dataPath"}}</th></tr>
+<tr><td class="num">{{size .ChatSize}}</td><td class="num">{{size .FileSize}}</td><td class="num">{{size .MediaSize}}</td><td class="num">{{size .OtherSize}}</td><td class="path">{{.DataPath}}</td></tr>
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+// HTML 按当前语言生成自包含的 HTML 报告
+func (r *Report) HTML() ([]byte, error) {
+	tmpl, err := htmlTemplate.Clone()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Funcs(htmlFuncs(apperr.Locale())).Execute(&buf, r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sizeUnits 大小单位（1024 进制）
+var sizeUnits = []string{"B", "KB", "MB", "GB", "TB"}
+
+// formatSize 格式化大小，如 1.5 GB（与命令行输出一致）
+func formatSize(size int64) string {
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(sizeUnits)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, sizeUnits[unit])
+}
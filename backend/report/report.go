@@ -0,0 +1,387 @@
+// Package report 生成扫描报告，供技术支持附在工单中：自包含的 HTML 页面、每个部分一个 CSV 文件，
+// 以及结构固定的 JSON 文档。JSON 的 schema 字段标明格式版本，同一版本内字段只增加不修改。
+package report
+
+import (
+	"bytes"
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
+	"ccooler/backend/models"
+	"ccooler/backend/services"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema JSON 报告的格式版本
+const Schema = "ccooler.report/v1"
+
+// 报告格式
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatHTML = "html"
+)
+
+// 报告的各部分（CSV 文件名后缀、Report.Errors 的键）
+const (
+	SectionClean      = "clean"
+	SectionLargeFiles = "largefiles"
+	SectionOptimize   = "optimize"
+	SectionSoftware   = "software"
+	SectionWeChat     = "wechat"
+)
+
+// Sections 所有部分（按报告中的顺序）
+var Sections = []string{SectionClean, SectionLargeFiles, SectionOptimize, SectionSoftware, SectionWeChat}
+
+// Input 报告的数据来源，为空的部分不出现在报告中
+type Input struct {
+	Disk       *models.DiskInfo
+	CleanItems []*models.CleanItem            // ScanCleanItems
+	LargeFiles *services.ScanResult           // ScanLargeFiles
+	Optimize   *services.SystemOptimizeResult // ScanSystemOptimize
+	Software   []*models.SoftwareInfo         // GetInstalledSoftware
+	WeChat     *models.WeChatData             // DetectWeChat
+	Errors     map[string]string              // 扫描失败的部分及原因
+}
+
+// Empty 是否没有任何扫描结果
+func (in *Input) Empty() bool {
+	return in.CleanItems == nil && in.LargeFiles == nil && in.Optimize == nil && in.Software == nil && in.WeChat == nil
+}
+
+// Report 报告（JSON 结构）
+type Report struct {
+	Schema      string            `json:"schema"`
+	GeneratedAt time.Time         `json:"generatedAt"`
+	Computer    string            `json:"computer"`
+	OS          string            `json:"os"`
+	Disk        *Disk             `json:"disk,omitempty"`
+	Clean       *CleanSection     `json:"clean,omitempty"`
+	LargeFiles  *LargeFileSection `json:"largeFiles,omitempty"`
+	Optimize    *OptimizeSection  `json:"optimize,omitempty"`
+	Software    *SoftwareSection  `json:"software,omitempty"`
+	WeChat      *WeChatSection    `json:"wechat,omitempty"`
+	Errors      map[string]string `json:"errors,omitempty"` // 扫描失败的部分
+}
+
+// Disk 系统盘空间
+type Disk struct {
+	Drive string `json:"drive"`
+	Total int64  `json:"total"`
+	Used  int64  `json:"used"`
+	Free  int64  `json:"free"`
+}
+
+// CleanSection 清理项
+type CleanSection struct {
+	TotalSize  int64       `json:"totalSize"`
+	TotalFiles int         `json:"totalFiles"`
+	Items      []CleanItem `json:"items"`
+}
+
+// CleanItem 清理项的扫描结果
+type CleanItem struct {
+	ID           string      `json:"id"`
+	Name         string      `json:"name"`
+	Size         int64       `json:"size"`
+	Files        int         `json:"files"`
+	Recommended  bool        `json:"recommended"` // 默认选中
+	Safe         bool        `json:"safe"`
+	NeedsAdmin   bool        `json:"needsAdmin"`
+	Status       string      `json:"status"`
+	Error        string      `json:"error,omitempty"`
+	ExcludedSize int64       `json:"excludedSize,omitempty"` // 受保护路径中的内容
+	Paths        []CleanPath `json:"paths"`
+}
+
+// CleanPath 清理项中的路径
+type CleanPath struct {
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+	Files int    `json:"files"`
+}
+
+// LargeFileSection 大文件
+type LargeFileSection struct {
+	Drive      string          `json:"drive"`
+	TotalSize  int64           `json:"totalSize"`
+	TotalFiles int             `json:"totalFiles"`
+	Status     string          `json:"status,omitempty"` // 扫描被取消时为 cancelled
+	Categories []FileCategory  `json:"categories"`
+	Files      []LargeFileItem `json:"files"` // 从大到小
+}
+
+// FileCategory 大文件分类统计
+type FileCategory struct {
+	Category string `json:"category"`
+	Size     int64  `json:"size"`
+	Files    int    `json:"files"`
+}
+
+// LargeFileItem 大文件
+type LargeFileItem struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Category string `json:"category"`
+	Modified string `json:"modified"`
+}
+
+// OptimizeSection 系统优化项
+type OptimizeSection struct {
+	TotalSize int64          `json:"totalSize"`
+	Items     []OptimizeItem `json:"items"`
+}
+
+// OptimizeItem 系统优化项
+type OptimizeItem struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Enabled bool   `json:"enabled"`
+}
+
+// SoftwareSection 已安装软件
+type SoftwareSection struct {
+	TotalSize int64          `json:"totalSize"`
+	Status    string         `json:"status,omitempty"` // 统计被取消时为 cancelled
+	Items     []SoftwareItem `json:"items"`            // 从大到小
+}
+
+// SoftwareItem 已安装软件
+type SoftwareItem struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// WeChatSection 微信数据
+type WeChatSection struct {
+	InstallPath string `json:"installPath"`
+	DataPath    string `json:"dataPath"`
+	ChatSize    int64  `json:"chatSize"`
+	FileSize    int64  `json:"fileSize"`
+	MediaSize   int64  `json:"mediaSize"`
+	OtherSize   int64  `json:"otherSize"`
+	TotalSize   int64  `json:"totalSize"`
+	Status      string `json:"status,omitempty"`
+}
+
+// Build 根据扫描结果生成报告
+func Build(in *Input, now time.Time) *Report {
+	computer, _ := os.Hostname()
+	r := &Report{
+		Schema:      Schema,
+		GeneratedAt: now,
+		Computer:    computer,
+		OS:          runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if len(in.Errors) > 0 {
+		r.Errors = in.Errors
+	}
+	if in.Disk != nil {
+		r.Disk = &Disk{Drive: in.Disk.Drive, Total: in.Disk.Total, Used: in.Disk.Used, Free: in.Disk.Free}
+	}
+
+	if in.CleanItems != nil {
+		r.Clean = &CleanSection{Items: []CleanItem{}}
+		for _, item := range in.CleanItems {
+			ci := CleanItem{
+				ID:           item.ID,
+				Name:         item.Name,
+				Size:         item.Size,
+				Files:        item.FileCount,
+				Recommended:  item.Checked,
+				Safe:         item.Safe,
+				NeedsAdmin:   item.NeedsAdmin,
+				Status:       item.Status,
+				Error:        item.Error,
+				ExcludedSize: item.ExcludedSize,
+				Paths:        []CleanPath{},
+			}
+			for _, p := range item.Paths {
+				ci.Paths = append(ci.Paths, CleanPath{Path: p.Path, Size: p.Size, Files: p.FileCount})
+			}
+			r.Clean.Items = append(r.Clean.Items, ci)
+			r.Clean.TotalSize += item.Size
+			r.Clean.TotalFiles += item.FileCount
+		}
+	}
+
+	if in.LargeFiles != nil {
+		lf := in.LargeFiles
+		r.LargeFiles = &LargeFileSection{
+			Drive:      lf.Drive,
+			TotalSize:  lf.TotalSize,
+			TotalFiles: lf.TotalFiles,
+			Status:     lf.Status,
+			Categories: []FileCategory{},
+			Files:      []LargeFileItem{},
+		}
+		for _, stat := range lf.Stats {
+			r.LargeFiles.Categories = append(r.LargeFiles.Categories, FileCategory{Category: string(stat.Category), Size: stat.TotalSize, Files: stat.FileCount})
+		}
+		for _, file := range lf.Files {
+			r.LargeFiles.Files = append(r.LargeFiles.Files, LargeFileItem{Path: file.Path, Size: file.Size, Category: string(file.Category), Modified: file.ModifiedTime})
+		}
+		sort.SliceStable(r.LargeFiles.Files, func(i, j int) bool { return r.LargeFiles.Files[i].Size > r.LargeFiles.Files[j].Size })
+	}
+
+	if in.Optimize != nil {
+		r.Optimize = &OptimizeSection{TotalSize: in.Optimize.TotalSize, Items: []OptimizeItem{}}
+		for _, item := range in.Optimize.Items {
+			r.Optimize.Items = append(r.Optimize.Items, OptimizeItem{Type: string(item.Type), Name: item.Name, Path: item.Path, Size: item.Size, Enabled: item.Enabled})
+		}
+	}
+
+	if in.Software != nil {
+		r.Software = &SoftwareSection{Items: []SoftwareItem{}}
+		for _, software := range in.Software {
+			r.Software.Items = append(r.Software.Items, SoftwareItem{Name: software.Name, Path: software.Path, Size: software.Size})
+			r.Software.TotalSize += software.Size
+			if software.Status != "" {
+				r.Software.Status = software.Status
+			}
+		}
+		sort.SliceStable(r.Software.Items, func(i, j int) bool { return r.Software.Items[i].Size > r.Software.Items[j].Size })
+	}
+
+	if wc := in.WeChat; wc != nil {
+		r.WeChat = &WeChatSection{
+			InstallPath: wc.InstallPath,
+			DataPath:    wc.DataPath,
+			ChatSize:    wc.ChatSize,
+			FileSize:    wc.FileSize,
+			MediaSize:   wc.MediaSize,
+			OtherSize:   wc.OtherSize,
+			TotalSize:   wc.Total,
+			Status:      wc.Status,
+		}
+	}
+	return r
+}
+
+// JSON 生成 JSON 报告
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// CSV 生成每个部分的 CSV（UTF-8 带 BOM，便于 Excel 打开），键为部分名称；
+// 大小的单位为字节，列名与 JSON 字段一致。清理项的路径单独一个表（键为 clean-paths），
+// 汇总字段（totalSize、大文件分类统计等）不输出
+func (r *Report) CSV() (map[string][]byte, error) {
+	tables := make(map[string][][]string)
+	if r.Clean != nil {
+		rows := [][]string{{"id", "name", "size", "files", "recommended", "safe", "needsAdmin", "status", "error", "excludedSize"}}
+		paths := [][]string{{"id", "path", "size", "files"}}
+		for _, item := range r.Clean.Items {
+			rows = append(rows, []string{item.ID, item.Name, itoa(item.Size), strconv.Itoa(item.Files), strconv.FormatBool(item.Recommended),
+				strconv.FormatBool(item.Safe), strconv.FormatBool(item.NeedsAdmin), item.Status, item.Error, itoa(item.ExcludedSize)})
+			for _, p := range item.Paths {
+				paths = append(paths, []string{item.ID, p.Path, itoa(p.Size), strconv.Itoa(p.Files)})
+			}
+		}
+		tables[SectionClean] = rows
+		tables[SectionClean+"-paths"] = paths
+	}
+	if r.LargeFiles != nil {
+		rows := [][]string{{"path", "size", "category", "modified"}}
+		for _, file := range r.LargeFiles.Files {
+			rows = append(rows, []string{file.Path, itoa(file.Size), file.Category, file.Modified})
+		}
+		tables[SectionLargeFiles] = rows
+	}
+	if r.Optimize != nil {
+		rows := [][]string{{"type", "name", "path", "size", "enabled"}}
+		for _, item := range r.Optimize.Items {
+			rows = append(rows, []string{item.Type, item.Name, item.Path, itoa(item.Size), strconv.FormatBool(item.Enabled)})
+		}
+		tables[SectionOptimize] = rows
+	}
+	if r.Software != nil {
+		rows := [][]string{{"name", "path", "size"}}
+		for _, item := range r.Software.Items {
+			rows = append(rows, []string{item.Name, item.Path, itoa(item.Size)})
+		}
+		tables[SectionSoftware] = rows
+	}
+	if wc := r.WeChat; wc != nil {
+		tables[SectionWeChat] = [][]string{
+			{"installPath", "dataPath", "chatSize", "fileSize", "mediaSize", "otherSize", "totalSize", "status"},
+			{wc.InstallPath, wc.DataPath, itoa(wc.ChatSize), itoa(wc.FileSize), itoa(wc.MediaSize), itoa(wc.OtherSize), itoa(wc.TotalSize), wc.Status},
+		}
+	}
+
+	files := make(map[string][]byte, len(tables))
+	for section, rows := range tables {
+		var buf bytes.Buffer
+		buf.WriteString("\ufeff")
+		w := csv.NewWriter(&buf)
+		if err := w.WriteAll(rows); err != nil {
+			return nil, err
+		}
+		files[section] = buf.Bytes()
+	}
+	return files, nil
+}
+
+// Write 按格式写入报告，返回写入的文件。CSV 格式每个部分一个文件，
+// 文件名为 path 去掉扩展名后加上 "-部分名称.csv"
+func Write(filesystem fsys.FS, r *Report, format, path string) ([]string, error) {
+	contents := make(map[string][]byte)
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		content, err := r.JSON()
+		if err != nil {
+			return nil, err
+		}
+		contents[path] = content
+	case FormatHTML:
+		content, err := r.HTML()
+		if err != nil {
+			return nil, err
+		}
+		contents[path] = content
+	case FormatCSV:
+		tables, err := r.CSV()
+		if err != nil {
+			return nil, err
+		}
+		base := strings.TrimSuffix(path, filepath.Ext(path))
+		for section, content := range tables {
+			contents[base+"-"+section+".csv"] = content
+		}
+	default:
+		return nil, apperr.New(apperr.UnsupportedFormat, "format", format)
+	}
+	if len(contents) == 0 {
+		return nil, apperr.New(apperr.NoScanResults)
+	}
+
+	files := make([]string, 0, len(contents))
+	for file := range contents {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	if err := filesystem.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, apperr.Wrap(apperr.SaveFailed, err, "file", path)
+	}
+	for _, file := range files {
+		if err := filesystem.WriteFile(file, contents[file], 0644); err != nil {
+			return nil, apperr.Wrap(apperr.SaveFailed, err, "file", file)
+		}
+	}
+	return files, nil
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"ccooler/backend/apperr"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 类似 cron 的计划：分 时 日 月 星期（星期 0 和 7 都表示周日），
+// 支持 *、列表（1,15）、范围（1-5）、步长（*/15、8-18/2）以及 @hourly、@daily、@weekly、@monthly
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool // 日或星期为 * 时只按另一个字段匹配
+}
+
+// field 字段取值范围
+type field struct {
+	min, max int
+}
+
+// fields 分 时 日 月 星期
+var fields = []field{
+	{0, 59},
+	{0, 23},
+	{1, 31},
+	{1, 12},
+	{0, 7},
+}
+
+var macros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// ParseSchedule 解析计划表达式
+func ParseSchedule(expr string) (*Schedule, error) {
+	text := strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(text)]; ok {
+		text = macro
+	}
+	parts := strings.Fields(text)
+	if len(parts) != len(fields) {
+		return nil, apperr.New(apperr.InvalidSchedule, "schedule", expr)
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, bad, ok := parseField(part, fields[i])
+		if !ok {
+			return nil, apperr.New(apperr.InvalidScheduleField, "schedule", expr, "position", i+1,
+				"value", bad, "min", fields[i].min, "max", fields[i].max)
+		}
+		bits[i] = b
+	}
+	// 星期 7 与 0 相同
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+	return &Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}, nil
+}
+
+// parseField 解析一个字段，返回取值的位集合；无效时返回无效的部分
+func parseField(text string, f field) (uint64, string, bool) {
+	var bits uint64
+	for _, item := range strings.Split(text, ",") {
+		rangeText, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, item, false
+			}
+			step = n
+		}
+
+		low, high := f.min, f.max
+		if rangeText != "*" {
+			lowText, highText, isRange := strings.Cut(rangeText, "-")
+			var err error
+			if low, err = strconv.Atoi(lowText); err != nil {
+				return 0, item, false
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highText); err != nil {
+					return 0, item, false
+				}
+			} else if hasStep {
+				// 5/15 表示从 5 开始每 15
+				high = f.max
+			}
+		}
+		if low < f.min || high > f.max || low > high {
+			return 0, item, false
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, "", true
+}
+
+// matchDay 日期是否匹配（日和星期都有限制时满足其一即可，与 cron 相同）
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next 返回 after 之后（不含）的下一个运行时间，五年内没有匹配时返回零值
+func (s *Schedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,838 @@
+// Package scheduler 自动清理：按计划（类似 cron）或在系统盘剩余空间低于阈值时执行保存的清理方案，
+// 记录每次运行。需要管理员权限的清理项在未提升权限时跳过，或排队等下次打开程序时由用户确认清理。
+// 方案、运行状态和记录保存在用户配置目录，程序窗口和命令行（ccooler schedule run）共用同一个文件。
+package scheduler
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/catalog"
+	"ccooler/backend/fsys"
+	"ccooler/backend/history"
+	"ccooler/backend/models"
+	"ccooler/backend/ops"
+	"ccooler/backend/quarantine"
+	"ccooler/backend/services"
+	"ccooler/backend/walker"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileVersion 方案文件格式版本
+const FileVersion = 1
+
+const (
+	CheckInterval     = time.Minute   // 程序运行时检查计划的间隔
+	ThresholdCooldown = 6 * time.Hour // 剩余空间不足触发后，同一方案至少间隔多久再次触发
+	maxHistory        = 200           // 最多保留的运行记录
+)
+
+// 任务计划程序中的任务（RegisterTask）：程序未运行时定期执行 schedule run
+const (
+	TaskName     = `CCooler\AutoClean`
+	TaskInterval = 15 // 运行间隔（分钟）
+)
+
+// 触发方式（Run.Trigger）
+const (
+	TriggerSchedule  = "schedule"  // 按计划
+	TriggerThreshold = "threshold" // 剩余空间低于阈值
+	TriggerManual    = "manual"    // 手动运行
+)
+
+// 需要管理员权限的清理项的处理方式（Profile.AdminItems）
+const (
+	AdminSkip  = "skip"  // 跳过（默认）
+	AdminQueue = "queue" // 排队，下次打开程序时提示用户清理
+)
+
+// 运行状态（Run.Status）
+const (
+	RunCompleted = "completed"
+	RunPartial   = "partial" // 有清理项出错
+	RunFailed    = "failed"
+	RunCancelled = ops.StatusCancelled
+)
+
+// 清理项在运行记录中的状态（除清理项本身的状态外）
+const (
+	ItemSkipped = "skipped"
+	ItemQueued  = "queued"
+)
+
+// ErrNotFound 方案不存在
+var ErrNotFound = apperr.New(apperr.ProfileNotFound)
+
+// Profile 保存的清理方案
+type Profile struct {
+	ID         string                     `json:"id"`
+	Name       string                     `json:"name"`
+	Enabled    bool                       `json:"enabled"`
+	Items      []string                   `json:"items"`                // 清理项 ID
+	Filters    map[string]*catalog.Filter `json:"filters,omitempty"`    // 替换清理项的过滤条件（只用于 folder 处理方式）
+	DryRun     bool                       `json:"dryRun,omitempty"`     // 只生成删除清单
+	Quarantine bool                       `json:"quarantine,omitempty"` // 移入隔离区而不是直接删除
+	Schedule   string                     `json:"schedule,omitempty"`   // 计划，如 "0 3 * * 0" 或 "@daily"，为空时不按计划运行
+	FreeBelow  int64                      `json:"freeBelow,omitempty"`  // 系统盘剩余空间低于该值（字节）时运行，0 表示不检查
+	AdminItems string                     `json:"adminItems,omitempty"` // 需要管理员权限的清理项：skip 或 queue
+}
+
+// ProfileStatus 方案及其运行状态
+type ProfileStatus struct {
+	*Profile
+	LastRun    string `json:"lastRun,omitempty"`    // 上次运行时间
+	LastStatus string `json:"lastStatus,omitempty"` // 上次运行状态
+	NextRun    string `json:"nextRun,omitempty"`    // 下次按计划运行的时间
+}
+
+// RunItem 运行记录中的清理项
+type RunItem struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Size   int64  `json:"size"` // 扫描到的大小
+}
+
+// Run 一次运行记录
+type Run struct {
+	ID            string    `json:"id"`
+	ProfileID     string    `json:"profileId"`
+	ProfileName   string    `json:"profileName"`
+	Trigger       string    `json:"trigger"`
+	StartedAt     time.Time `json:"startedAt"`
+	FinishedAt    time.Time `json:"finishedAt"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	DryRun        bool      `json:"dryRun,omitempty"`
+	FreeBefore    int64     `json:"freeBefore,omitempty"` // 运行前系统盘剩余空间（无法获取时为 0）
+	FreeAfter     int64     `json:"freeAfter,omitempty"`  // 运行后系统盘剩余空间
+	FreedSize     int64     `json:"freedSize"`
+	RemovedCount  int       `json:"removedCount"`
+	SkippedCount  int       `json:"skippedCount"`            // 未能清理的文件数
+	PlannedSize   int64     `json:"plannedSize,omitempty"`   // 试运行：将要删除的大小
+	PlannedCount  int       `json:"plannedCount,omitempty"`  // 试运行：将要删除的文件数
+	Manifest      string    `json:"manifest,omitempty"`      // 试运行：删除清单文件，可用 ReplayCleanManifest 执行
+	QuarantineRun string    `json:"quarantineRun,omitempty"` // 隔离批次 ID，可用 RestoreCleanRun 恢复
+	Items         []RunItem `json:"items"`
+}
+
+// PendingItem 排队等待用户确认清理的清理项（需要管理员权限）
+type PendingItem struct {
+	ItemID    string    `json:"itemId"`
+	Name      string    `json:"name"`
+	ProfileID string    `json:"profileId"`
+	QueuedAt  time.Time `json:"queuedAt"`
+}
+
+// profileState 方案的运行状态
+type profileState struct {
+	LastCheck     time.Time `json:"lastCheck"`               // 上次检查计划的时间
+	LastRun       time.Time `json:"lastRun,omitempty"`       // 上次运行时间
+	LastThreshold time.Time `json:"lastThreshold,omitempty"` // 上次因剩余空间不足运行的时间
+	LastStatus    string    `json:"lastStatus,omitempty"`
+}
+
+// data 方案文件内容
+type data struct {
+	Version  int                      `json:"version"`
+	Profiles []*Profile               `json:"profiles"`
+	State    map[string]*profileState `json:"state,omitempty"`
+	History  []*Run                   `json:"history,omitempty"`
+	Pending  []PendingItem            `json:"pending,omitempty"`
+}
+
+// Scheduler 自动清理调度器（并发安全；每次操作都重新读取文件，修改时对文件加跨进程锁，
+// 程序窗口和命令行可以同时使用）
+type Scheduler struct {
+	fs         fsys.FS
+	path       string
+	clean      *services.CleanService
+	quarantine *quarantine.Store
+	index      *walker.Index  // 目录大小索引，为空时不更新
+	history    *history.Store // 扫描和清理记录，为空时不记录
+	elevated   func() bool    // 是否以管理员权限运行
+	onRun      func(*Run)     // 每次运行结束后调用
+
+	mu    sync.Mutex // 保护文件读写
+	runMu sync.Mutex // 同一时间只运行一个方案
+}
+
+// DefaultPath 默认的方案文件位置（用户配置目录，无法获取时使用程序目录）
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		exePath, _ := os.Executable()
+		dir = filepath.Dir(exePath)
+	}
+	return filepath.Join(dir, "CCooler", "schedule.json")
+}
+
+// New 创建调度器，clean 提供清理规则、受保护路径和剩余空间，store 用于隔离模式
+func New(filesystem fsys.FS, file string, clean *services.CleanService, store *quarantine.Store) *Scheduler {
+	return &Scheduler{fs: filesystem, path: file, clean: clean, quarantine: store}
+}
+
+// SetSizeIndex 设置目录大小索引（清理后使对应目录失效）
+func (s *Scheduler) SetSizeIndex(index *walker.Index) {
+	s.index = index
+}
+
+// SetHistory 设置扫描和清理记录
+func (s *Scheduler) SetHistory(store *history.Store) {
+	s.history = store
+}
+
+// SetElevated 设置检查是否以管理员权限运行的函数（未设置时视为没有管理员权限）
+func (s *Scheduler) SetElevated(elevated func() bool) {
+	s.elevated = elevated
+}
+
+// SetOnRun 设置每次运行结束后的回调
+func (s *Scheduler) SetOnRun(onRun func(*Run)) {
+	s.onRun = onRun
+}
+
+// load 读取方案文件，文件不存在时返回空内容
+func (s *Scheduler) load() (*data, error) {
+	d := &data{Version: FileVersion, State: make(map[string]*profileState)}
+	content, err := s.fs.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return d, nil
+		}
+		return nil, apperr.Wrap(apperr.ReadFailed, err, "file", s.path)
+	}
+	if err := json.Unmarshal(content, d); err != nil {
+		return nil, apperr.Wrap(apperr.FileInvalid, err, "file", s.path)
+	}
+	if d.Version != FileVersion {
+		return nil, apperr.New(apperr.FileVersion, "file", s.path, "version", d.Version)
+	}
+	if d.State == nil {
+		d.State = make(map[string]*profileState)
+	}
+	return d, nil
+}
+
+// save 写入方案文件（先写临时文件再替换，其他进程不会读到写了一半的文件）
+func (s *Scheduler) save(d *data) error {
+	content, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fsys.WriteFileAtomic(s.fs, s.path, content, 0644); err != nil {
+		return apperr.Wrap(apperr.SaveFailed, err, "file", s.path)
+	}
+	return nil
+}
+
+// lock 对方案文件加跨进程锁（方案文件旁边的 .lock 文件），
+// 程序窗口和命令行同时检查计划时只有一个进程会运行到期的方案
+func (s *Scheduler) lock() (func(), error) {
+	if err := s.fs.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return nil, apperr.Wrap(apperr.SaveFailed, err, "file", s.path)
+	}
+	unlock, err := fsys.Lock(s.fs, s.path+".lock")
+	if err != nil {
+		return nil, apperr.Wrap(apperr.SaveFailed, err, "file", s.path)
+	}
+	return unlock, nil
+}
+
+// update 读取、修改并写入方案文件（持有跨进程锁）
+func (s *Scheduler) update(fn func(d *data) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	d, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(d); err != nil {
+		return err
+	}
+	return s.save(d)
+}
+
+// read 读取方案文件
+func (s *Scheduler) read() (*data, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Profiles 返回所有方案及其运行状态
+func (s *Scheduler) Profiles() ([]ProfileStatus, error) {
+	d, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]ProfileStatus, 0, len(d.Profiles))
+	for _, profile := range d.Profiles {
+		status := ProfileStatus{Profile: profile}
+		state := d.State[profile.ID]
+		if state != nil && !state.LastRun.IsZero() {
+			status.LastRun = state.LastRun.Format(time.RFC3339)
+			status.LastStatus = state.LastStatus
+		}
+		if profile.Enabled && profile.Schedule != "" {
+			if schedule, err := ParseSchedule(profile.Schedule); err == nil {
+				from := time.Now()
+				if state != nil && !state.LastCheck.IsZero() && state.LastCheck.Before(from) {
+					from = state.LastCheck
+				}
+				if next := schedule.Next(from); !next.IsZero() {
+					status.NextRun = next.Format(time.RFC3339)
+				}
+			}
+		}
+		list = append(list, status)
+	}
+	return list, nil
+}
+
+// SaveProfile 校验并保存方案（ID 为空时新建），返回保存后的方案
+func (s *Scheduler) SaveProfile(profile *Profile) (*Profile, error) {
+	if err := s.validate(profile); err != nil {
+		return nil, err
+	}
+	saved := *profile
+	err := s.update(func(d *data) error {
+		if saved.ID == "" {
+			saved.ID = newProfileID()
+			d.Profiles = append(d.Profiles, &saved)
+			return nil
+		}
+		for i, existing := range d.Profiles {
+			if existing.ID == saved.ID {
+				// 修改计划后从现在开始计算下次运行时间
+				if existing.Schedule != saved.Schedule {
+					delete(d.State, saved.ID)
+				}
+				d.Profiles[i] = &saved
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// DeleteProfile 删除方案（保留运行记录）
+func (s *Scheduler) DeleteProfile(id string) error {
+	return s.update(func(d *data) error {
+		for i, profile := range d.Profiles {
+			if profile.ID == id {
+				d.Profiles = append(d.Profiles[:i], d.Profiles[i+1:]...)
+				delete(d.State, id)
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+}
+
+// ReplaceProfiles 校验并替换全部方案（ID 为空的新建，不在列表中的删除，保留运行记录），返回保存后的方案。
+// 任一方案无效时不修改任何方案
+func (s *Scheduler) ReplaceProfiles(profiles []*Profile) ([]*Profile, error) {
+	saved, ids, err := s.prepare(profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.update(func(d *data) error {
+		// 删除的方案和修改了计划的方案从现在开始计算下次运行时间
+		for _, old := range d.Profiles {
+			if profile := ids[old.ID]; profile == nil || profile.Schedule != old.Schedule {
+				delete(d.State, old.ID)
+			}
+		}
+		d.Profiles = saved
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// ValidateProfiles 校验 ReplaceProfiles 的参数，不保存
+func (s *Scheduler) ValidateProfiles(profiles []*Profile) error {
+	_, _, err := s.prepare(profiles)
+	return err
+}
+
+// prepare 校验并复制要替换的方案（为新方案分配 ID），返回方案列表和按 ID 的索引
+func (s *Scheduler) prepare(profiles []*Profile) ([]*Profile, map[string]*Profile, error) {
+	saved := make([]*Profile, 0, len(profiles))
+	ids := make(map[string]*Profile, len(profiles))
+	for _, profile := range profiles {
+		if profile == nil {
+			continue
+		}
+		if err := s.validate(profile); err != nil {
+			return nil, nil, apperr.Wrap(apperr.ProfileInvalid, err, "name", profile.Name)
+		}
+		copied := *profile
+		if copied.ID == "" {
+			copied.ID = newProfileID()
+		}
+		if ids[copied.ID] != nil {
+			return nil, nil, apperr.New(apperr.DuplicateProfile, "id", copied.ID)
+		}
+		ids[copied.ID] = &copied
+		saved = append(saved, &copied)
+	}
+	return saved, ids, nil
+}
+
+// validate 校验方案
+func (s *Scheduler) validate(profile *Profile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return apperr.New(apperr.ProfileNoName)
+	}
+	if len(profile.Items) == 0 {
+		return apperr.New(apperr.ProfileNoItems)
+	}
+	for _, id := range profile.Items {
+		if _, ok := s.clean.Catalog().Rule(id); !ok {
+			return apperr.New(apperr.UnknownItem, "id", id)
+		}
+	}
+	if _, err := s.clean.Catalog().WithFilters(profile.Filters); err != nil {
+		return err
+	}
+	if profile.Schedule != "" {
+		if _, err := ParseSchedule(profile.Schedule); err != nil {
+			return err
+		}
+	}
+	if profile.FreeBelow < 0 {
+		return apperr.New(apperr.NegativeFreeBelow)
+	}
+	switch profile.AdminItems {
+	case "", AdminSkip, AdminQueue:
+	default:
+		return apperr.New(apperr.UnknownAdminMode, "mode", profile.AdminItems)
+	}
+	if profile.DryRun && profile.Quarantine {
+		return apperr.New(apperr.DryRunQuarantine)
+	}
+	return nil
+}
+
+// History 返回运行记录（最新的在前），profileID 为空时返回所有方案的记录
+func (s *Scheduler) History(profileID string) ([]*Run, error) {
+	d, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]*Run, 0, len(d.History))
+	for i := len(d.History) - 1; i >= 0; i-- {
+		if profileID == "" || d.History[i].ProfileID == profileID {
+			runs = append(runs, d.History[i])
+		}
+	}
+	return runs, nil
+}
+
+// Pending 返回排队等待清理的清理项
+func (s *Scheduler) Pending() ([]PendingItem, error) {
+	d, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return append([]PendingItem{}, d.Pending...), nil
+}
+
+// ClearPending 从队列中移除清理项（用户已清理或忽略），itemIDs 为空时清空队列
+func (s *Scheduler) ClearPending(itemIDs []string) error {
+	return s.update(func(d *data) error {
+		if len(itemIDs) == 0 {
+			d.Pending = nil
+			return nil
+		}
+		remove := make(map[string]bool, len(itemIDs))
+		for _, id := range itemIDs {
+			remove[id] = true
+		}
+		kept := d.Pending[:0]
+		for _, item := range d.Pending {
+			if !remove[item.ItemID] {
+				kept = append(kept, item)
+			}
+		}
+		d.Pending = kept
+		return nil
+	})
+}
+
+// Start 每隔 CheckInterval 检查一次计划和剩余空间，直到 ctx 取消（onError 接收检查失败的错误）
+func (s *Scheduler) Start(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(CheckInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.RunDue(ctx, time.Now()); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// due 需要运行的方案及其触发方式
+type due struct {
+	profile *Profile
+	trigger string
+}
+
+// RunDue 运行到期的方案：上次检查之后到 now 之间有计划时间的方案（错过的计划只补运行一次），
+// 以及剩余空间低于阈值且距上次因此运行超过 ThresholdCooldown 的方案。返回本次的运行记录
+func (s *Scheduler) RunDue(ctx context.Context, now time.Time) ([]*Run, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	var pending []due
+	err := s.update(func(d *data) error {
+		var free int64 = -1
+		for _, profile := range d.Profiles {
+			state := d.State[profile.ID]
+			if state == nil {
+				state = &profileState{}
+				d.State[profile.ID] = state
+			}
+			if !profile.Enabled {
+				state.LastCheck = now
+				continue
+			}
+
+			trigger := ""
+			if profile.Schedule != "" && !state.LastCheck.IsZero() {
+				if schedule, err := ParseSchedule(profile.Schedule); err == nil {
+					if next := schedule.Next(state.LastCheck); !next.IsZero() && !next.After(now) {
+						trigger = TriggerSchedule
+					}
+				}
+			}
+			if trigger == "" && profile.FreeBelow > 0 && now.Sub(state.LastThreshold) >= ThresholdCooldown {
+				if free < 0 {
+					free = s.freeSpace()
+				}
+				if free >= 0 && free < profile.FreeBelow {
+					trigger = TriggerThreshold
+					state.LastThreshold = now
+				}
+			}
+			state.LastCheck = now
+			if trigger != "" {
+				pending = append(pending, due{profile: profile, trigger: trigger})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*Run
+	for _, item := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		run, err := s.run(ctx, item.profile, item.trigger)
+		if err != nil {
+			return runs, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// RunNow 立即运行方案（不论是否启用）
+func (s *Scheduler) RunNow(ctx context.Context, id string) (*Run, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	d, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range d.Profiles {
+		if profile.ID == id {
+			return s.run(ctx, profile, TriggerManual)
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// run 执行方案并保存运行记录
+func (s *Scheduler) run(ctx context.Context, profile *Profile, trigger string) (*Run, error) {
+	run, queued := s.execute(ctx, profile, trigger)
+
+	var dropped []*Run
+	err := s.update(func(d *data) error {
+		d.History = append(d.History, run)
+		if len(d.History) > maxHistory {
+			dropped = append(dropped, d.History[:len(d.History)-maxHistory]...)
+			d.History = append([]*Run{}, d.History[len(d.History)-maxHistory:]...)
+		}
+		d.Pending = mergePending(d.Pending, queued)
+		if state := d.State[profile.ID]; state != nil {
+			state.LastRun = run.FinishedAt
+			state.LastStatus = run.Status
+		}
+		return nil
+	})
+	for _, old := range dropped {
+		if old.Manifest != "" {
+			s.fs.Remove(old.Manifest)
+		}
+	}
+
+	if s.onRun != nil {
+		s.onRun(run)
+	}
+	return run, err
+}
+
+// execute 扫描并清理方案中的清理项，返回运行记录和排队的清理项
+func (s *Scheduler) execute(ctx context.Context, profile *Profile, trigger string) (*Run, []PendingItem) {
+	run := &Run{
+		ID:          quarantine.NewRunID(),
+		ProfileID:   profile.ID,
+		ProfileName: profile.Name,
+		Trigger:     trigger,
+		StartedAt:   time.Now(),
+		DryRun:      profile.DryRun,
+		FreeBefore:  max(s.freeSpace(), 0),
+		Items:       []RunItem{},
+	}
+	defer func() {
+		run.FinishedAt = time.Now()
+		run.FreeAfter = max(s.freeSpace(), 0)
+		run.Status = runStatus(ctx, run)
+	}()
+
+	cat, err := s.clean.Catalog().WithFilters(profile.Filters)
+	if err != nil {
+		run.Error = err.Error()
+		return run, nil
+	}
+	svc := s.clean.WithCatalog(cat)
+	elevated := s.elevated != nil && s.elevated()
+
+	// 需要管理员权限的项目跳过或排队
+	var items []*models.CleanItem
+	var queued []PendingItem
+	for _, id := range profile.Items {
+		rule, ok := cat.Rule(id)
+		if !ok {
+			run.Items = append(run.Items, RunItem{ID: id, Status: "error", Error: apperr.New(apperr.UnknownItem, "id", id).Error()})
+			continue
+		}
+		if rule.NeedsAdmin && !elevated {
+			item := RunItem{ID: id, Name: rule.Name, Status: ItemSkipped, Error: apperr.New(apperr.ItemSkippedAdmin).Error()}
+			if profile.AdminItems == AdminQueue {
+				item.Status = ItemQueued
+				item.Error = apperr.New(apperr.ItemQueuedAdmin).Error()
+				queued = append(queued, PendingItem{ItemID: id, Name: rule.Name, ProfileID: profile.ID, QueuedAt: time.Now()})
+			}
+			run.Items = append(run.Items, item)
+			continue
+		}
+		item := rule.NewItem("scanning")
+		item.Checked = true
+		items = append(items, item)
+	}
+
+	scan := history.Begin(history.KindScan, history.SourceSchedule, elevated, run.FreeBefore)
+	for _, item := range items {
+		if ctx.Err() != nil {
+			item.Status = ops.StatusCancelled
+			continue
+		}
+		svc.ScanSingleItem(ctx, item)
+	}
+	s.saveIndex()
+	scan.SetScan(items)
+	s.addHistory(ctx, scan)
+
+	if ctx.Err() == nil {
+		if profile.DryRun {
+			s.plan(ctx, svc, items, run)
+		} else if err := s.cleanItems(ctx, svc, profile, items, run); err != nil {
+			run.Error = err.Error()
+		}
+	}
+
+	for _, item := range items {
+		run.Items = append(run.Items, RunItem{ID: item.ID, Name: item.Name, Status: item.Status, Error: item.Error, Size: item.Size})
+	}
+	// 按方案中的顺序列出
+	order := make(map[string]int, len(profile.Items))
+	for i, id := range profile.Items {
+		order[id] = i
+	}
+	sort.SliceStable(run.Items, func(i, j int) bool { return order[run.Items[i].ID] < order[run.Items[j].ID] })
+	return run, queued
+}
+
+// plan 试运行：生成删除清单并保存到方案文件旁边
+func (s *Scheduler) plan(ctx context.Context, svc *services.CleanService, items []*models.CleanItem, run *Run) {
+	manifest := services.NewCleanManifest()
+	for _, item := range items {
+		if item.Status == "scanned" {
+			svc.PlanItem(ctx, item, manifest)
+		}
+	}
+	run.PlannedSize = manifest.TotalSize
+	run.PlannedCount = manifest.TotalFiles
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		run.Error = err.Error()
+		return
+	}
+	file := filepath.Join(filepath.Dir(s.path), "manifests", run.ID+".json")
+	if err := s.fs.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		run.Error = apperr.Wrap(apperr.SaveFailed, err, "file", file).Error()
+		return
+	}
+	if err := fsys.WriteFileAtomic(s.fs, file, content, 0644); err != nil {
+		run.Error = apperr.Wrap(apperr.SaveFailed, err, "file", file).Error()
+		return
+	}
+	run.Manifest = file
+}
+
+// cleanItems 清理扫描成功的项目
+func (s *Scheduler) cleanItems(ctx context.Context, svc *services.CleanService, profile *Profile, items []*models.CleanItem, run *Run) error {
+	var qrun *quarantine.Run
+	if profile.Quarantine {
+		var err error
+		if qrun, err = s.quarantine.Begin(run.ID, "scheduler"); err != nil {
+			return err
+		}
+		defer qrun.Close()
+		run.QuarantineRun = run.ID
+	}
+
+	record := history.Begin(history.KindClean, history.SourceSchedule, s.elevated != nil && s.elevated(), s.freeSpace())
+	report := services.NewCleanReport()
+	var cleaned []*models.CleanItem
+	var paths []string
+	for _, item := range items {
+		if item.Status != "scanned" {
+			continue
+		}
+		cleaned = append(cleaned, item)
+		if ctx.Err() != nil {
+			item.Status = ops.StatusCancelled
+			continue
+		}
+		svc.CleanItem(ctx, item, qrun, report)
+		for _, pathDetail := range item.Paths {
+			paths = append(paths, pathDetail.Path)
+		}
+	}
+	run.FreedSize = report.FreedSize
+	run.RemovedCount = report.RemovedCount
+	run.SkippedCount = report.SkippedCount
+
+	record.SetClean(cleaned, report)
+	record.QuarantineRun = run.QuarantineRun
+	s.addHistory(ctx, record)
+
+	if s.index != nil {
+		s.index.Invalidate(paths...)
+		s.saveIndex()
+	}
+	return nil
+}
+
+// runStatus 根据运行结果确定状态
+func runStatus(ctx context.Context, run *Run) string {
+	if ops.Cancelled(ctx) {
+		return RunCancelled
+	}
+	if run.Error != "" {
+		return RunFailed
+	}
+	for _, item := range run.Items {
+		if item.Status == "error" {
+			return RunPartial
+		}
+	}
+	return RunCompleted
+}
+
+// freeSpace 返回系统盘剩余空间，无法获取时返回 -1
+func (s *Scheduler) freeSpace() int64 {
+	info, err := s.clean.GetDiskInfo()
+	if err != nil {
+		return -1
+	}
+	return info.Free
+}
+
+// addHistory 保存扫描或清理记录（保存失败只记录日志，不影响运行结果）
+func (s *Scheduler) addHistory(ctx context.Context, record *history.Record) {
+	if s.history == nil {
+		return
+	}
+	record.Finish(ctx, s.freeSpace(), nil)
+	if err := s.history.Add(record); err != nil {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Failed to save scheduled run history: %v\n", err)
+	}
+}
+
+func (s *Scheduler) saveIndex() {
+	if s.index != nil {
+		s.index.Save()
+	}
+}
+
+// mergePending 把新排队的清理项加入队列（同一清理项只保留一个）
+func mergePending(list, queued []PendingItem) []PendingItem {
+	for _, item := range queued {
+		found := false
+		for i := range list {
+			if list[i].ItemID == item.ItemID {
+				list[i] = item
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func newProfileID() string {
+	var b [4]byte
+	rand.Read(b[:])
+	return "profile-" + hex.EncodeToString(b[:])
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package scheduler
+
+import "errors"
+
+// RegisterTask 任务计划程序仅支持 Windows
+func RegisterTask(exe string) error {
+	return errors.ErrUnsupported
+}
+
+// UnregisterTask 任务计划程序仅支持 Windows
+func UnregisterTask() error {
+	return errors.ErrUnsupported
+}
+
+// TaskRegistered 任务计划程序仅支持 Windows
+func TaskRegistered() bool {
+	return false
+}
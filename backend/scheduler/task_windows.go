@@ -0,0 +1,38 @@
+package scheduler
+
+import (
+	"ccooler/backend/apperr"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// schtasks 运行 schtasks.exe（不显示控制台窗口），失败时返回带输出的错误
+func schtasks(args ...string) error {
+	cmd := exec.Command("schtasks", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return apperr.Wrap(apperr.CommandFailed, err, "output", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// RegisterTask 在任务计划程序中注册当前用户的任务，每隔 TaskInterval 分钟运行 exe schedule run
+// （程序未运行时也按计划清理）；任务已存在时覆盖
+func RegisterTask(exe string) error {
+	return schtasks("/Create", "/F", "/SC", "MINUTE", "/MO", strconv.Itoa(TaskInterval),
+		"/TN", TaskName, "/TR", fmt.Sprintf(`"%s" schedule run`, exe))
+}
+
+// UnregisterTask 删除任务计划程序中的任务
+func UnregisterTask() error {
+	return schtasks("/Delete", "/F", "/TN", TaskName)
+}
+
+// TaskRegistered 任务计划程序中是否有该任务
+func TaskRegistered() bool {
+	return schtasks("/Query", "/TN", TaskName) == nil
+}
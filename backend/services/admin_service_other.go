@@ -0,0 +1,24 @@
+//go:build !windows
+
+package services
+
+type AdminService struct{}
+
+func NewAdminService() *AdminService {
+	return &AdminService{}
+}
+
+// IsAdmin 检查当前进程是否以管理员权限运行（仅支持 Windows）
+func (s *AdminService) IsAdmin() bool {
+	return false
+}
+
+// IsElevated 检查当前进程是否提升了权限（仅支持 Windows）
+func (s *AdminService) IsElevated() bool {
+	return false
+}
+
+// RestartAsAdmin 以管理员身份重启应用程序（仅支持 Windows）
+func (s *AdminService) RestartAsAdmin() error {
+	return errNotSupported
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"ccooler/backend/models"
+	"ccooler/backend/progress"
+	"context"
+	"sync"
+)
+
+// CleanTracker 汇总应用内清理的进度并限频发送 clean-progress 事件，
+// 数据与辅助程序通过 /elevated-progress 发送的相同。sink 为空时为 nil，所有方法都可以用 nil 调用
+type CleanTracker struct {
+	throttle *progress.Throttle
+
+	mu    sync.Mutex
+	state models.CleanProgress
+}
+
+// NewCleanTracker 开始跟踪清理进度，totalPaths 为要清理的路径数
+func NewCleanTracker(sink progress.Sink, totalPaths int) *CleanTracker {
+	if sink == nil {
+		return nil
+	}
+	return &CleanTracker{
+		throttle: progress.NewThrottle(sink, progress.EventClean),
+		state:    models.CleanProgress{TotalPaths: totalPaths},
+	}
+}
+
+type cleanTrackerKey struct{}
+
+// WithCleanTracker 返回带清理进度的 context，RemoveFiltered 和 CleanLogFilesInPath 据此更新进度
+func WithCleanTracker(ctx context.Context, t *CleanTracker) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, cleanTrackerKey{}, t)
+}
+
+// CleanTrackerFrom 返回 context 中的清理进度（没有时为 nil）
+func CleanTrackerFrom(ctx context.Context) *CleanTracker {
+	t, _ := ctx.Value(cleanTrackerKey{}).(*CleanTracker)
+	return t
+}
+
+func (t *CleanTracker) snapshot() any {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state
+}
+
+// update 修改进度后限频发送
+func (t *CleanTracker) update(fn func(state *models.CleanProgress)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	fn(&t.state)
+	t.mu.Unlock()
+	t.throttle.Emit(t.snapshot)
+}
+
+// BeginPath 开始清理清理项的一个路径
+func (t *CleanTracker) BeginPath(itemID, path string) {
+	t.update(func(state *models.CleanProgress) {
+		state.Item = itemID
+		state.CurrentPath = path
+	})
+}
+
+// EndPath 完成一个路径
+func (t *CleanTracker) EndPath() {
+	t.update(func(state *models.CleanProgress) {
+		state.ProcessedPaths++
+	})
+}
+
+// Add 累加实际删除的大小和文件数
+func (t *CleanTracker) Add(size int64, count int) {
+	t.update(func(state *models.CleanProgress) {
+		state.CleanedSize += size
+		state.CleanedCount += count
+	})
+}
+
+// Finish 发送最终进度
+func (t *CleanTracker) Finish() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.state.ProcessedPaths = t.state.TotalPaths
+	t.state.CurrentPath = "完成"
+	state := t.state
+	t.mu.Unlock()
+	t.throttle.Flush(state)
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"ccooler/backend/models"
+	"ccooler/backend/quarantine"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// MaxSkippedFiles 清理报告中保留的未清理文件明细数量上限（计数不受限制）
+const MaxSkippedFiles = 200
+
+// NewCleanReport 创建空的清理报告
+func NewCleanReport() *models.CleanReport {
+	return &models.CleanReport{
+		SkippedReasons: make(map[models.SkipReason]int),
+		Skipped:        []models.SkippedFile{},
+		Paths:          []models.PathReport{},
+	}
+}
+
+// MergeCleanReport 将 src 合并到 dst
+func MergeCleanReport(dst, src *models.CleanReport) {
+	if src == nil {
+		return
+	}
+	dst.FreedSize += src.FreedSize
+	dst.RemovedCount += src.RemovedCount
+	dst.SkippedSize += src.SkippedSize
+	dst.SkippedCount += src.SkippedCount
+	if dst.SkippedReasons == nil {
+		dst.SkippedReasons = make(map[models.SkipReason]int)
+	}
+	for reason, n := range src.SkippedReasons {
+		dst.SkippedReasons[reason] += n
+	}
+	for _, skipped := range src.Skipped {
+		if len(dst.Skipped) >= MaxSkippedFiles {
+			break
+		}
+		dst.Skipped = append(dst.Skipped, skipped)
+	}
+	for _, p := range src.Paths {
+		dp := pathReport(dst, p.Path, p.ItemID)
+		dp.FreedSize += p.FreedSize
+		dp.RemovedCount += p.RemovedCount
+		dp.SkippedSize += p.SkippedSize
+		dp.SkippedCount += p.SkippedCount
+	}
+}
+
+// SetReportItem 设置清理路径所属的清理项（辅助程序只知道路径时由主程序补全）
+func SetReportItem(report *models.CleanReport, root, itemID string) {
+	for i := range report.Paths {
+		if report.Paths[i].Path == root && report.Paths[i].ItemID == "" {
+			report.Paths[i].ItemID = itemID
+		}
+	}
+}
+
+// pathReport 返回 root 对应的路径结果（不存在时添加）
+func pathReport(report *models.CleanReport, root, itemID string) *models.PathReport {
+	for i := len(report.Paths) - 1; i >= 0; i-- {
+		if report.Paths[i].Path == root {
+			if report.Paths[i].ItemID == "" {
+				report.Paths[i].ItemID = itemID
+			}
+			return &report.Paths[i]
+		}
+	}
+	report.Paths = append(report.Paths, models.PathReport{Path: root, ItemID: itemID})
+	return &report.Paths[len(report.Paths)-1]
+}
+
+// RecordRemoval 记录一次删除的结果（err 为 nil 表示删除成功），report 为 nil 时忽略
+func RecordRemoval(report *models.CleanReport, root, itemID, path string, info fs.FileInfo, err error) {
+	if report == nil {
+		return
+	}
+	var size int64
+	if info != nil {
+		size = info.Size()
+	}
+
+	p := pathReport(report, root, itemID)
+	if err == nil {
+		report.FreedSize += size
+		report.RemovedCount++
+		p.FreedSize += size
+		p.RemovedCount++
+		return
+	}
+
+	RecordSkipped(report, root, itemID, path, size, SkipReasonOf(err, info), err)
+}
+
+// RecordSkipped 记录未清理的文件
+func RecordSkipped(report *models.CleanReport, root, itemID, path string, size int64, reason models.SkipReason, err error) {
+	if report == nil {
+		return
+	}
+	p := pathReport(report, root, itemID)
+	p.SkippedSize += size
+	p.SkippedCount++
+
+	report.SkippedSize += size
+	report.SkippedCount++
+	if report.SkippedReasons == nil {
+		report.SkippedReasons = make(map[models.SkipReason]int)
+	}
+	report.SkippedReasons[reason]++
+
+	if len(report.Skipped) < MaxSkippedFiles {
+		skipped := models.SkippedFile{Path: path, Size: size, Reason: reason}
+		if err != nil {
+			skipped.Error = err.Error()
+		}
+		report.Skipped = append(report.Skipped, skipped)
+	}
+}
+
+// SkipReasonOf 根据删除错误判断未清理的原因
+func SkipReasonOf(err error, info fs.FileInfo) models.SkipReason {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return models.SkipVanished
+	case errors.Is(err, quarantine.ErrFull):
+		return models.SkipQuarantine
+	case isInUseError(err):
+		return models.SkipInUse
+	case errors.Is(err, fs.ErrPermission) || os.IsPermission(err):
+		if info != nil && info.Mode().Perm()&0o200 == 0 {
+			return models.SkipProtected // 只读文件
+		}
+		return models.SkipAccessDenied
+	default:
+		return models.SkipFailed
+	}
+}
@@ -1,509 +1,313 @@
 package services
 
 import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/catalog"
+	"ccooler/backend/fsys"
 	"ccooler/backend/models"
+	"ccooler/backend/ops"
+	"ccooler/backend/progress"
+	"ccooler/backend/protect"
+	"ccooler/backend/quarantine"
+	"ccooler/backend/walker"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
-	"syscall"
-	"unsafe"
 )
 
-type CleanService struct{}
-
-func NewCleanService() *CleanService {
-	return &CleanService{}
+type CleanService struct {
+	fs       fsys.FS
+	catalog  *catalog.Catalog
+	index    *walker.Index // 目录大小索引，为空时每次完整遍历
+	progress progress.Sink // 扫描进度，为空时不发送
+	protect  *protect.List // 受保护路径，为空时不限制
 }
 
-// GetDiskInfo 获取C盘信息
-func (s *CleanService) GetDiskInfo() (*models.DiskInfo, error) {
-	kernel32 := syscall.NewLazyDLL("kernel32.dll")
-	getDiskFreeSpaceEx := kernel32.NewProc("GetDiskFreeSpaceExW")
-
-	var freeBytesAvailable, totalBytes, totalFreeBytes int64
-
-	drive := "C:\\"
-	drivePtr, _ := syscall.UTF16PtrFromString(drive)
-
-	ret, _, _ := getDiskFreeSpaceEx.Call(
-		uintptr(unsafe.Pointer(drivePtr)),
-		uintptr(unsafe.Pointer(&freeBytesAvailable)),
-		uintptr(unsafe.Pointer(&totalBytes)),
-		uintptr(unsafe.Pointer(&totalFreeBytes)),
-	)
-
-	if ret == 0 {
-		return nil, fmt.Errorf("failed to get disk info")
-	}
-
-	return &models.DiskInfo{
-		Total: totalBytes,
-		Free:  totalFreeBytes,
-		Used:  totalBytes - totalFreeBytes,
-	}, nil
+func NewCleanService(filesystem fsys.FS, cat *catalog.Catalog) *CleanService {
+	return &CleanService{fs: filesystem, catalog: cat}
 }
 
-// GetTempPath 获取临时文件路径
-func (s *CleanService) GetTempPath() string {
-	return os.TempDir()
+// SetSizeIndex 设置目录大小索引（只用于不带过滤条件的路径）
+func (s *CleanService) SetSizeIndex(index *walker.Index) {
+	s.index = index
 }
 
-// GetSystemTempPath 获取系统临时文件路径
-func (s *CleanService) GetSystemTempPath() string {
-	return `C:\Windows\Temp`
+// SetProgressSink 设置扫描进度的接收者
+func (s *CleanService) SetProgressSink(sink progress.Sink) {
+	s.progress = sink
 }
 
-// GetRecycleBinPath 获取回收站路径
-func (s *CleanService) GetRecycleBinPath() string {
-	return `C:\$Recycle.Bin`
+// SetCatalog 替换清理规则（用户修改了过滤条件或默认选中的清理项）
+func (s *CleanService) SetCatalog(cat *catalog.Catalog) {
+	s.catalog = cat
 }
 
-// GetBrowserCachePaths 获取浏览器缓存路径
-func (s *CleanService) GetBrowserCachePaths() []string {
-	localAppData := os.Getenv("LOCALAPPDATA")
-	appData := os.Getenv("APPDATA")
-
-	paths := []string{}
-
-	// 通用 Chromium 浏览器列表（支持所有主流浏览器）
-	chromiumBrowsers := []struct {
-		name     string
-		basePath string
-	}{
-		{"Google\\Chrome", filepath.Join(localAppData, `Google\Chrome\User Data\Default`)},
-		{"Microsoft\\Edge", filepath.Join(localAppData, `Microsoft\Edge\User Data\Default`)},
-		{"BraveSoftware\\Brave-Browser", filepath.Join(localAppData, `BraveSoftware\Brave-Browser\User Data\Default`)},
-		{"Vivaldi", filepath.Join(localAppData, `Vivaldi\User Data\Default`)},
-		{"Yandex\\YandexBrowser", filepath.Join(localAppData, `Yandex\YandexBrowser\User Data\Default`)},
-		{"360Chrome\\Chrome", filepath.Join(localAppData, `360Chrome\Chrome\User Data\Default`)},
-		{"Tencent\\QQBrowser", filepath.Join(localAppData, `Tencent\QQBrowser\User Data\Default`)},
-		{"Opera Software\\Opera Stable", filepath.Join(appData, `Opera Software\Opera Stable`)},
-		{"SogouExplorer", filepath.Join(appData, `SogouExplorer\User Data\Default`)},
-		{"UCBrowser", filepath.Join(localAppData, `UCBrowser\User Data\Default`)},
-		{"Quark", filepath.Join(localAppData, `Quark\User Data\Default`)},
-	}
-
-	// 安全可清理的缓存目录（Chromium 通用）
-	cacheDirs := []string{
-		"Cache",          // HTTP 缓存
-		"Code Cache",     // JS/CSS 编译缓存（通常最大）
-		"GPUCache",       // GPU 缓存
-		"Service Worker", // Service Worker 缓存
-		// 注意：不包含 Extensions, IndexedDB, Local Storage 等，避免影响用户体验
-	}
+// SetProtected 设置受保护路径（扫描时单独统计，清理和删除时跳过）
+func (s *CleanService) SetProtected(list *protect.List) {
+	s.protect = list
+}
 
-	// 扫描所有 Chromium 浏览器
-	for _, browser := range chromiumBrowsers {
-		if _, err := os.Stat(browser.basePath); err == nil {
-			for _, dir := range cacheDirs {
-				cachePath := filepath.Join(browser.basePath, dir)
-				if _, err := os.Stat(cachePath); err == nil {
-					paths = append(paths, cachePath)
-				}
-			}
-		}
-	}
+// Protected 判断路径是否受保护
+func (s *CleanService) Protected(path string) bool {
+	return s.protect.Protected(path)
+}
 
-	// Firefox（不同结构）
-	firefoxPath := filepath.Join(appData, `Mozilla\Firefox\Profiles`)
-	if _, err := os.Stat(firefoxPath); err == nil {
-		// 查找 Firefox 配置文件
-		entries, _ := os.ReadDir(firefoxPath)
-		for _, entry := range entries {
-			if entry.IsDir() {
-				cachePath := filepath.Join(firefoxPath, entry.Name(), "cache2")
-				if _, err := os.Stat(cachePath); err == nil {
-					paths = append(paths, cachePath)
-				}
-			}
-		}
+// exclude 返回扫描时排除受保护路径的规则，没有受保护路径时为 nil
+func (s *CleanService) exclude() func(string) bool {
+	if s.protect.Empty() {
+		return nil
 	}
+	return s.protect.Protected
+}
 
-	return paths
+// Catalog 返回清理项目录
+func (s *CleanService) Catalog() *catalog.Catalog {
+	return s.catalog
 }
 
-// GetWindowsUpdateCachePaths 获取 Windows 更新缓存路径（多个）
-func (s *CleanService) GetWindowsUpdateCachePaths() []string {
-	return []string{
-		`C:\Windows\SoftwareDistribution\Download`,  // 更新下载文件
-		`C:\Windows\SoftwareDistribution\DataStore`, // 更新历史数据库
-		`C:\Windows\System32\catroot2`,              // 加密签名缓存
-		// `C:\Windows\Logs\CBS` 已移至系统文件清理项（包含在 C:\Windows\Logs 中）
-	}
+// WithCatalog 返回使用另一份清理规则的副本（共用目录大小索引和受保护路径，不发送扫描进度），
+// 用于按计划清理时替换过滤条件
+func (s *CleanService) WithCatalog(cat *catalog.Catalog) *CleanService {
+	copied := *s
+	copied.catalog = cat
+	copied.progress = nil
+	return &copied
 }
 
-// GetSystemFilePaths 获取系统文件清理路径
-func (s *CleanService) GetSystemFilePaths() []string {
-	return []string{
-		`C:\ProgramData\Microsoft\Windows\WER`,
-		filepath.Join(os.Getenv("LOCALAPPDATA"), `Microsoft\Windows\Explorer`),
-		`C:\Windows\Prefetch`,
-		`C:\Windows\Logs`,                                         // 系统日志（包含CBS、DISM、WindowsUpdate等）
-		`C:\Windows\Installer`,                                    // MSI 安装文件（通常几百MB到几GB）
-		`C:\ProgramData\Microsoft\Windows Defender\Scans\History`, // Defender 扫描历史
+// CatalogItems 返回目录中所有清理项（未扫描状态）
+func (s *CleanService) CatalogItems() []*models.CleanItem {
+	items := make([]*models.CleanItem, 0, len(s.catalog.Rules))
+	for i := range s.catalog.Rules {
+		items = append(items, s.catalog.Rules[i].NewItem("idle"))
 	}
+	return items
 }
 
-// GetAppCachePaths 获取应用缓存路径
-func (s *CleanService) GetAppCachePaths() []string {
-	localAppData := os.Getenv("LOCALAPPDATA")
-	userProfile := os.Getenv("USERPROFILE")
-	return []string{
-		filepath.Join(localAppData, "Temp"),
-		filepath.Join(localAppData, "Microsoft", "Windows", "INetCache"),
-		filepath.Join(localAppData, "CrashDumps"),
-		filepath.Join(localAppData, "Microsoft", "Windows", "WebCache"), // IE/Edge WebCache
-		filepath.Join(localAppData, "Microsoft", "Windows", "Caches"),   // Windows 应用缓存
-		filepath.Join(localAppData, "Packages"),                         // UWP 应用缓存（部分）
-		filepath.Join(userProfile, ".gradle", "caches"),                 // Gradle 缓存
-		filepath.Join(userProfile, ".m2", "repository"),                 // Maven 缓存
-		filepath.Join(localAppData, "pip", "cache"),                     // Python pip 缓存
-		filepath.Join(localAppData, "npm-cache"),                        // npm 缓存
-	}
-}
-
-// ScanLogFiles 扫描常见日志目录中的 .log 文件（优化版本）
-func (s *CleanService) ScanLogFiles() (int64, int, []string, error) {
-	var totalSize int64
-	var fileCount int
-
-	// 按目录分组统计
-	dirStats := make(map[string]struct {
-		size  int64
-		count int
-	})
-
-	// 定义常见的日志文件目录（平衡速度和覆盖率）
-	userProfile := os.Getenv("USERPROFILE")
-	localAppData := os.Getenv("LOCALAPPDATA")
-	appData := os.Getenv("APPDATA")
-	programData := os.Getenv("PROGRAMDATA")
+// GetDiskInfo 获取系统盘（Windows 所在分区）信息
+func (s *CleanService) GetDiskInfo() (*models.DiskInfo, error) {
+	return s.GetDriveInfo(fsys.SystemDrive())
+}
 
-	// 分组定义：不同目录使用不同的扫描深度
-	type scanConfig struct {
-		path     string
-		maxDepth int
+// GetDriveInfo 获取指定分区的信息，drive 如 "D:"、"D:\\"
+func (s *CleanService) GetDriveInfo(drive string) (*models.DiskInfo, error) {
+	root, err := fsys.DriveRoot(drive)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.fs.DiskFree(root)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.DiskInfo, err)
 	}
 
-	scanConfigs := []scanConfig{
-		// 用户目录 - 深度扫描（5层）
-		{filepath.Join(localAppData), 5},
-		{filepath.Join(appData), 5},
+	return &models.DiskInfo{
+		Drive: root,
+		Total: usage.Total,
+		Free:  usage.Free,
+		Used:  usage.Total - usage.Free,
+	}, nil
+}
 
-		// 程序数据 - 深度扫描（5层）
-		{programData, 5},
+// maxLogPaths 日志文件清理项最多显示的目录数
+const maxLogPaths = 50
 
-		// 临时目录 - 中度扫描（4层）
-		{filepath.Join(localAppData, "Temp"), 4},
-		{filepath.Join(userProfile, "AppData", "Local", "Temp"), 4},
-		{"C:\\Temp", 4},
-		{"C:\\tmp", 4},
+// skipLogDirs 扫描日志时跳过的目录（明显不包含日志，减少跳过项以提高覆盖率）
+var skipLogDirs = walker.SkipNames("node_modules", ".git", recycleBinPath, "System Volume Information")
 
-		// 用户文档和桌面 - 浅度扫描（2层，避免扫描太多个人文件）
-		{filepath.Join(userProfile, "Desktop"), 2},
-		{filepath.Join(userProfile, "Documents"), 2},
-		{filepath.Join(userProfile, "Downloads"), 2},
+// ScanLogFiles 扫描日志根目录中的 .log 文件（每个根目录使用各自的扫描深度），
+// 返回总大小、文件数和包含日志文件的目录（按大小从大到小，最多 maxLogPaths 个）。
+// ctx 取消时返回已扫描部分的结果
+func (s *CleanService) ScanLogFiles(ctx context.Context, roots []catalog.ResolvedPath) (int64, int, []models.PathDetail, error) {
+	return s.scanLogFiles(ctx, roots, nil, nil)
+}
 
-		// 常见软件安装目录 - 中度扫描（4层）
-		{"C:\\Program Files", 4},
-		{"C:\\Program Files (x86)", 4},
+// scanLogFiles 扫描日志文件并更新扫描进度，受保护的文件计入 item 的 ExcludedSize（item 可以为空）
+func (s *CleanService) scanLogFiles(ctx context.Context, roots []catalog.ResolvedPath, tracker *scanTracker, item *models.CleanItem) (int64, int, []models.PathDetail, error) {
+	var totalSize int64
+	var fileCount int
 
-		// 用户根目录下的常见位置 - 浅度扫描（3层）
-		{filepath.Join(userProfile, ".config"), 3},
-		{filepath.Join(userProfile, ".cache"), 3},
-		{filepath.Join(userProfile, ".local"), 3},
-	}
+	// 按目录分组统计（只统计目录中直接包含的日志文件）
+	dirStats := make(map[string]*walker.DirStats)
 
 	// 扫描每个目录
-	for _, config := range scanConfigs {
-		if _, err := os.Stat(config.path); os.IsNotExist(err) {
+	for _, root := range roots {
+		result, err := walker.Walk(ctx, s.fs, root.Path, walker.Options{
+			MaxDepth: root.MaxDepth + 1, // 规则中的深度不含根目录本身
+			SkipDir:  skipLogDirs,
+			Match: func(path string, _ os.FileInfo) bool {
+				return filepath.Ext(path) == ".log"
+			},
+			Exclude: s.exclude(),
+			PerDir:  true,
+			OnDir:   tracker.onDir(),
+		})
+		if err != nil && ctx.Err() == nil {
 			continue // 目录不存在，跳过
 		}
+		addExcluded(item, result.ExcludedSize, result.ExcludedFiles)
 
-		s.scanLogFilesInDir(config.path, 0, config.maxDepth, dirStats, &totalSize, &fileCount)
-	}
-
-	// 提取有日志文件的目录路径并按大小排序
-	type dirInfo struct {
-		path  string
-		size  int64
-		count int
+		totalSize += result.Size
+		fileCount += result.Files
+		for dir, stats := range result.PerDir {
+			if existing, ok := dirStats[dir]; ok && existing.Files >= stats.Files {
+				continue // 根目录重叠时同一目录只统计一次
+			}
+			dirStats[dir] = stats
+		}
 	}
 
-	var sortedDirs []dirInfo
+	var logPaths []models.PathDetail
 	for dir, stats := range dirStats {
-		sortedDirs = append(sortedDirs, dirInfo{
-			path:  dir,
-			size:  stats.size,
-			count: stats.count,
+		logPaths = append(logPaths, models.PathDetail{
+			Path:      dir,
+			Size:      stats.Size,
+			FileCount: stats.Files,
 		})
 	}
 
 	// 按大小排序
-	sort.Slice(sortedDirs, func(i, j int) bool {
-		return sortedDirs[i].size > sortedDirs[j].size
+	sort.Slice(logPaths, func(i, j int) bool {
+		return logPaths[i].Size > logPaths[j].Size
 	})
 
-	// 提取路径（限制数量）
-	var logPaths []string
-	maxPaths := 50
-	for _, dir := range sortedDirs {
-		if len(logPaths) >= maxPaths {
-			break
-		}
-		logPaths = append(logPaths, dir.path)
+	// 限制数量
+	if len(logPaths) > maxLogPaths {
+		logPaths = logPaths[:maxLogPaths]
 	}
 
 	return totalSize, fileCount, logPaths, nil
 }
 
-// scanLogFilesInDir 递归扫描目录中的日志文件（带深度限制）
-func (s *CleanService) scanLogFilesInDir(dir string, currentDepth, maxDepth int, dirStats map[string]struct {
-	size  int64
-	count int
-}, totalSize *int64, fileCount *int) {
-	if currentDepth > maxDepth {
-		return
-	}
-
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return // 无权限或其他错误，跳过
+// CleanLogFilesInPath 清理指定路径下的所有 .log 文件（run 不为空时移入隔离区），
+// 每个文件的结果记录到 report，返回实际删除的大小和文件数（ctx 取消时停止）
+func (s *CleanService) CleanLogFilesInPath(ctx context.Context, dirPath, itemID string, run *quarantine.Run, report *models.CleanReport) (int64, int) {
+	var cleanedSize int64
+	var cleanedCount int
+	tracker := CleanTrackerFrom(ctx)
+	if s.skipProtectedRoot(report, dirPath, itemID) {
+		return 0, 0
 	}
 
-	for _, entry := range entries {
-		fullPath := filepath.Join(dir, entry.Name())
-
-		if entry.IsDir() {
-			// 跳过一些明显不包含日志的目录（减少跳过项，提高覆盖率）
-			name := entry.Name()
-			if name == "node_modules" || name == ".git" || name == "$Recycle.Bin" ||
-				name == "System Volume Information" {
-				continue
-			}
-
-			// 递归扫描子目录
-			s.scanLogFilesInDir(fullPath, currentDepth+1, maxDepth, dirStats, totalSize, fileCount)
-		} else if filepath.Ext(entry.Name()) == ".log" {
-			// 找到日志文件
-			info, err := entry.Info()
-			if err != nil {
-				continue
-			}
-
-			size := info.Size()
-			*totalSize += size
-			*fileCount++
-
-			// 按父目录分组
-			parentDir := filepath.Dir(fullPath)
-			stats := dirStats[parentDir]
-			stats.size += size
-			stats.count++
-			dirStats[parentDir] = stats
+	s.fs.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
-	}
-}
-
-// calculateDirLogSize 计算指定目录下的日志文件大小
-func (s *CleanService) calculateDirLogSize(dirPath string) (int64, int, error) {
-	var size int64
-	var count int
-
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			return nil
+			return nil // 跳过无权限的文件
 		}
-
-		// 只统计当前目录，不递归子目录
-		if info.IsDir() && path != dirPath {
-			return filepath.SkipDir
+		if path != dirPath && (info.IsDir() || filepath.Ext(path) == ".log") {
+			if skip, walkErr := s.SkipProtected(report, dirPath, itemID, path, info); skip {
+				return walkErr
+			}
 		}
 
-		// 只处理 .log 文件
 		if !info.IsDir() && filepath.Ext(path) == ".log" {
-			size += info.Size()
-			count++
+			err := quarantine.Remove(s.fs, run, path)
+			RecordRemoval(report, dirPath, itemID, path, info, err)
+			if err == nil {
+				cleanedSize += info.Size()
+				cleanedCount++
+				tracker.Add(info.Size(), 1)
+			}
 		}
-
 		return nil
 	})
 
-	return size, count, err
+	return cleanedSize, cleanedCount
 }
 
-// CleanLogFiles 清理常见日志目录中的 .log 文件（优化版本）
-func (s *CleanService) CleanLogFiles() (int64, int, error) {
-	var cleanedSize int64
-	var cleanedCount int
-
-	// 定义常见的日志文件目录（与扫描逻辑一致）
-	userProfile := os.Getenv("USERPROFILE")
-	localAppData := os.Getenv("LOCALAPPDATA")
-	appData := os.Getenv("APPDATA")
-	programData := os.Getenv("PROGRAMDATA")
-
-	type cleanConfig struct {
-		path     string
-		maxDepth int
-	}
-
-	cleanConfigs := []cleanConfig{
-		{filepath.Join(localAppData), 5},
-		{filepath.Join(appData), 5},
-		{programData, 5},
-		{filepath.Join(localAppData, "Temp"), 4},
-		{filepath.Join(userProfile, "AppData", "Local", "Temp"), 4},
-		{"C:\\Temp", 4},
-		{"C:\\tmp", 4},
-		{filepath.Join(userProfile, "Desktop"), 2},
-		{filepath.Join(userProfile, "Documents"), 2},
-		{filepath.Join(userProfile, "Downloads"), 2},
-		{"C:\\Program Files", 4},
-		{"C:\\Program Files (x86)", 4},
-		{filepath.Join(userProfile, ".config"), 3},
-		{filepath.Join(userProfile, ".cache"), 3},
-		{filepath.Join(userProfile, ".local"), 3},
-	}
-
-	// 清理每个目录
-	for _, config := range cleanConfigs {
-		if _, err := os.Stat(config.path); os.IsNotExist(err) {
-			continue
-		}
-
-		s.cleanLogFilesInDir(config.path, 0, config.maxDepth, &cleanedSize, &cleanedCount)
-	}
+// CalculateFolderSize 计算文件夹大小
+func (s *CleanService) CalculateFolderSize(ctx context.Context, path string) (int64, error) {
+	result, err := walker.Walk(ctx, s.fs, path, walker.Options{})
+	return result.Size, err
+}
 
-	return cleanedSize, cleanedCount, nil
+// CalculateFolderDetails 计算文件夹详细信息（大小、文件数、文件夹数），
+// filter 不为空时只统计符合过滤条件、实际会被清理的内容。ctx 取消时返回已统计部分和 ctx 的错误
+func (s *CleanService) CalculateFolderDetails(ctx context.Context, path string, filter *catalog.Filter) (int64, int, int, error) {
+	return s.folderDetails(ctx, path, filter, nil, nil)
 }
 
-// cleanLogFilesInDir 递归清理目录中的日志文件（带深度限制）
-func (s *CleanService) cleanLogFilesInDir(dir string, currentDepth, maxDepth int, cleanedSize *int64, cleanedCount *int) {
-	if currentDepth > maxDepth {
-		return
+// folderDetails 计算文件夹详细信息并更新扫描进度，受保护的内容计入 item 的 ExcludedSize（item 可以为空）
+func (s *CleanService) folderDetails(ctx context.Context, path string, filter *catalog.Filter, tracker *scanTracker, item *models.CleanItem) (int64, int, int, error) {
+	if filter != nil {
+		return s.calculateFilteredDetails(ctx, path, filter, tracker, item)
 	}
 
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return
+	// 检查路径是否存在
+	if _, err := s.fs.Stat(path); os.IsNotExist(err) {
+		return 0, 0, 0, nil // 路径不存在，返回0
 	}
 
-	for _, entry := range entries {
-		fullPath := filepath.Join(dir, entry.Name())
+	result, err := walker.Walk(ctx, s.fs, path, walker.Options{
+		Index:   s.index,
+		Profile: walker.ProfileSize,
+		Exclude: s.exclude(),
+		OnDir:   tracker.onDir(),
+	})
+	addExcluded(item, result.ExcludedSize, result.ExcludedFiles)
 
-		if entry.IsDir() {
-			name := entry.Name()
-			if name == "node_modules" || name == ".git" || name == "$Recycle.Bin" ||
-				name == "System Volume Information" {
-				continue
-			}
+	// 记录跳过的文件信息（用于调试，写到标准错误，不影响命令行的 --json 输出）
+	if result.Errors > 0 {
+		fmt.Fprintf(os.Stderr, "Scan %s: files=%d, folders=%d, size=%d bytes, skipped=%d (access denied=%d)\n",
+			path, result.Files, result.Folders, result.Size, result.Errors, result.Denied)
+	}
 
-			s.cleanLogFilesInDir(fullPath, currentDepth+1, maxDepth, cleanedSize, cleanedCount)
-		} else if filepath.Ext(entry.Name()) == ".log" {
-			info, err := entry.Info()
-			if err != nil {
-				continue
-			}
+	return result.Size, result.Files, result.Folders, err
+}
 
-			size := info.Size()
-			if err := os.Remove(fullPath); err == nil {
-				*cleanedSize += size
-				*cleanedCount++
-			}
+// calculateFilteredDetails 按过滤条件统计文件夹内容
+func (s *CleanService) calculateFilteredDetails(ctx context.Context, path string, filter *catalog.Filter, tracker *scanTracker, item *models.CleanItem) (int64, int, int, error) {
+	if _, err := s.fs.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, 0, nil
 		}
+		return 0, 0, 0, err
 	}
-}
 
-// CalculateFolderSize 计算文件夹大小
-func (s *CleanService) CalculateFolderSize(path string) (int64, error) {
 	var size int64
-
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // 忽略错误，继续
-		}
-		if !info.IsDir() {
-			size += info.Size()
+	var fileCount, folderCount int
+	err := filter.Walk(s.fs, path, func(filePath string, info os.FileInfo) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
-		return nil
-	})
-
-	return size, err
-}
-
-// CalculateFolderDetails 计算文件夹详细信息（大小、文件数、文件夹数）
-func (s *CleanService) CalculateFolderDetails(path string) (int64, int, int, error) {
-	var size int64
-	var fileCount int
-	var folderCount int
-	var skippedCount int
-	var accessDeniedCount int
-
-	// 检查路径是否存在
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return 0, 0, 0, nil // 路径不存在，返回0
-	}
-
-	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			skippedCount++
-			// 检查是否是权限错误
-			if os.IsPermission(err) {
-				accessDeniedCount++
+		if s.protect.Protected(filePath) {
+			if !info.IsDir() {
+				addExcluded(item, info.Size(), 1)
 			}
-			// 如果是目录访问错误，跳过整个目录
-			if info != nil && info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil // 跳过单个文件错误，继续
-		}
-
-		// 跳过符号链接，避免循环
-		if info.Mode()&os.ModeSymlink != 0 {
 			return nil
 		}
-
 		if info.IsDir() {
-			// 统计文件夹（排除根目录本身）
-			if filePath != path {
-				folderCount++
-			}
+			folderCount++
 		} else {
-			// 统计文件
-			fileSize := info.Size()
-			if fileSize >= 0 {
-				size += fileSize
-				fileCount++
-			}
+			size += info.Size()
+			fileCount++
+			tracker.file(filePath, info.Size())
 		}
-
 		return nil
 	})
-
-	// 记录跳过的文件信息（用于调试）
-	if skippedCount > 0 {
-		fmt.Printf("Scan %s: files=%d, folders=%d, size=%d bytes, skipped=%d (access denied=%d)\n",
-			path, fileCount, folderCount, size, skippedCount, accessDeniedCount)
-	}
-
 	return size, fileCount, folderCount, err
 }
 
-// ScanCleanItems 扫描所有清理项（并行扫描）
-func (s *CleanService) ScanCleanItems() ([]*models.CleanItem, error) {
-	items := []*models.CleanItem{
-		{ID: "1", Name: "系统临时文件", Checked: true, Safe: true, Status: "idle"},
-		{ID: "2", Name: "浏览器缓存", Checked: true, Safe: true, Status: "idle"},
-		{ID: "3", Name: "回收站", Checked: true, Safe: true, Status: "idle"},
-		{ID: "4", Name: "Windows更新缓存", Checked: true, Safe: true, Status: "idle"},
-		{ID: "5", Name: "系统文件清理", Checked: true, Safe: true, Status: "idle"},
-		{ID: "6", Name: "应用缓存", Checked: false, Safe: false, Status: "idle"},
-		{ID: "7", Name: "应用日志文件", Checked: false, Safe: false, Status: "idle"},
+// addExcluded 累加清理项中受保护的内容
+func addExcluded(item *models.CleanItem, size int64, count int) {
+	if item == nil {
+		return
 	}
+	item.ExcludedSize += size
+	item.ExcludedCount += count
+}
+
+// ScanCleanItems 扫描所有清理项（并行扫描）。
+// ctx 取消时未扫描完的清理项状态为 "cancelled"，大小为已扫描部分
+func (s *CleanService) ScanCleanItems(ctx context.Context) ([]*models.CleanItem, error) {
+	items := s.CatalogItems()
+	tracker := newScanTracker(s.progress, ScanClean, len(items))
+	defer tracker.finish()
 
 	// 使用 goroutine 并行扫描
 	var wg sync.WaitGroup
@@ -511,7 +315,8 @@ func (s *CleanService) ScanCleanItems() ([]*models.CleanItem, error) {
 		wg.Add(1)
 		go func(index int) {
 			defer wg.Done()
-			s.ScanSingleItem(items[index])
+			s.scanItem(ctx, items[index], tracker)
+			tracker.done()
 		}(i)
 	}
 	wg.Wait()
@@ -520,79 +325,64 @@ func (s *CleanService) ScanCleanItems() ([]*models.CleanItem, error) {
 }
 
 // ScanSingleItem 扫描单个清理项（导出方法）
-func (s *CleanService) ScanSingleItem(item *models.CleanItem) {
-	item.Status = "scanning"
-
-	switch item.ID {
-	case "1": // 系统临时文件
-		paths := []string{
-			s.GetTempPath(),
-			s.GetSystemTempPath(),
-		}
-		s.scanPaths(item, paths)
-
-	case "2": // 浏览器缓存
-		paths := s.GetBrowserCachePaths()
-		s.scanPaths(item, paths)
-
-	case "3": // 回收站
-		paths := []string{s.GetRecycleBinPath()}
-		s.scanPaths(item, paths)
-
-	case "4": // Windows更新缓存
-		paths := s.GetWindowsUpdateCachePaths()
-		s.scanPaths(item, paths)
+func (s *CleanService) ScanSingleItem(ctx context.Context, item *models.CleanItem) {
+	tracker := newScanTracker(s.progress, ScanClean, 1)
+	defer tracker.finish()
+	s.scanItem(ctx, item, tracker)
+	tracker.done()
+}
 
-	case "5": // 系统文件清理
-		paths := s.GetSystemFilePaths()
-		s.scanPaths(item, paths)
+// scanItem 扫描单个清理项并更新扫描进度
+func (s *CleanService) scanItem(ctx context.Context, item *models.CleanItem, tracker *scanTracker) {
+	tracker.item(item.Name)
+	rule, ok := s.catalog.Rule(item.ID)
+	if !ok {
+		item.Status = "error"
+		item.Error = apperr.New(apperr.UnknownItem, "id", item.ID).Error()
+		return
+	}
 
-	case "6": // 应用缓存
-		paths := s.GetAppCachePaths()
-		s.scanPaths(item, paths)
+	item.Status = "scanning"
+	item.ExcludedSize, item.ExcludedCount = 0, 0
+	roots := rule.ResolvePaths(s.fs)
+
+	switch rule.Handler {
+	case catalog.HandlerFolder, catalog.HandlerRecycleBin:
+		var paths []string
+		for _, root := range roots {
+			paths = append(paths, root.Path)
+		}
+		s.scanPaths(ctx, item, paths, s.catalog.PathFilter(item.ID), tracker)
 
-	case "7": // 应用日志文件
-		size, fileCount, logPaths, err := s.ScanLogFiles()
+	case catalog.HandlerLogFiles:
+		size, fileCount, logPaths, err := s.scanLogFiles(ctx, roots, tracker, item)
 		if err == nil {
 			item.Size = size
 			item.FileCount = fileCount
-
-			// 添加路径详情（显示包含日志文件的目录）
-			var pathDetails []models.PathDetail
-			for _, path := range logPaths {
-				// 计算该目录下的日志文件大小
-				dirSize, dirCount, _ := s.calculateDirLogSize(path)
-				if dirSize > 0 {
-					pathDetails = append(pathDetails, models.PathDetail{
-						Path:      path,
-						Size:      dirSize,
-						FileCount: dirCount,
-					})
-				}
-			}
-
-			// 按大小从大到小排序
-			sort.Slice(pathDetails, func(i, j int) bool {
-				return pathDetails[i].Size > pathDetails[j].Size
-			})
-
-			item.Paths = pathDetails
+			item.Paths = logPaths // 包含日志文件的目录
 		}
 	}
 
+	if ops.Cancelled(ctx) {
+		item.Status = ops.StatusCancelled
+		return
+	}
 	item.Status = "scanned"
 }
 
-// scanPaths 扫描多个路径并汇总结果
-func (s *CleanService) scanPaths(item *models.CleanItem, paths []string) {
+// scanPaths 扫描多个路径并汇总结果（ctx 取消时保留已扫描部分）
+func (s *CleanService) scanPaths(ctx context.Context, item *models.CleanItem, paths []string, filter *catalog.Filter, tracker *scanTracker) {
 	var totalSize int64
 	var totalFiles int
 	var pathDetails []models.PathDetail
 
 	for _, path := range paths {
-		size, fileCount, folderCount, err := s.CalculateFolderDetails(path)
-		// 只要路径存在（err == nil），就记录，即使大小为0
-		if err == nil {
+		if ctx.Err() != nil {
+			break
+		}
+		size, fileCount, folderCount, err := s.folderDetails(ctx, path, filter, tracker, item)
+		// 只要路径存在（err == nil），就记录，即使大小为0；被取消时记录已扫描部分
+		if err == nil || err == ctx.Err() {
 			totalSize += size
 			totalFiles += fileCount
 			// 只记录有内容的路径到详情中
@@ -612,16 +402,39 @@ func (s *CleanService) scanPaths(item *models.CleanItem, paths []string) {
 	item.Paths = pathDetails
 }
 
+// SkipProtected 受保护的文件或目录记录到清理报告并跳过（遍历回调中使用）：
+// 返回 true 时调用方跳过该路径，目录同时返回 filepath.SkipDir
+func (s *CleanService) SkipProtected(report *models.CleanReport, root, itemID, path string, info os.FileInfo) (bool, error) {
+	if !s.protect.Protected(path) {
+		return false, nil
+	}
+	if info.IsDir() {
+		RecordSkipped(report, root, itemID, path, 0, models.SkipExcluded, nil)
+		return true, filepath.SkipDir
+	}
+	RecordSkipped(report, root, itemID, path, info.Size(), models.SkipExcluded, nil)
+	return true, nil
+}
+
+// skipProtectedRoot 清理路径本身受保护时记录到清理报告，返回 true 表示整个路径跳过
+func (s *CleanService) skipProtectedRoot(report *models.CleanReport, root, itemID string) bool {
+	if !s.protect.Protected(root) {
+		return false
+	}
+	RecordSkipped(report, root, itemID, root, 0, models.SkipExcluded, nil)
+	return true
+}
+
 // CleanFolder 清理文件夹（保留文件夹本身，只删除内容）
 func (s *CleanService) CleanFolder(path string) error {
 	// 检查路径是否存在
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	if _, err := s.fs.Stat(path); os.IsNotExist(err) {
 		return nil // 路径不存在，跳过
 	}
 
-	entries, err := os.ReadDir(path)
+	entries, err := s.fs.ReadDir(path)
 	if err != nil {
-		return fmt.Errorf("无法读取目录 %s: %v", path, err)
+		return apperr.Wrap(apperr.ReadFailed, err, "file", path)
 	}
 
 	var lastError error
@@ -629,7 +442,7 @@ func (s *CleanService) CleanFolder(path string) error {
 		fullPath := filepath.Join(path, entry.Name())
 
 		// 尝试删除，忽略单个文件的错误
-		err := os.RemoveAll(fullPath)
+		err := s.fs.RemoveAll(fullPath)
 		if err != nil {
 			lastError = err
 			// 继续删除其他文件
@@ -639,126 +452,133 @@ func (s *CleanService) CleanFolder(path string) error {
 	return lastError
 }
 
-// CleanFolderSafe 安全清理文件夹（跳过正在使用的文件）
-func (s *CleanService) CleanFolderSafe(path string) (int64, error) {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return 0, nil
-	}
-
-	var cleaned int64
-	entries, err := os.ReadDir(path)
-	if err != nil {
+// CleanFolderSafe 安全清理文件夹（跳过正在使用的文件，保留文件夹本身）。
+// 按清理项的过滤条件逐个删除文件，run 不为空时移入隔离区，每个文件的结果记录到 report
+func (s *CleanService) CleanFolderSafe(ctx context.Context, path, itemID string, run *quarantine.Run, report *models.CleanReport) (int64, error) {
+	if _, err := s.fs.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
 		return 0, err
 	}
 
-	for _, entry := range entries {
-		fullPath := filepath.Join(path, entry.Name())
+	cleaned, _ := s.RemoveFiltered(ctx, path, itemID, run, report)
+	return cleaned, ctx.Err()
+}
 
-		// 获取文件/文件夹大小
-		info, err := os.Stat(fullPath)
-		if err != nil {
-			continue
-		}
+// RemoveFiltered 逐个删除 root 下符合清理项过滤条件的文件（run 不为空时移入隔离区），
+// 再从最深处删除已清空的目录；root 本身保留。返回实际删除的大小和文件数（ctx 取消时停止）
+func (s *CleanService) RemoveFiltered(ctx context.Context, root, itemID string, run *quarantine.Run, report *models.CleanReport) (int64, int) {
+	var removed int64
+	var count int
+	var dirs []string
+	tracker := CleanTrackerFrom(ctx)
+	if s.skipProtectedRoot(report, root, itemID) {
+		return 0, 0
+	}
 
-		var itemSize int64
+	s.catalog.PathFilter(itemID).Walk(s.fs, root, func(path string, info os.FileInfo) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if skip, walkErr := s.SkipProtected(report, root, itemID, path, info); skip {
+			return walkErr
+		}
 		if info.IsDir() {
-			itemSize, _ = s.CalculateFolderSize(fullPath)
-		} else {
-			itemSize = info.Size()
+			dirs = append(dirs, path)
+			return nil
 		}
-
-		// 尝试删除
-		err = os.RemoveAll(fullPath)
+		err := quarantine.Remove(s.fs, run, path)
+		RecordRemoval(report, root, itemID, path, info, err)
 		if err == nil {
-			cleaned += itemSize
+			removed += info.Size()
+			count++
+			tracker.Add(info.Size(), 1)
 		}
-	}
-
-	return cleaned, nil
-}
-
-// EmptyRecycleBin 清空回收站
-func (s *CleanService) EmptyRecycleBin() error {
-	shell32 := syscall.NewLazyDLL("shell32.dll")
-	emptyRecycleBin := shell32.NewProc("SHEmptyRecycleBinW")
-
-	ret, _, _ := emptyRecycleBin.Call(
-		0,
-		0,
-		0x00000001|0x00000002, // SHERB_NOCONFIRMATION | SHERB_NOPROGRESSUI
-	)
-
-	// HRESULT 返回值：
-	// S_OK (0x00000000) = 成功清空
-	// S_FALSE (0x00000001) = 回收站已经是空的
-	if ret == 0 || ret == 1 {
 		return nil
-	}
+	})
 
-	// 解析常见错误
-	var errMsg string
-	switch ret {
-	case 0x80070005: // E_ACCESSDENIED
-		errMsg = "权限不足，请以管理员身份运行"
-	case 0x8000FFFF: // E_UNEXPECTED
-		errMsg = "回收站被占用或系统状态异常，请关闭资源管理器中的回收站窗口后重试"
-	case 0x80004005: // E_FAIL
-		errMsg = "操作失败"
-	default:
-		errMsg = fmt.Sprintf("未知错误 (HRESULT: 0x%X)", ret)
+	// 非空目录（有文件未删除或被过滤）会删除失败并保留
+	for i := len(dirs) - 1; i >= 0; i-- {
+		s.fs.Remove(dirs[i])
 	}
-
-	return fmt.Errorf("%s", errMsg)
+	return removed, count
 }
 
-// OpenFolder 使用资源管理器打开文件夹
-func (s *CleanService) OpenFolder(path string) error {
-	// 使用 Windows API 展开环境变量
-	kernel32 := syscall.NewLazyDLL("kernel32.dll")
-	expandEnvironmentStrings := kernel32.NewProc("ExpandEnvironmentStringsW")
-
-	pathPtr, _ := syscall.UTF16PtrFromString(path)
-	buffer := make([]uint16, 32768) // MAX_PATH 的扩展版本
-
-	ret, _, _ := expandEnvironmentStrings.Call(
-		uintptr(unsafe.Pointer(pathPtr)),
-		uintptr(unsafe.Pointer(&buffer[0])),
-		uintptr(len(buffer)),
-	)
-
-	if ret == 0 {
-		return fmt.Errorf("无法展开环境变量: %s", path)
-	}
-
-	expandedPath := syscall.UTF16ToString(buffer)
-
-	// 检查路径是否存在
-	if _, err := os.Stat(expandedPath); os.IsNotExist(err) {
-		return fmt.Errorf("路径不存在: %s", expandedPath)
-	}
-
-	// 使用 explorer 打开文件夹
-	shell32 := syscall.NewLazyDLL("shell32.dll")
-	shellExecute := shell32.NewProc("ShellExecuteW")
-
-	operation, _ := syscall.UTF16PtrFromString("open")
-	file, _ := syscall.UTF16PtrFromString("explorer.exe")
-	params, _ := syscall.UTF16PtrFromString(expandedPath)
+// CleanItem 按清理项的处理方式清理扫描结果中的路径（回收站、日志文件或按目录清理），
+// 结果记录到 report，清理项的状态设为 completed、error 或 cancelled。不检查是否需要管理员权限。
+// 清理项无法执行（未知的清理项、清空回收站失败）时返回错误，部分文件删除失败记录在 report 中
+func (s *CleanService) CleanItem(ctx context.Context, item *models.CleanItem, run *quarantine.Run, report *models.CleanReport) error {
+	rule, ok := s.catalog.Rule(item.ID)
+	if !ok {
+		err := apperr.New(apperr.UnknownItem, "id", item.ID)
+		item.Status = "error"
+		item.Error = err.Error()
+		return err
+	}
+	tracker := CleanTrackerFrom(ctx)
+
+	switch rule.Handler {
+	case catalog.HandlerRecycleBin:
+		// 回收站使用特殊API
+		tracker.BeginPath(item.ID, item.Name)
+		freedBefore, removedBefore := report.FreedSize, report.RemovedCount
+		err := s.CleanRecycleBin(item.ID, report)
+		tracker.Add(report.FreedSize-freedBefore, report.RemovedCount-removedBefore)
+		tracker.EndPath()
+		if err != nil {
+			item.Status = "error"
+			if !errors.Is(err, apperr.New(apperr.RecycleBinFailed)) {
+				err = apperr.Wrap(apperr.RecycleBinFailed, err)
+			}
+			item.Error = err.Error()
+			return err
+		}
+		item.Status = "completed"
+
+	case catalog.HandlerLogFiles:
+		// 日志文件：使用扫描结果中的路径
+		var totalCleaned int64
+		var totalCount int
+		for _, pathDetail := range item.Paths {
+			tracker.BeginPath(item.ID, pathDetail.Path)
+			cleaned, count := s.CleanLogFilesInPath(ctx, pathDetail.Path, item.ID, run, report)
+			tracker.EndPath()
+			totalCleaned += cleaned
+			totalCount += count
+		}
+		item.Size = totalCleaned
+		item.FileCount = totalCount
+		item.Status = "completed"
+		if ctx.Err() != nil {
+			item.Status = ops.StatusCancelled
+		}
 
-	ret2, _, _ := shellExecute.Call(
-		0,
-		uintptr(unsafe.Pointer(operation)),
-		uintptr(unsafe.Pointer(file)),
-		uintptr(unsafe.Pointer(params)),
-		0,
-		1, // SW_SHOWNORMAL
-	)
+	default:
+		// 按目录清理扫描结果中的路径
+		hasError := false
+		skippedBefore := report.SkippedCount
+		for _, pathDetail := range item.Paths {
+			tracker.BeginPath(item.ID, pathDetail.Path)
+			_, err := s.CleanFolderSafe(ctx, pathDetail.Path, item.ID, run, report)
+			tracker.EndPath()
+			if ctx.Err() != nil {
+				break
+			}
+			if err != nil {
+				hasError = true
+			}
+		}
 
-	// ShellExecute 返回值 > 32 表示成功
-	if ret2 <= 32 {
-		return fmt.Errorf("无法打开文件夹: %s", expandedPath)
+		if ctx.Err() != nil {
+			item.Status = ops.StatusCancelled
+		} else if hasError || report.SkippedCount > skippedBefore {
+			item.Status = "error"
+			item.Error = apperr.New(apperr.PartialClean).Error()
+		} else {
+			item.Status = "completed"
+		}
 	}
-
 	return nil
 }
 
@@ -768,23 +588,23 @@ func (s *CleanService) ScanDesktop(desktopPath string) ([]*models.DesktopFileInf
 	if desktopPath == "" {
 		userProfile := os.Getenv("USERPROFILE")
 		if userProfile == "" {
-			return nil, fmt.Errorf("无法获取用户配置文件路径")
+			return nil, apperr.New(apperr.UserProfile)
 		}
 		desktopPath = filepath.Join(userProfile, "Desktop")
 
 		// 检查路径是否存在，如果不存在尝试其他可能的位置
-		if _, err := os.Stat(desktopPath); os.IsNotExist(err) {
+		if _, err := s.fs.Stat(desktopPath); os.IsNotExist(err) {
 			// 尝试公共桌面路径
 			publicProfile := os.Getenv("PUBLIC")
 			if publicProfile != "" {
 				publicDesktop := filepath.Join(publicProfile, "Desktop")
-				if _, err := os.Stat(publicDesktop); err == nil {
+				if _, err := s.fs.Stat(publicDesktop); err == nil {
 					desktopPath = publicDesktop
 				}
 			}
 
 			// 如果仍然不存在，尝试获取当前用户的真实桌面路径
-			if _, err := os.Stat(desktopPath); os.IsNotExist(err) {
+			if _, err := s.fs.Stat(desktopPath); os.IsNotExist(err) {
 				// 使用 Windows API 获取桌面路径（简化实现）
 				desktopPath = s.getDesktopPath()
 			}
@@ -792,14 +612,14 @@ func (s *CleanService) ScanDesktop(desktopPath string) ([]*models.DesktopFileInf
 	}
 
 	// 检查路径是否存在
-	if _, err := os.Stat(desktopPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("桌面路径不存在: %s", desktopPath)
+	if _, err := s.fs.Stat(desktopPath); os.IsNotExist(err) {
+		return nil, apperr.New(apperr.PathNotFound, "path", desktopPath)
 	}
 
 	// 读取桌面目录内容
-	entries, err := os.ReadDir(desktopPath)
+	entries, err := s.fs.ReadDir(desktopPath)
 	if err != nil {
-		return nil, fmt.Errorf("无法读取桌面目录: %v", err)
+		return nil, apperr.Wrap(apperr.ReadFailed, err, "file", desktopPath)
 	}
 
 	var files []*models.DesktopFileInfo
@@ -846,28 +666,86 @@ func (s *CleanService) getDesktopPath() string {
 		return desktopPath
 	}
 	// 兜底方案
-	return "C:\\Users\\Default\\Desktop"
+	return filepath.Join(fsys.SystemDrive(), "Users", "Default", "Desktop")
 }
 
 // DeleteDesktopFile 删除桌面文件
 func (s *CleanService) DeleteDesktopFile(filePath string) error {
 	// 检查文件是否存在
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("文件不存在: %s", filePath)
+	info, err := s.fs.Stat(filePath)
+	if os.IsNotExist(err) {
+		return apperr.New(apperr.PathNotFound, "path", filePath)
+	}
+
+	// 文件夹中包含受保护的内容时整体不删除
+	if err == nil && s.containsProtected(filePath, info) {
+		return apperr.Wrap(apperr.Protected, nil, "path", filePath)
 	}
 
 	// 删除文件或文件夹
-	err := os.RemoveAll(filePath)
+	err = s.fs.RemoveAll(filePath)
 	if err != nil {
-		return fmt.Errorf("删除失败: %v", err)
+		return apperr.Wrap(apperr.DeleteFailed, err, "path", filePath)
 	}
 
 	return nil
 }
 
+// containsProtected 判断路径本身或其中的任何内容是否受保护
+func (s *CleanService) containsProtected(path string, info os.FileInfo) bool {
+	if s.protect.Empty() {
+		return false
+	}
+	if s.protect.Protected(path) {
+		return true
+	}
+	if !info.IsDir() {
+		return false
+	}
+	found := false
+	s.fs.Walk(path, func(p string, _ os.FileInfo, err error) error {
+		if err == nil && s.protect.Protected(p) {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
 // SelectFolder 使用系统对话框选择文件夹
 func (s *CleanService) SelectFolder() (string, error) {
 	// 这里使用简单的实现，实际项目中可能需要使用 Windows API 或第三方库
 	// 返回当前用户的桌面路径
 	return s.getDesktopPath(), nil
 }
+
+// recycleBinPath 清理报告中回收站使用的路径
+const recycleBinPath = "$Recycle.Bin"
+
+// CleanRecycleBin 清空回收站，按清空前后的回收站大小记录实际释放的空间
+func (s *CleanService) CleanRecycleBin(itemID string, report *models.CleanReport) error {
+	beforeSize, beforeCount, queryErr := s.QueryRecycleBin()
+	if err := s.EmptyRecycleBin(); err != nil {
+		return err
+	}
+	if queryErr != nil || report == nil {
+		return nil
+	}
+
+	afterSize, afterCount, err := s.QueryRecycleBin()
+	if err != nil {
+		return nil
+	}
+
+	p := pathReport(report, recycleBinPath, itemID)
+	p.FreedSize += beforeSize - afterSize
+	p.RemovedCount += int(beforeCount - afterCount)
+	report.FreedSize += beforeSize - afterSize
+	report.RemovedCount += int(beforeCount - afterCount)
+	if afterCount > 0 {
+		// 回收站中仍有未能删除的项目
+		RecordSkipped(report, recycleBinPath, itemID, recycleBinPath, afterSize, models.SkipFailed, nil)
+	}
+	return nil
+}
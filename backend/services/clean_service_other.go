@@ -0,0 +1,28 @@
+//go:build !windows
+
+package services
+
+import "ccooler/backend/apperr"
+
+// errNotSupported 非 Windows 平台不支持的操作
+var errNotSupported = apperr.New(apperr.NotSupported)
+
+// EmptyRecycleBin 清空回收站（仅支持 Windows）
+func (s *CleanService) EmptyRecycleBin() error {
+	return errNotSupported
+}
+
+// OpenFolder 使用资源管理器打开文件夹（仅支持 Windows）
+func (s *CleanService) OpenFolder(path string) error {
+	return errNotSupported
+}
+
+// isInUseError 判断错误是否由文件被占用引起（仅 Windows 可区分）
+func isInUseError(err error) bool {
+	return false
+}
+
+// QueryRecycleBin 查询回收站大小和项目数（仅支持 Windows）
+func (s *CleanService) QueryRecycleBin() (int64, int64, error) {
+	return 0, 0, errNotSupported
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"ccooler/backend/catalog"
+	"ccooler/backend/fsys"
+	"ccooler/backend/models"
+	"ccooler/backend/protect"
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testCatalog temp 按过滤条件清理（早于 1 天、跳过 keep 目录），logs 清理日志文件
+const testCatalog = `{
+	"version": 1,
+	"rules": [
+		{"id": "temp", "name": "Temp", "risk": "safe", "handler": "folder", "paths": [{"path": "C:\\Temp"}],
+		 "filter": {"minAge": "1d", "exclude": ["keep"]}},
+		{"id": "all", "name": "All", "risk": "safe", "handler": "folder", "paths": [{"path": "C:\\Temp"}]},
+		{"id": "logs", "name": "Logs", "risk": "safe", "handler": "log-files", "paths": [{"path": "C:\\Logs", "maxDepth": 3}]}
+	]
+}`
+
+// newTestService 构造内存文件系统中的测试目录树，protected 为受保护路径
+func newTestService(t *testing.T, protected ...string) (*CleanService, *fsys.Mem) {
+	t.Helper()
+	old := time.Now().Add(-48 * time.Hour)
+	mem := fsys.NewMem(1 << 30)
+	mem.AddFile(`C:\Temp\old.tmp`, 100, old)
+	mem.AddFile(`C:\Temp\new.tmp`, 50, time.Now())
+	mem.AddFile(`C:\Temp\sub\a.tmp`, 30, old)
+	mem.AddFile(`C:\Temp\keep\b.tmp`, 20, old)
+	mem.AddFile(`C:\Logs\a.log`, 10, old)
+	mem.AddFile(`C:\Logs\b.txt`, 20, old)
+	mem.AddFile(`C:\Logs\sub\c.log`, 30, old)
+
+	cat, err := catalog.Parse([]byte(testCatalog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewCleanService(mem, cat)
+	if len(protected) > 0 {
+		list, err := protect.Load(mem, protectedFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := list.Set(models.ProtectedRules{Paths: protected}); err != nil {
+			t.Fatal(err)
+		}
+		s.SetProtected(list)
+	}
+	return s, mem
+}
+
+// protectedFile 受保护路径的规则文件（使用 / 分隔，filepath.Dir 在其他系统上也能取得目录）
+const protectedFile = `C:/Config/protected.json`
+
+// remainingFiles 剩余的 C:\Temp 和 C:\Logs 中的文件（使用 / 分隔，不含受保护路径的规则文件）
+func remainingFiles(mem *fsys.Mem) []string {
+	var files []string
+	for _, file := range mem.Files() {
+		if file = filepath.ToSlash(file); file != protectedFile {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func TestCalculateFolderDetails(t *testing.T) {
+	tests := []struct {
+		name      string
+		item      string
+		protected []string
+		size      int64
+		files     int
+	}{
+		{"no filter", "all", nil, 200, 4},
+		{"filter", "temp", nil, 130, 2},
+		{"protected", "all", []string{`C:\Temp\sub`}, 170, 3},
+		{"filter and protected", "temp", []string{`C:\Temp\old.tmp`}, 30, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, tt.protected...)
+			size, files, _, err := s.CalculateFolderDetails(context.Background(), `C:\Temp`, s.Catalog().PathFilter(tt.item))
+			if err != nil || size != tt.size || files != tt.files {
+				t.Fatalf("CalculateFolderDetails = %d, %d, %v; want %d, %d", size, files, err, tt.size, tt.files)
+			}
+		})
+	}
+}
+
+func TestCleanFolderSafe(t *testing.T) {
+	tests := []struct {
+		name      string
+		item      string
+		protected []string
+		freed     int64
+		removed   int
+		remaining []string
+	}{
+		{"no filter", "all", nil, 200, 4, nil},
+		{"filter", "temp", nil, 130, 2, []string{`C:/Temp/keep/b.tmp`, `C:/Temp/new.tmp`}},
+		{"protected", "all", []string{`C:\Temp\sub`}, 170, 3, []string{`C:/Temp/sub/a.tmp`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mem := newTestService(t, tt.protected...)
+			report := NewCleanReport()
+			freed, err := s.CleanFolderSafe(context.Background(), `C:\Temp`, tt.item, nil, report)
+			if err != nil || freed != tt.freed || report.FreedSize != tt.freed || report.RemovedCount != tt.removed {
+				t.Fatalf("CleanFolderSafe = %d, %v (report %d, %d); want %d, %d",
+					freed, err, report.FreedSize, report.RemovedCount, tt.freed, tt.removed)
+			}
+			want := append([]string{`C:/Logs/a.log`, `C:/Logs/b.txt`, `C:/Logs/sub/c.log`}, tt.remaining...)
+			if got := remainingFiles(mem); !reflect.DeepEqual(got, want) {
+				t.Fatalf("remaining files = %q; want %q", got, want)
+			}
+			if len(tt.protected) > 0 && report.SkippedReasons[models.SkipExcluded] == 0 {
+				t.Fatalf("protected path not reported: %+v", report.SkippedReasons)
+			}
+			if !mem.Exists(`C:\Temp`) {
+				t.Fatal("cleaned folder itself was removed")
+			}
+		})
+	}
+}
+
+func TestCleanLogFilesInPath(t *testing.T) {
+	s, mem := newTestService(t)
+	report := NewCleanReport()
+	freed, count := s.CleanLogFilesInPath(context.Background(), `C:\Logs`, "logs", nil, report)
+	if freed != 40 || count != 2 || report.FreedSize != 40 || report.RemovedCount != 2 {
+		t.Fatalf("CleanLogFilesInPath = %d, %d (report %d, %d); want 40, 2", freed, count, report.FreedSize, report.RemovedCount)
+	}
+	want := []string{`C:/Logs/b.txt`, `C:/Temp/keep/b.tmp`, `C:/Temp/new.tmp`, `C:/Temp/old.tmp`, `C:/Temp/sub/a.tmp`}
+	if got := remainingFiles(mem); !reflect.DeepEqual(got, want) {
+		t.Fatalf("remaining files = %q; want %q", got, want)
+	}
+}
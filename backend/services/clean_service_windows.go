@@ -0,0 +1,120 @@
+package services
+
+import (
+	"ccooler/backend/apperr"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// isInUseError 判断错误是否由文件被占用引起
+func isInUseError(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) || errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
+
+// shQueryRBInfo SHQUERYRBINFO 结构
+type shQueryRBInfo struct {
+	cbSize      uint32
+	i64Size     int64
+	i64NumItems int64
+}
+
+// QueryRecycleBin 查询所有分区回收站的大小和项目数
+func (s *CleanService) QueryRecycleBin() (int64, int64, error) {
+	info := shQueryRBInfo{}
+	info.cbSize = uint32(unsafe.Sizeof(info))
+
+	ret, _, _ := syscall.NewLazyDLL("shell32.dll").NewProc("SHQueryRecycleBinW").Call(
+		0, // 所有分区
+		uintptr(unsafe.Pointer(&info)),
+	)
+	if ret != 0 {
+		return 0, 0, apperr.Wrap(apperr.RecycleBinQuery, fmt.Errorf("HRESULT 0x%X", ret))
+	}
+	return info.i64Size, info.i64NumItems, nil
+}
+
+// EmptyRecycleBin 清空回收站
+func (s *CleanService) EmptyRecycleBin() error {
+	shell32 := syscall.NewLazyDLL("shell32.dll")
+	emptyRecycleBin := shell32.NewProc("SHEmptyRecycleBinW")
+
+	ret, _, _ := emptyRecycleBin.Call(
+		0,
+		0,
+		0x00000001|0x00000002, // SHERB_NOCONFIRMATION | SHERB_NOPROGRESSUI
+	)
+
+	// HRESULT 返回值：
+	// S_OK (0x00000000) = 成功清空
+	// S_FALSE (0x00000001) = 回收站已经是空的
+	if ret == 0 || ret == 1 {
+		return nil
+	}
+
+	// 解析常见错误
+	switch ret {
+	case 0x80070005: // E_ACCESSDENIED
+		return apperr.New(apperr.AccessDenied)
+	case 0x8000FFFF: // E_UNEXPECTED
+		return apperr.New(apperr.RecycleBinBusy)
+	case 0x80004005: // E_FAIL
+		return apperr.New(apperr.RecycleBinFailed)
+	}
+	return apperr.Wrap(apperr.RecycleBinFailed, fmt.Errorf("HRESULT 0x%X", ret))
+}
+
+// OpenFolder 使用资源管理器打开文件夹
+func (s *CleanService) OpenFolder(path string) error {
+	// 使用 Windows API 展开环境变量
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	expandEnvironmentStrings := kernel32.NewProc("ExpandEnvironmentStringsW")
+
+	pathPtr, _ := syscall.UTF16PtrFromString(path)
+	buffer := make([]uint16, 32768) // MAX_PATH 的扩展版本
+
+	ret, _, _ := expandEnvironmentStrings.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(unsafe.Pointer(&buffer[0])),
+		uintptr(len(buffer)),
+	)
+
+	if ret == 0 {
+		return apperr.New(apperr.ExpandFailed, "path", path)
+	}
+
+	expandedPath := syscall.UTF16ToString(buffer)
+
+	// 检查路径是否存在
+	if _, err := s.fs.Stat(expandedPath); os.IsNotExist(err) {
+		return apperr.New(apperr.PathNotFound, "path", expandedPath)
+	}
+
+	// 使用 explorer 打开文件夹
+	shell32 := syscall.NewLazyDLL("shell32.dll")
+	shellExecute := shell32.NewProc("ShellExecuteW")
+
+	operation, _ := syscall.UTF16PtrFromString("open")
+	file, _ := syscall.UTF16PtrFromString("explorer.exe")
+	params, _ := syscall.UTF16PtrFromString(expandedPath)
+
+	ret2, _, _ := shellExecute.Call(
+		0,
+		uintptr(unsafe.Pointer(operation)),
+		uintptr(unsafe.Pointer(file)),
+		uintptr(unsafe.Pointer(params)),
+		0,
+		1, // SW_SHOWNORMAL
+	)
+
+	// ShellExecute 返回值 > 32 表示成功
+	if ret2 <= 32 {
+		return apperr.New(apperr.OpenFailed, "path", expandedPath)
+	}
+
+	return nil
+}
@@ -1,13 +1,23 @@
 package services
 
 import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
+	"ccooler/backend/ops"
+	"ccooler/backend/progress"
+	"ccooler/backend/protect"
+	"ccooler/backend/quarantine"
+	"ccooler/backend/walker"
+	"context"
 	"fmt"
 	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
-	"syscall"
-	"unsafe"
+	"sync"
 )
 
 // LargeFileCategory 大文件分类
@@ -23,6 +33,9 @@ const (
 	CategoryOther     LargeFileCategory = "other"
 )
 
+// ThresholdCategories 可以单独设置最小大小的分类（按文件类型划分的分类）
+var ThresholdCategories = []LargeFileCategory{CategoryMedia, CategoryDocument, CategoryArchive, CategoryInstaller, CategoryOther}
+
 // LargeFileInfo 大文件信息
 type LargeFileInfo struct {
 	ID           string            `json:"id"`
@@ -43,109 +56,200 @@ type CategoryStats struct {
 
 // ScanResult 扫描结果
 type ScanResult struct {
+	Drive      string          `json:"drive"` // 扫描的分区，如 "D:\\"
 	Files      []LargeFileInfo `json:"files"`
 	Stats      []CategoryStats `json:"stats"`
 	TotalFiles int             `json:"totalFiles"`
 	TotalSize  int64           `json:"totalSize"`
+	Status     string          `json:"status,omitempty"` // 扫描被取消时为 "cancelled"，结果只包含已扫描部分
+
+	// 受保护路径中的大文件（不列出）
+	ExcludedSize  int64 `json:"excludedSize,omitempty"`
+	ExcludedCount int   `json:"excludedCount,omitempty"`
 }
 
+// DefaultLargeFileMinSize 默认的大文件最小大小（字节）
+const DefaultLargeFileMinSize = 10 * 1024 * 1024
+
 // LargeFileService 大文件扫描服务
 type LargeFileService struct {
-	minSize int64 // 最小文件大小（字节）
+	fs       fsys.FS
+	minSize  int64                       // 最小文件大小（字节）
+	minSizes map[LargeFileCategory]int64 // 各分类单独的最小大小，未设置的分类使用 minSize
+	index    *walker.Index               // 目录大小索引，为空时每次完整遍历
+	progress progress.Sink               // 扫描进度，为空时不发送
+	protect  *protect.List               // 受保护路径，为空时不限制
 }
 
 // NewLargeFileService 创建大文件扫描服务
-func NewLargeFileService() *LargeFileService {
+func NewLargeFileService(filesystem fsys.FS) *LargeFileService {
 	return &LargeFileService{
-		minSize: 100 * 1024 * 1024, // 默认100MB
+		fs:      filesystem,
+		minSize: DefaultLargeFileMinSize,
 	}
 }
 
-// SetMinSize 设置最小文件大小
+// SetMinSize 设置最小文件大小（MB），各分类使用同一个阈值
 func (s *LargeFileService) SetMinSize(sizeInMB int64) {
-	s.minSize = sizeInMB * 1024 * 1024
+	s.SetThresholds(sizeInMB*1024*1024, nil)
 }
 
-// ScanCDrive 扫描C盘大文件
-func (s *LargeFileService) ScanCDrive() (*ScanResult, error) {
-	files := make([]LargeFileInfo, 0)
-	fileID := 0
+// SetThresholds 设置最小文件大小（字节）和各分类单独的最小大小
+func (s *LargeFileService) SetThresholds(minSize int64, categories map[LargeFileCategory]int64) {
+	s.minSize = minSize
+	s.minSizes = maps.Clone(categories)
+}
 
-	// 遍历C盘
-	err := filepath.WalkDir("C:\\", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			// 忽略无权限访问的目录
-			return nil
-		}
+// profile 目录大小索引中的遍历配置名，使用非默认阈值时加上阈值，不同阈值的结果分别缓存
+func (s *LargeFileService) profile() string {
+	if s.minSize == DefaultLargeFileMinSize && len(s.minSizes) == 0 {
+		return walker.ProfileLargeFiles
+	}
+	profile := walker.ProfileLargeFiles + ":" + strconv.FormatInt(s.minSize, 10)
+	categories := make([]string, 0, len(s.minSizes))
+	for category, size := range s.minSizes {
+		categories = append(categories, string(category)+"="+strconv.FormatInt(size, 10))
+	}
+	sort.Strings(categories)
+	if len(categories) > 0 {
+		profile += "," + strings.Join(categories, ",")
+	}
+	return profile
+}
 
-		// 跳过目录
-		if d.IsDir() {
-			// 跳过系统目录和隐藏目录
-			if shouldSkipDir(path) {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+// SetSizeIndex 设置目录大小索引
+func (s *LargeFileService) SetSizeIndex(index *walker.Index) {
+	s.index = index
+}
+
+// SetProgressSink 设置扫描进度的接收者
+func (s *LargeFileService) SetProgressSink(sink progress.Sink) {
+	s.progress = sink
+}
 
-		// 获取文件信息
-		info, err := d.Info()
-		if err != nil {
-			return nil
+// SetProtected 设置受保护路径（扫描时不列出，不能删除）
+func (s *LargeFileService) SetProtected(list *protect.List) {
+	s.protect = list
+}
+
+// ScanDrive 扫描分区中的大文件，drive 为空时扫描系统盘（ctx 取消时返回已扫描部分）
+func (s *LargeFileService) ScanDrive(ctx context.Context, drive string) (*ScanResult, error) {
+	root := fsys.SystemDrive()
+	if drive != "" {
+		var err error
+		if root, err = fsys.DriveRoot(drive); err != nil {
+			return nil, err
 		}
+	}
 
-		// 先判断文件分类
-		category := s.categorizeFile(path)
+	files := make([]LargeFileInfo, 0)
+	var mu sync.Mutex
 
-		// 根据分类设置不同的最小大小阈值
-		minSizeForCategory := s.getMinSizeForCategory(category)
+	// 进度中的大小和文件数为已找到的大文件
+	tracker := newScanTracker(s.progress, ScanLargeFiles, 0)
+	defer tracker.finish()
 
-		// 只处理大于最小大小的文件
-		if info.Size() >= minSizeForCategory {
-			fileID++
+	// 遍历分区（并行读取目录，跳过系统目录）
+	skip := skipDirs(root)
+	var exclude func(string) bool
+	if !s.protect.Empty() {
+		exclude = s.protect.Protected
+	}
 
-			files = append(files, LargeFileInfo{
-				ID:           fmt.Sprintf("%d", fileID),
+	// 阈值改变后丢弃按旧阈值缓存的结果
+	profile := s.profile()
+	s.index.DropProfiles(walker.ProfileLargeFiles, profile)
+
+	walked, err := walker.Walk(ctx, s.fs, root, walker.Options{
+		Index:   s.index,
+		Profile: profile,
+		SkipDir: func(path string, _ fs.DirEntry) bool {
+			return shouldSkipDir(path, skip)
+		},
+		// 根据分类设置不同的最小大小阈值，只处理大于最小大小的文件
+		Match: func(path string, info fs.FileInfo) bool {
+			return info.Size() >= s.getMinSizeForCategory(s.categorizeFile(path))
+		},
+		Exclude: exclude,
+		OnFile: func(path string, info fs.FileInfo) {
+			file := LargeFileInfo{
 				Name:         info.Name(),
 				Path:         path,
 				Size:         info.Size(),
-				Category:     category,
+				Category:     s.categorizeFile(path),
 				ModifiedTime: info.ModTime().Format("2006-01-02 15:04"),
 				Extension:    strings.ToLower(filepath.Ext(path)),
-			})
-		}
-
-		return nil
+			}
+			mu.Lock()
+			files = append(files, file)
+			mu.Unlock()
+		},
+		OnDir: tracker.onDir(),
 	})
 
-	if err != nil {
+	if err != nil && !ops.Cancelled(ctx) {
 		return nil, err
 	}
 
+	// 并行遍历的顺序不固定，按路径排序后编号
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	for i := range files {
+		files[i].ID = fmt.Sprintf("%d", i+1)
+	}
+
 	// 计算统计信息
 	stats := s.calculateStats(files)
 
-	return &ScanResult{
+	result := &ScanResult{
+		Drive:      root,
 		Files:      files,
 		Stats:      stats,
 		TotalFiles: len(files),
 		TotalSize:  s.calculateTotalSize(files),
-	}, nil
+
+		ExcludedSize:  walked.ExcludedSize,
+		ExcludedCount: walked.ExcludedFiles,
+	}
+	if ops.Cancelled(ctx) {
+		result.Status = ops.StatusCancelled
+	}
+	return result, nil
 }
 
-// shouldSkipDir 判断是否跳过目录
-func shouldSkipDir(path string) bool {
-	lowerPath := strings.ToLower(path)
+// skipDirs 返回扫描分区时跳过的系统目录和文件（小写，目录以 \\ 结尾）。
+// Windows 目录和错误报告目录按环境变量确定，Windows 不在 C 盘时也能正确跳过
+func skipDirs(root string) []string {
+	root = strings.ToLower(root)
+	dirs := []string{
+		root + "$recycle.bin\\",
+		root + "system volume information\\",
+		root + "pagefile.sys",
+		root + "hiberfil.sys",
+		root + "swapfile.sys",
+		root + strings.ToLower(quarantine.DirName) + "\\",      // 隔离区
+		root + strings.ToLower(quarantine.AdminDirName) + "\\", // 管理员隔离区
+	}
 
-	// 跳过系统目录
-	skipDirs := []string{
-		"c:\\windows\\",
-		"c:\\$recycle.bin\\",
-		"c:\\system volume information\\",
-		"c:\\pagefile.sys",
-		"c:\\hiberfil.sys",
-		"c:\\swapfile.sys",
-		"c:\\programdata\\microsoft\\windows\\wer\\",
+	systemDrive := strings.ToLower(fsys.SystemDrive())
+	windowsDir := os.Getenv("SystemRoot")
+	if windowsDir == "" {
+		windowsDir = systemDrive + "windows"
 	}
+	programData := os.Getenv("ProgramData")
+	if programData == "" {
+		programData = systemDrive + "programdata"
+	}
+	return append(dirs,
+		strings.ToLower(strings.TrimRight(windowsDir, "\\"))+"\\",
+		strings.ToLower(strings.TrimRight(programData, "\\"))+"\\microsoft\\windows\\wer\\",
+	)
+}
+
+// shouldSkipDir 判断是否跳过目录
+func shouldSkipDir(path string, skipDirs []string) bool {
+	lowerPath := strings.ToLower(path) + "\\"
 
 	for _, skipDir := range skipDirs {
 		if strings.HasPrefix(lowerPath, skipDir) {
@@ -158,8 +262,10 @@ func shouldSkipDir(path string) bool {
 
 // getMinSizeForCategory 根据文件分类返回最小大小阈值
 func (s *LargeFileService) getMinSizeForCategory(category LargeFileCategory) int64 {
-	// 所有分类统一：10MB起
-	return 10 * 1024 * 1024 // 10MB
+	if size, ok := s.minSizes[category]; ok {
+		return size
+	}
+	return s.minSize
 }
 
 // 文件扩展名分类映射（使用 map 提升查找性能）
@@ -440,47 +546,20 @@ func (s *LargeFileService) calculateTotalSize(files []LargeFileInfo) int64 {
 // DeleteFile 删除文件
 func (s *LargeFileService) DeleteFile(path string) error {
 	// 检查文件是否存在
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return fmt.Errorf("文件不存在: %s", path)
+	if _, err := s.fs.Stat(path); os.IsNotExist(err) {
+		return apperr.New(apperr.PathNotFound, "path", path)
+	}
+	if s.protect.Protected(path) {
+		return apperr.Wrap(apperr.Protected, nil, "path", path)
 	}
 
 	// 删除文件
-	err := os.Remove(path)
+	err := s.fs.Remove(path)
 	if err != nil {
-		return fmt.Errorf("删除文件失败: %v", err)
+		return apperr.Wrap(apperr.DeleteFailed, err, "path", path)
 	}
-
-	return nil
-}
-
-// OpenFileLocation 在资源管理器中打开文件位置
-func (s *LargeFileService) OpenFileLocation(path string) error {
-	// 检查文件是否存在
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return fmt.Errorf("文件不存在: %s", path)
-	}
-
-	// 使用 Windows API 打开文件位置并选中文件
-	// 使用 explorer /select 命令
-	shell32 := syscall.NewLazyDLL("shell32.dll")
-	shellExecute := shell32.NewProc("ShellExecuteW")
-
-	operation, _ := syscall.UTF16PtrFromString("open")
-	file, _ := syscall.UTF16PtrFromString("explorer.exe")
-	params, _ := syscall.UTF16PtrFromString(fmt.Sprintf("/select,%s", path))
-
-	ret, _, _ := shellExecute.Call(
-		0,
-		uintptr(unsafe.Pointer(operation)),
-		uintptr(unsafe.Pointer(file)),
-		uintptr(unsafe.Pointer(params)),
-		0,
-		1, // SW_SHOWNORMAL
-	)
-
-	// ShellExecute 返回值 > 32 表示成功
-	if ret <= 32 {
-		return fmt.Errorf("无法打开文件位置: %s", path)
+	if s.index != nil {
+		s.index.Invalidate(filepath.Dir(path))
 	}
 
 	return nil
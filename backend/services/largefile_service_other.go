@@ -0,0 +1,8 @@
+//go:build !windows
+
+package services
+
+// OpenFileLocation 在资源管理器中打开文件位置（仅支持 Windows）
+func (s *LargeFileService) OpenFileLocation(path string) error {
+	return errNotSupported
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"ccooler/backend/apperr"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// OpenFileLocation 在资源管理器中打开文件位置
+func (s *LargeFileService) OpenFileLocation(path string) error {
+	// 检查文件是否存在
+	if _, err := s.fs.Stat(path); os.IsNotExist(err) {
+		return apperr.New(apperr.PathNotFound, "path", path)
+	}
+
+	// 使用 Windows API 打开文件位置并选中文件
+	// 使用 explorer /select 命令
+	shell32 := syscall.NewLazyDLL("shell32.dll")
+	shellExecute := shell32.NewProc("ShellExecuteW")
+
+	operation, _ := syscall.UTF16PtrFromString("open")
+	file, _ := syscall.UTF16PtrFromString("explorer.exe")
+	params, _ := syscall.UTF16PtrFromString(fmt.Sprintf("/select,%s", path))
+
+	ret, _, _ := shellExecute.Call(
+		0,
+		uintptr(unsafe.Pointer(operation)),
+		uintptr(unsafe.Pointer(file)),
+		uintptr(unsafe.Pointer(params)),
+		0,
+		1, // SW_SHOWNORMAL
+	)
+
+	// ShellExecute 返回值 > 32 表示成功
+	if ret <= 32 {
+		return apperr.New(apperr.OpenFailed, "path", path)
+	}
+
+	return nil
+}
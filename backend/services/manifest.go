@@ -0,0 +1,163 @@
+package services
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/catalog"
+	"ccooler/backend/models"
+	"ccooler/backend/quarantine"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestVersion 删除清单格式版本
+const ManifestVersion = 1
+
+// NewCleanManifest 创建空的删除清单
+func NewCleanManifest() *models.CleanManifest {
+	return &models.CleanManifest{
+		Version:   ManifestVersion,
+		CreatedAt: time.Now(),
+		Entries:   []models.ManifestEntry{},
+	}
+}
+
+// MergeCleanManifest 将 src 中的条目合并到 dst
+func MergeCleanManifest(dst, src *models.CleanManifest) {
+	if src == nil {
+		return
+	}
+	for _, entry := range src.Entries {
+		addManifestEntry(dst, entry)
+	}
+}
+
+func addManifestEntry(manifest *models.CleanManifest, entry models.ManifestEntry) {
+	manifest.Entries = append(manifest.Entries, entry)
+	manifest.TotalSize += entry.Size
+	manifest.TotalFiles++
+}
+
+// PlanItem 按扫描结果中的路径生成清理项的删除清单（与实际清理规则一致，不删除任何文件）
+func (s *CleanService) PlanItem(ctx context.Context, item *models.CleanItem, manifest *models.CleanManifest) {
+	rule, ok := s.catalog.Rule(item.ID)
+	if !ok {
+		return
+	}
+
+	for _, pathDetail := range item.Paths {
+		s.PlanPath(ctx, pathDetail.Path, rule.Handler, item.ID, manifest)
+	}
+}
+
+// PlanPath 生成单个清理路径的删除清单（按清理项的过滤条件筛选，ctx 取消时停止）
+func (s *CleanService) PlanPath(ctx context.Context, root string, handler catalog.HandlerKind, itemID string, manifest *models.CleanManifest) {
+	var filter *catalog.Filter
+	if handler == catalog.HandlerFolder {
+		filter = s.catalog.PathFilter(itemID)
+	}
+
+	if s.protect.Protected(root) {
+		return
+	}
+
+	filter.Walk(s.fs, root, func(path string, info os.FileInfo) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if s.protect.Protected(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil // 受保护的内容不列入清单
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		// 日志文件清理只删除 .log 文件
+		if handler == catalog.HandlerLogFiles && filepath.Ext(path) != ".log" {
+			return nil
+		}
+
+		addManifestEntry(manifest, models.ManifestEntry{
+			Path:    path,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			ItemID:  itemID,
+			Root:    root,
+			Reason:  string(handler),
+		})
+		return nil
+	})
+}
+
+// ReplayManifest 按删除清单逐个删除文件（大小或修改时间已变化的文件视为不同文件，跳过；
+// run 不为空时移入隔离区），每个文件的结果记录到 report。ctx 取消时停止并返回已删除部分
+func (s *CleanService) ReplayManifest(ctx context.Context, manifest *models.CleanManifest, run *quarantine.Run, report *models.CleanReport) (int64, int, error) {
+	if manifest.Version < 1 || manifest.Version > ManifestVersion {
+		return 0, 0, apperr.New(apperr.ManifestVersion, "version", manifest.Version)
+	}
+
+	var cleanedSize int64
+	var cleanedCount int
+
+	for _, entry := range manifest.Entries {
+		if ctx.Err() != nil {
+			return cleanedSize, cleanedCount, ctx.Err()
+		}
+		if s.protect.Protected(entry.Path) {
+			// 导出清单后才设置的受保护路径
+			RecordSkipped(report, entry.Root, entry.ItemID, entry.Path, entry.Size, models.SkipExcluded, nil)
+			continue
+		}
+		info, err := s.fs.Lstat(entry.Path)
+		if err != nil {
+			RecordSkipped(report, entry.Root, entry.ItemID, entry.Path, entry.Size, SkipReasonOf(err, nil), err)
+			continue
+		}
+
+		if info.IsDir() || info.Size() != entry.Size || !info.ModTime().Equal(entry.ModTime) {
+			RecordSkipped(report, entry.Root, entry.ItemID, entry.Path, entry.Size, models.SkipChanged, nil)
+			continue
+		}
+
+		err = quarantine.Remove(s.fs, run, entry.Path)
+		RecordRemoval(report, entry.Root, entry.ItemID, entry.Path, info, err)
+		if err == nil {
+			cleanedSize += entry.Size
+			cleanedCount++
+		}
+	}
+
+	return cleanedSize, cleanedCount, nil
+}
+
+// ExportCleanManifest 将删除清单导出为 JSON 文件
+func ExportCleanManifest(manifest *models.CleanManifest, path string) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return apperr.Wrap(apperr.SaveFailed, err, "file", path)
+	}
+	return nil
+}
+
+// LoadCleanManifest 从 JSON 文件读取删除清单
+func LoadCleanManifest(path string) (*models.CleanManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.ReadFailed, err, "file", path)
+	}
+
+	var manifest models.CleanManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, apperr.Wrap(apperr.FileInvalid, err, "file", path)
+	}
+	return &manifest, nil
+}
@@ -2,12 +2,10 @@ package services
 
 import (
 	"bytes"
-	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"syscall"
-	"unsafe"
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
+	"ccooler/backend/walker"
+	"context"
 
 	"golang.org/x/text/encoding/simplifiedchinese"
 	"golang.org/x/text/transform"
@@ -40,11 +38,13 @@ type SystemOptimizeResult struct {
 }
 
 // OptimizeService 系统优化服务
-type OptimizeService struct{}
+type OptimizeService struct {
+	fs fsys.FS
+}
 
 // NewOptimizeService 创建系统优化服务实例
-func NewOptimizeService() *OptimizeService {
-	return &OptimizeService{}
+func NewOptimizeService(filesystem fsys.FS) *OptimizeService {
+	return &OptimizeService{fs: filesystem}
 }
 
 // Scan 扫描系统优化项
@@ -81,10 +81,10 @@ func (s *OptimizeService) Scan() (*SystemOptimizeResult, error) {
 
 // checkHibernation 检查休眠文件
 func (s *OptimizeService) checkHibernation() *SystemOptimizeItem {
-	path := "C:\\hiberfil.sys"
+	path := fsys.SystemDrive() + "hiberfil.sys"
 
 	// 检查文件是否存在
-	info, err := os.Stat(path)
+	info, err := s.fs.Stat(path)
 	if err != nil {
 		// 文件不存在，说明休眠已禁用
 		return &SystemOptimizeItem{
@@ -111,14 +111,11 @@ func (s *OptimizeService) checkHibernation() *SystemOptimizeItem {
 
 // checkPagefile 检查虚拟内存文件
 func (s *OptimizeService) checkPagefile() *SystemOptimizeItem {
-	path := "C:\\pagefile.sys"
+	path := fsys.SystemDrive() + "pagefile.sys"
 
 	// 先检查注册表配置（更准确）
 	psScript := `(Get-ItemProperty -Path 'HKLM:\SYSTEM\CurrentControlSet\Control\Session Manager\Memory Management' -Name 'PagingFiles').PagingFiles`
-	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", psScript)
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
-	}
+	cmd := hiddenCommand("powershell", "-NoProfile", "-NonInteractive", "-Command", psScript)
 	output, err := cmd.CombinedOutput()
 
 	// 如果注册表显示已禁用（空值或空数组）
@@ -136,7 +133,7 @@ func (s *OptimizeService) checkPagefile() *SystemOptimizeItem {
 	}
 
 	// 检查文件是否存在
-	info, err := os.Stat(path)
+	info, err := s.fs.Stat(path)
 	if err != nil {
 		return &SystemOptimizeItem{
 			Type:        OptimizePagefile,
@@ -162,7 +159,7 @@ func (s *OptimizeService) checkPagefile() *SystemOptimizeItem {
 
 // checkSystemRestore 检查系统还原点
 func (s *OptimizeService) checkSystemRestore() *SystemOptimizeItem {
-	path := "C:\\System Volume Information"
+	path := fsys.SystemDrive() + "System Volume Information"
 
 	// 计算目录大小
 	size := s.calculateDirSize(path)
@@ -180,19 +177,7 @@ func (s *OptimizeService) checkSystemRestore() *SystemOptimizeItem {
 
 // calculateDirSize 计算目录大小
 func (s *OptimizeService) calculateDirSize(path string) int64 {
-	var size int64
-
-	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // 忽略错误，继续遍历
-		}
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
-	})
-
-	return size
+	return walker.Size(context.Background(), s.fs, path, nil)
 }
 
 // Clean 清理/禁用系统优化项
@@ -205,7 +190,7 @@ func (s *OptimizeService) Clean(itemType SystemOptimizeType) error {
 	case OptimizePagefile:
 		return s.disablePagefile()
 	default:
-		return fmt.Errorf("未知的优化项类型: %s", itemType)
+		return apperr.New(apperr.UnknownOptimizeItem, "type", itemType)
 	}
 }
 
@@ -223,10 +208,7 @@ func gbkToUtf8(s []byte) (string, error) {
 // disableHibernation 禁用休眠
 func (s *OptimizeService) disableHibernation() error {
 	// 使用 powercfg 命令禁用休眠
-	cmd := exec.Command("powercfg", "/hibernate", "off")
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
-	}
+	cmd := hiddenCommand("powercfg", "/hibernate", "off")
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -235,7 +217,7 @@ func (s *OptimizeService) disableHibernation() error {
 		if convErr != nil {
 			outputStr = string(output) // 转换失败则使用原始输出
 		}
-		return fmt.Errorf("禁用休眠失败: %v, 输出: %s", err, outputStr)
+		return apperr.Wrap(apperr.HibernateFailed, err, "output", outputStr)
 	}
 
 	return nil
@@ -245,10 +227,7 @@ func (s *OptimizeService) disableHibernation() error {
 func (s *OptimizeService) cleanSystemRestore() error {
 	// 使用 vssadmin 命令删除所有还原点
 	// 注意：这需要管理员权限
-	cmd := exec.Command("vssadmin", "delete", "shadows", "/all", "/quiet")
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
-	}
+	cmd := hiddenCommand("vssadmin", "delete", "shadows", "/all", "/quiet")
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -257,7 +236,7 @@ func (s *OptimizeService) cleanSystemRestore() error {
 		if convErr != nil {
 			outputStr = string(output) // 转换失败则使用原始输出
 		}
-		return fmt.Errorf("清理系统还原点失败: %v, 输出: %s", err, outputStr)
+		return apperr.Wrap(apperr.RestorePointsFailed, err, "output", outputStr)
 	}
 
 	return nil
@@ -276,10 +255,7 @@ func (s *OptimizeService) disablePagefile() error {
 		Set-ItemProperty -Path $path -Name 'PagingFiles' -Type MultiString -Value @()
 	`
 
-	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", psScript)
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
-	}
+	cmd := hiddenCommand("powershell", "-NoProfile", "-NonInteractive", "-Command", psScript)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -288,48 +264,7 @@ func (s *OptimizeService) disablePagefile() error {
 		if convErr != nil {
 			outputStr = string(output)
 		}
-		return fmt.Errorf("禁用虚拟内存失败: %v, 输出: %s", err, outputStr)
-	}
-
-	return nil
-}
-
-// IsAdmin 检查是否有管理员权限
-func (s *OptimizeService) IsAdmin() bool {
-	shell32 := syscall.NewLazyDLL("shell32.dll")
-	isUserAnAdmin := shell32.NewProc("IsUserAnAdmin")
-
-	ret, _, _ := isUserAnAdmin.Call()
-	return ret != 0
-}
-
-// RequestAdmin 请求管理员权限
-func (s *OptimizeService) RequestAdmin() error {
-	exe, err := os.Executable()
-	if err != nil {
-		return err
-	}
-
-	verb := "runas"
-	verbPtr, _ := syscall.UTF16PtrFromString(verb)
-	exePtr, _ := syscall.UTF16PtrFromString(exe)
-	cwdPtr, _ := syscall.UTF16PtrFromString("")
-	argPtr, _ := syscall.UTF16PtrFromString("")
-
-	shell32 := syscall.NewLazyDLL("shell32.dll")
-	shellExecute := shell32.NewProc("ShellExecuteW")
-
-	ret, _, _ := shellExecute.Call(
-		0,
-		uintptr(unsafe.Pointer(verbPtr)),
-		uintptr(unsafe.Pointer(exePtr)),
-		uintptr(unsafe.Pointer(argPtr)),
-		uintptr(unsafe.Pointer(cwdPtr)),
-		1, // SW_SHOWNORMAL
-	)
-
-	if ret <= 32 {
-		return fmt.Errorf("请求管理员权限失败")
+		return apperr.Wrap(apperr.PagefileFailed, err, "output", outputStr)
 	}
 
 	return nil
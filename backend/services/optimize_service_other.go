@@ -0,0 +1,20 @@
+//go:build !windows
+
+package services
+
+import "os/exec"
+
+// hiddenCommand 创建命令（非 Windows 平台没有控制台窗口需要隐藏）
+func hiddenCommand(name string, args ...string) *exec.Cmd {
+	return exec.Command(name, args...)
+}
+
+// IsAdmin 检查是否有管理员权限（仅支持 Windows）
+func (s *OptimizeService) IsAdmin() bool {
+	return false
+}
+
+// RequestAdmin 请求管理员权限（仅支持 Windows）
+func (s *OptimizeService) RequestAdmin() error {
+	return errNotSupported
+}
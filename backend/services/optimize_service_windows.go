@@ -0,0 +1,59 @@
+package services
+
+import (
+	"ccooler/backend/apperr"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// hiddenCommand 创建不显示控制台窗口的命令
+func hiddenCommand(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow: true,
+	}
+	return cmd
+}
+
+// IsAdmin 检查是否有管理员权限
+func (s *OptimizeService) IsAdmin() bool {
+	shell32 := syscall.NewLazyDLL("shell32.dll")
+	isUserAnAdmin := shell32.NewProc("IsUserAnAdmin")
+
+	ret, _, _ := isUserAnAdmin.Call()
+	return ret != 0
+}
+
+// RequestAdmin 请求管理员权限
+func (s *OptimizeService) RequestAdmin() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	verb := "runas"
+	verbPtr, _ := syscall.UTF16PtrFromString(verb)
+	exePtr, _ := syscall.UTF16PtrFromString(exe)
+	cwdPtr, _ := syscall.UTF16PtrFromString("")
+	argPtr, _ := syscall.UTF16PtrFromString("")
+
+	shell32 := syscall.NewLazyDLL("shell32.dll")
+	shellExecute := shell32.NewProc("ShellExecuteW")
+
+	ret, _, _ := shellExecute.Call(
+		0,
+		uintptr(unsafe.Pointer(verbPtr)),
+		uintptr(unsafe.Pointer(exePtr)),
+		uintptr(unsafe.Pointer(argPtr)),
+		uintptr(unsafe.Pointer(cwdPtr)),
+		1, // SW_SHOWNORMAL
+	)
+
+	if ret <= 32 {
+		return apperr.New(apperr.ElevateFailed)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"ccooler/backend/models"
+	"ccooler/backend/progress"
+	"ccooler/backend/walker"
+	"sync"
+)
+
+// 扫描类型（ScanProgress.Scan）
+const (
+	ScanClean      = "clean"
+	ScanLargeFiles = "largefiles"
+	ScanSoftware   = "software"
+)
+
+// scanTracker 汇总一次扫描的进度并限频发送（多个协程并发更新）。
+// sink 为空时为 nil，所有方法都可以用 nil 调用
+type scanTracker struct {
+	throttle *progress.Throttle
+
+	mu    sync.Mutex
+	state models.ScanProgress
+}
+
+// newScanTracker 开始跟踪扫描进度，total 为项目总数（未知时为 0）
+func newScanTracker(sink progress.Sink, scan string, total int) *scanTracker {
+	if sink == nil {
+		return nil
+	}
+	t := &scanTracker{
+		throttle: progress.NewThrottle(sink, progress.EventScan),
+		state:    models.ScanProgress{Scan: scan, Total: total},
+	}
+	t.throttle.Flush(t.state)
+	return t
+}
+
+func (t *scanTracker) snapshot() any {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state
+}
+
+// update 修改进度后限频发送
+func (t *scanTracker) update(fn func(state *models.ScanProgress)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	fn(&t.state)
+	t.mu.Unlock()
+	t.throttle.Emit(t.snapshot)
+}
+
+// item 开始扫描项目
+func (t *scanTracker) item(name string) {
+	t.update(func(state *models.ScanProgress) {
+		state.Item = name
+	})
+}
+
+// dir 统计完一个目录（用作 walker.Options.OnDir）
+func (t *scanTracker) dir(path string, stats walker.DirStats) {
+	t.update(func(state *models.ScanProgress) {
+		state.Path = path
+		state.Size += stats.Size
+		state.Files += stats.Files
+	})
+}
+
+// onDir 返回 walker.Options.OnDir，未跟踪进度时为 nil
+func (t *scanTracker) onDir() func(path string, stats walker.DirStats) {
+	if t == nil {
+		return nil
+	}
+	return t.dir
+}
+
+// file 统计一个文件（按过滤条件逐个统计时使用）
+func (t *scanTracker) file(path string, size int64) {
+	t.update(func(state *models.ScanProgress) {
+		state.Path = path
+		state.Size += size
+		state.Files++
+	})
+}
+
+// done 完成一个项目
+func (t *scanTracker) done() {
+	t.update(func(state *models.ScanProgress) {
+		state.Current++
+	})
+}
+
+// finish 发送最终进度
+func (t *scanTracker) finish() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.state.Done = true
+	t.state.Path = ""
+	state := t.state
+	t.mu.Unlock()
+	t.throttle.Flush(state)
+}
@@ -1,110 +1,88 @@
 package services
 
 import (
+	"ccooler/backend/fsys"
 	"ccooler/backend/models"
-	"os"
-	"path/filepath"
+	"ccooler/backend/ops"
+	"ccooler/backend/progress"
+	"ccooler/backend/walker"
+	"context"
 	"strings"
-
-	"golang.org/x/sys/windows/registry"
 )
 
-type SoftwareService struct{}
-
-func NewSoftwareService() *SoftwareService {
-	return &SoftwareService{}
+type SoftwareService struct {
+	fs       fsys.FS
+	index    *walker.Index // 目录大小索引，为空时每次完整遍历
+	progress progress.Sink // 扫描进度，为空时不发送
 }
 
-// GetInstalledSoftware 获取已安装软件列表
-func (s *SoftwareService) GetInstalledSoftware() ([]*models.SoftwareInfo, error) {
-	var softwareList []*models.SoftwareInfo
-
-	// 读取注册表中的软件信息
-	registryPaths := []string{
-		`SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall`,
-		`SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall`,
-	}
-
-	for _, regPath := range registryPaths {
-		key, err := registry.OpenKey(registry.LOCAL_MACHINE, regPath, registry.ENUMERATE_SUB_KEYS|registry.QUERY_VALUE)
-		if err != nil {
-			continue
-		}
-		defer key.Close()
-
-		subKeys, err := key.ReadSubKeyNames(-1)
-		if err != nil {
-			continue
-		}
-
-		for _, subKeyName := range subKeys {
-			subKey, err := registry.OpenKey(key, subKeyName, registry.QUERY_VALUE)
-			if err != nil {
-				continue
-			}
-
-			displayName, _, err := subKey.GetStringValue("DisplayName")
-			if err != nil || displayName == "" {
-				subKey.Close()
-				continue
-			}
+func NewSoftwareService(filesystem fsys.FS) *SoftwareService {
+	return &SoftwareService{fs: filesystem}
+}
 
-			installLocation, _, err := subKey.GetStringValue("InstallLocation")
-			if err != nil || installLocation == "" {
-				subKey.Close()
-				continue
-			}
+// SetSizeIndex 设置目录大小索引
+func (s *SoftwareService) SetSizeIndex(index *walker.Index) {
+	s.index = index
+}
 
-			// 计算软件大小
-			size := s.calculateDirectorySize(installLocation)
+// SetProgressSink 设置扫描进度的接收者
+func (s *SoftwareService) SetProgressSink(sink progress.Sink) {
+	s.progress = sink
+}
 
-			// 提取图标
-			icon := s.extractIcon(subKey, installLocation)
+// uninstallEntry 注册表卸载信息中的软件
+type uninstallEntry struct {
+	displayName     string
+	installLocation string
+	icon            string
+}
 
-			softwareList = append(softwareList, &models.SoftwareInfo{
-				Name: displayName,
-				Path: installLocation,
-				Size: size,
-				Icon: icon,
-			})
+// GetInstalledSoftware 获取已安装软件列表。
+// ctx 取消时仍返回全部软件，未统计完大小的软件状态为 "cancelled"
+func (s *SoftwareService) GetInstalledSoftware(ctx context.Context) ([]*models.SoftwareInfo, error) {
+	var softwareList []*models.SoftwareInfo
 
-			subKey.Close()
+	entries := s.readUninstallEntries()
+	tracker := newScanTracker(s.progress, ScanSoftware, len(entries))
+	defer tracker.finish()
+
+	for _, entry := range entries {
+		// 计算软件大小
+		tracker.item(entry.displayName)
+		size := s.calculateDirectorySize(ctx, entry.installLocation, tracker)
+		tracker.done()
+
+		software := &models.SoftwareInfo{
+			Name: entry.displayName,
+			Path: entry.installLocation,
+			Size: size,
+			Icon: entry.icon,
+		}
+		if ops.Cancelled(ctx) {
+			software.Status = ops.StatusCancelled
 		}
+		softwareList = append(softwareList, software)
 	}
 
-	// 过滤只显示C盘的软件
-	var cDriveSoftware []*models.SoftwareInfo
+	// 过滤只显示系统盘的软件
+	systemDrive := fsys.SystemDrive()
+	var systemSoftware []*models.SoftwareInfo
 	for _, software := range softwareList {
-		// 检查安装路径是否在C盘（大小写不敏感）
-		if len(software.Path) >= 3 && strings.ToUpper(software.Path[:3]) == "C:\\" {
-			cDriveSoftware = append(cDriveSoftware, software)
+		// 检查安装路径是否在系统盘（大小写不敏感）
+		if len(software.Path) >= 3 && strings.EqualFold(software.Path[:3], systemDrive) {
+			systemSoftware = append(systemSoftware, software)
 		}
 	}
 
-	return cDriveSoftware, nil
+	return systemSoftware, nil
 }
 
-// extractIcon 提取软件图标（返回图标标识）
-func (s *SoftwareService) extractIcon(key registry.Key, installPath string) string {
-	// 暂时返回空字符串，让前端使用首字母头像
-	// 完整的图标提取需要复杂的 Windows API 调用和图像处理
-	// 可以在后续版本中实现
-	return ""
-}
-
-// calculateDirectorySize 计算目录大小
-func (s *SoftwareService) calculateDirectorySize(path string) int64 {
-	var size int64
-
-	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
+// calculateDirectorySize 计算目录大小（ctx 取消时返回已统计部分）
+func (s *SoftwareService) calculateDirectorySize(ctx context.Context, path string, tracker *scanTracker) int64 {
+	result, _ := walker.Walk(ctx, s.fs, path, walker.Options{
+		Index:   s.index,
+		Profile: walker.ProfileSize,
+		OnDir:   tracker.onDir(),
 	})
-
-	return size
+	return result.Size
 }
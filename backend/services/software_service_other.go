@@ -0,0 +1,8 @@
+//go:build !windows
+
+package services
+
+// readUninstallEntries 读取软件卸载信息（非 Windows 平台没有注册表）
+func (s *SoftwareService) readUninstallEntries() []uninstallEntry {
+	return nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import "golang.org/x/sys/windows/registry"
+
+// readUninstallEntries 读取注册表中的软件卸载信息
+func (s *SoftwareService) readUninstallEntries() []uninstallEntry {
+	var entries []uninstallEntry
+
+	// 读取注册表中的软件信息
+	registryPaths := []string{
+		`SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall`,
+		`SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall`,
+	}
+
+	for _, regPath := range registryPaths {
+		key, err := registry.OpenKey(registry.LOCAL_MACHINE, regPath, registry.ENUMERATE_SUB_KEYS|registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		defer key.Close()
+
+		subKeys, err := key.ReadSubKeyNames(-1)
+		if err != nil {
+			continue
+		}
+
+		for _, subKeyName := range subKeys {
+			subKey, err := registry.OpenKey(key, subKeyName, registry.QUERY_VALUE)
+			if err != nil {
+				continue
+			}
+
+			displayName, _, err := subKey.GetStringValue("DisplayName")
+			if err != nil || displayName == "" {
+				subKey.Close()
+				continue
+			}
+
+			installLocation, _, err := subKey.GetStringValue("InstallLocation")
+			if err != nil || installLocation == "" {
+				subKey.Close()
+				continue
+			}
+
+			// 提取图标
+			icon := s.extractIcon(subKey, installLocation)
+
+			entries = append(entries, uninstallEntry{
+				displayName:     displayName,
+				installLocation: installLocation,
+				icon:            icon,
+			})
+
+			subKey.Close()
+		}
+	}
+
+	return entries
+}
+
+// extractIcon 提取软件图标（返回图标标识）
+func (s *SoftwareService) extractIcon(key registry.Key, installPath string) string {
+	// 暂时返回空字符串，让前端使用首字母头像
+	// 完整的图标提取需要复杂的 Windows API 调用和图像处理
+	// 可以在后续版本中实现
+	return ""
+}
@@ -1,42 +1,46 @@
 package services
 
 import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
 	"ccooler/backend/models"
-	"fmt"
+	"ccooler/backend/ops"
+	"ccooler/backend/walker"
+	"context"
 	"os"
 	"os/exec"
 	"path/filepath"
-
-	"golang.org/x/sys/windows/registry"
 )
 
-type WeChatService struct{}
+type WeChatService struct {
+	fs fsys.FS
+}
 
-func NewWeChatService() *WeChatService {
-	return &WeChatService{}
+func NewWeChatService(filesystem fsys.FS) *WeChatService {
+	return &WeChatService{fs: filesystem}
 }
 
-// DetectWeChat 检测微信安装
-func (s *WeChatService) DetectWeChat() (*models.WeChatData, error) {
+// DetectWeChat 检测微信安装（ctx 取消时返回已扫描部分的大小）
+func (s *WeChatService) DetectWeChat(ctx context.Context) (*models.WeChatData, error) {
 	// 从注册表读取微信安装路径
 	installPath, err := s.getWeChatInstallPath()
 	if err != nil {
-		return nil, fmt.Errorf("未检测到微信安装")
+		return nil, apperr.New(apperr.WeChatNotFound)
 	}
 
 	// 获取微信数据路径
 	dataPath := s.getWeChatDataPath()
 
 	// 扫描微信数据大小
-	chatSize, _ := s.calculateFolderSize(filepath.Join(dataPath, "Msg"))
-	fileSize, _ := s.calculateFolderSize(filepath.Join(dataPath, "FileStorage"))
-	mediaSize, _ := s.calculateFolderSize(filepath.Join(dataPath, "Data"))
-	otherSize, _ := s.calculateFolderSize(dataPath)
-	otherSize = otherSize - chatSize - fileSize - mediaSize
+	chatSize, _ := s.calculateFolderSize(ctx, filepath.Join(dataPath, "Msg"))
+	fileSize, _ := s.calculateFolderSize(ctx, filepath.Join(dataPath, "FileStorage"))
+	mediaSize, _ := s.calculateFolderSize(ctx, filepath.Join(dataPath, "Data"))
+	otherSize, _ := s.calculateFolderSize(ctx, dataPath)
+	otherSize = max(otherSize-chatSize-fileSize-mediaSize, 0) // 取消时整体大小可能未统计完
 
 	total := chatSize + fileSize + mediaSize + otherSize
 
-	return &models.WeChatData{
+	data := &models.WeChatData{
 		InstallPath: installPath,
 		DataPath:    dataPath,
 		ChatSize:    chatSize,
@@ -44,33 +48,29 @@ func (s *WeChatService) DetectWeChat() (*models.WeChatData, error) {
 		MediaSize:   mediaSize,
 		OtherSize:   otherSize,
 		Total:       total,
-	}, nil
+	}
+	if ops.Cancelled(ctx) {
+		data.Status = ops.StatusCancelled
+	}
+	return data, nil
 }
 
-// getWeChatInstallPath 从注册表获取微信安装路径
-func (s *WeChatService) getWeChatInstallPath() (string, error) {
-	// 尝试从注册表读取
-	key, err := registry.OpenKey(registry.CURRENT_USER, `Software\Tencent\WeChat`, registry.QUERY_VALUE)
-	if err != nil {
-		// 尝试默认路径
-		defaultPath := `C:\Program Files\Tencent\WeChat`
-		if _, err := os.Stat(filepath.Join(defaultPath, "WeChat.exe")); err == nil {
-			return defaultPath, nil
-		}
-		defaultPath = `C:\Program Files (x86)\Tencent\WeChat`
-		if _, err := os.Stat(filepath.Join(defaultPath, "WeChat.exe")); err == nil {
-			return defaultPath, nil
-		}
-		return "", err
+// findDefaultInstallPath 在默认安装位置查找微信
+func (s *WeChatService) findDefaultInstallPath() (string, error) {
+	defaultPaths := []string{
+		`C:\Program Files\Tencent\WeChat`,
+		`C:\Program Files (x86)\Tencent\WeChat`,
 	}
-	defer key.Close()
 
-	installPath, _, err := key.GetStringValue("InstallPath")
-	if err != nil {
-		return "", err
+	var lastErr error
+	for _, defaultPath := range defaultPaths {
+		_, err := s.fs.Stat(filepath.Join(defaultPath, "WeChat.exe"))
+		if err == nil {
+			return defaultPath, nil
+		}
+		lastErr = err
 	}
-
-	return installPath, nil
+	return "", lastErr
 }
 
 // getWeChatDataPath 获取微信数据路径
@@ -85,7 +85,7 @@ func (s *WeChatService) getWeChatDataPath() string {
 	}
 
 	for _, path := range possiblePaths {
-		if _, err := os.Stat(path); err == nil {
+		if _, err := s.fs.Stat(path); err == nil {
 			return path
 		}
 	}
@@ -94,21 +94,10 @@ func (s *WeChatService) getWeChatDataPath() string {
 	return possiblePaths[0]
 }
 
-// calculateFolderSize 计算文件夹大小
-func (s *WeChatService) calculateFolderSize(path string) (int64, error) {
-	var size int64
-
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
-	})
-
-	return size, err
+// calculateFolderSize 计算文件夹大小（ctx 取消时返回已统计部分）
+func (s *WeChatService) calculateFolderSize(ctx context.Context, path string) (int64, error) {
+	result, err := walker.Walk(ctx, s.fs, path, walker.Options{})
+	return result.Size, err
 }
 
 // OpenWeChat 打开微信程序
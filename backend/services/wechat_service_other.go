@@ -0,0 +1,8 @@
+//go:build !windows
+
+package services
+
+// getWeChatInstallPath 获取微信安装路径（非 Windows 平台只查找默认位置）
+func (s *WeChatService) getWeChatInstallPath() (string, error) {
+	return s.findDefaultInstallPath()
+}
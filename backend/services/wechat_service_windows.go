@@ -0,0 +1,21 @@
+package services
+
+import "golang.org/x/sys/windows/registry"
+
+// getWeChatInstallPath 从注册表获取微信安装路径
+func (s *WeChatService) getWeChatInstallPath() (string, error) {
+	// 尝试从注册表读取
+	key, err := registry.OpenKey(registry.CURRENT_USER, `Software\Tencent\WeChat`, registry.QUERY_VALUE)
+	if err != nil {
+		// 尝试默认路径
+		return s.findDefaultInstallPath()
+	}
+	defer key.Close()
+
+	installPath, _, err := key.GetStringValue("InstallPath")
+	if err != nil {
+		return "", err
+	}
+
+	return installPath, nil
+}
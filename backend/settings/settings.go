@@ -0,0 +1,361 @@
+// Package settings 用户设置：大文件阈值、默认选中的清理项、清理项的过滤条件、受保护路径、
+// 自动清理方案、辅助程序的启动方式和提示文字的语言。设置文件带格式版本，读取旧版本的文件时逐级升级，
+// 读取到更新版本的文件时不覆盖。受保护路径和自动清理方案仍保存在各自的文件中（辅助程序和命令行
+// 直接读取），设置中的这两部分读取时从这些文件获取，保存时写回。
+package settings
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/catalog"
+	"ccooler/backend/fsys"
+	"ccooler/backend/models"
+	"ccooler/backend/protect"
+	"ccooler/backend/scheduler"
+	"ccooler/backend/services"
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// FileVersion 设置文件格式版本
+const FileVersion = 2
+
+// 辅助程序的启动方式（Settings.HelperMode）
+const (
+	HelperOnDemand = "on-demand" // 每个需要管理员权限的任务单独启动辅助程序，每次弹出 UAC 提示（默认）
+	HelperSession  = "session"   // 第一个任务启动常驻辅助程序，之后的任务都交给它执行
+)
+
+// MinLargeFileSize 大文件阈值的最小值
+const MinLargeFileSize = 1 << 20
+
+// ErrNewerVersion 设置文件由更新版本的程序创建（使用默认设置，不覆盖该文件）
+var ErrNewerVersion = apperr.New(apperr.SettingsNewer)
+
+// LargeFiles 大文件扫描的阈值
+type LargeFiles struct {
+	MinSize    int64                                `json:"minSize"`              // 最小文件大小（字节）
+	Categories map[services.LargeFileCategory]int64 `json:"categories,omitempty"` // 各分类单独的最小大小，未设置的分类使用 minSize
+}
+
+// Settings 用户设置
+type Settings struct {
+	Version      int                        `json:"version"`
+	LargeFiles   LargeFiles                 `json:"largeFiles"`
+	EnabledItems []string                   `json:"enabledItems"`      // 默认选中的清理项，为 nil 时使用清理规则中的推荐项
+	Filters      map[string]*catalog.Filter `json:"filters,omitempty"` // 替换清理项的过滤条件（只用于 folder 处理方式）
+	HelperMode   string                     `json:"helperMode"`        // on-demand 或 session
+	Locale       string                     `json:"locale"`            // 错误等提示文字的语言，如 zh-CN、en-US
+
+	// 保存在各自文件中的部分
+	Exclusions models.ProtectedRules `json:"exclusions"` // 受保护路径，更新时三个列表都为 nil 表示不修改（清空用空列表）
+	Profiles   []*scheduler.Profile  `json:"profiles"`   // 自动清理方案，更新时为 nil 表示不修改
+}
+
+// Default 默认设置
+func Default() Settings {
+	return Settings{
+		Version:    FileVersion,
+		LargeFiles: LargeFiles{MinSize: services.DefaultLargeFileMinSize},
+		HelperMode: HelperOnDemand,
+		Locale:     apperr.DefaultLocale,
+	}
+}
+
+// Catalog 在 base 的基础上应用过滤条件和默认选中的清理项
+func (s *Settings) Catalog(base *catalog.Catalog) (*catalog.Catalog, error) {
+	cat, err := base.WithFilters(s.Filters)
+	if err != nil {
+		return nil, err
+	}
+	if s.EnabledItems != nil {
+		cat = cat.WithChecked(s.EnabledItems)
+	}
+	return cat, nil
+}
+
+// file 设置文件内容（受保护路径和自动清理方案不在其中）
+type file struct {
+	Version      int                        `json:"version"`
+	LargeFiles   LargeFiles                 `json:"largeFiles"`
+	EnabledItems []string                   `json:"enabledItems"`
+	Filters      map[string]*catalog.Filter `json:"filters,omitempty"`
+	HelperMode   string                     `json:"helperMode"`
+	Locale       string                     `json:"locale"`
+}
+
+// migrations 设置文件的升级步骤，migrations[v] 把版本 v 的内容升级到版本 v+1
+var migrations = []func(doc map[string]json.RawMessage) error{
+	// 0：没有 version 字段的文件（手动编写），字段与版本 1 相同
+	func(doc map[string]json.RawMessage) error { return nil },
+	// 1：增加 locale，之前的版本只有中文提示
+	func(doc map[string]json.RawMessage) error {
+		if _, ok := doc["locale"]; !ok {
+			doc["locale"], _ = json.Marshal(apperr.DefaultLocale)
+		}
+		return nil
+	},
+}
+
+// migrate 把设置文件 path 的内容升级到当前版本
+func migrate(path string, content []byte) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, apperr.Wrap(apperr.FileInvalid, err, "file", path)
+	}
+	version := 0
+	if raw, ok := doc["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, apperr.Wrap(apperr.FileInvalid, err, "file", path)
+		}
+	}
+	if version > FileVersion {
+		return nil, ErrNewerVersion
+	}
+	if version < 0 {
+		return nil, apperr.New(apperr.FileVersion, "file", path, "version", version)
+	}
+	for ; version < FileVersion; version++ {
+		if err := migrations[version](doc); err != nil {
+			return nil, apperr.Wrap(apperr.FileInvalid, err, "file", path)
+		}
+	}
+	doc["version"], _ = json.Marshal(FileVersion)
+	return json.Marshal(doc)
+}
+
+// Read 读取设置文件（升级到当前版本），文件不存在时返回默认设置。不包括受保护路径和自动清理方案
+func Read(filesystem fsys.FS, path string) (*Settings, error) {
+	settings := Default()
+	content, err := filesystem.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &settings, nil
+		}
+		return nil, apperr.Wrap(apperr.ReadFailed, err, "file", path)
+	}
+	content, err = migrate(path, content)
+	if err != nil {
+		return nil, err
+	}
+	f := file{LargeFiles: settings.LargeFiles, HelperMode: settings.HelperMode, Locale: settings.Locale}
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, apperr.Wrap(apperr.FileInvalid, err, "file", path)
+	}
+	settings.LargeFiles = f.LargeFiles
+	settings.EnabledItems = f.EnabledItems
+	settings.Filters = f.Filters
+	settings.HelperMode = f.HelperMode
+	settings.Locale = f.Locale
+	return &settings, nil
+}
+
+// Store 用户设置（并发安全）
+type Store struct {
+	fs        fsys.FS
+	path      string
+	catalog   *catalog.Catalog     // 清理规则（不含用户设置），用于校验清理项和过滤条件
+	protect   *protect.List        // 受保护路径，为空时设置中不包括
+	scheduler *scheduler.Scheduler // 自动清理方案，为空时设置中不包括
+
+	mu       sync.Mutex
+	current  Settings
+	readOnly bool // 设置文件由更新版本的程序创建，不覆盖
+}
+
+// DefaultPath 默认的设置文件位置（用户配置目录，无法获取时使用程序目录）
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		exePath, _ := os.Executable()
+		dir = filepath.Dir(exePath)
+	}
+	return filepath.Join(dir, "CCooler", "settings.json")
+}
+
+// Open 读取设置文件，文件不存在时使用默认设置；文件无效时使用默认设置并返回错误。
+// cat 为不含用户设置的清理规则
+func Open(filesystem fsys.FS, path string, cat *catalog.Catalog) (*Store, error) {
+	s := &Store{fs: filesystem, path: path, catalog: cat, current: Default()}
+	settings, err := Read(filesystem, path)
+	if err == nil {
+		err = s.validate(settings)
+	}
+	if err != nil {
+		s.readOnly = errors.Is(err, ErrNewerVersion)
+		return s, err
+	}
+	s.current = *settings
+	return s, nil
+}
+
+// Path 设置文件位置
+func (s *Store) Path() string {
+	return s.path
+}
+
+// SetProtected 设置受保护路径（设置中的 exclusions）
+func (s *Store) SetProtected(list *protect.List) {
+	s.protect = list
+}
+
+// SetScheduler 设置自动清理调度器（设置中的 profiles）
+func (s *Store) SetScheduler(sched *scheduler.Scheduler) {
+	s.scheduler = sched
+}
+
+// Current 返回保存在设置文件中的部分（不包括受保护路径和自动清理方案，不读取文件）
+func (s *Store) Current() Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return clone(s.current)
+}
+
+// Get 返回全部设置
+func (s *Store) Get() (*Settings, error) {
+	settings := s.Current()
+	settings.Exclusions = s.protect.Rules()
+	settings.Profiles = []*scheduler.Profile{}
+	if s.scheduler != nil {
+		profiles, err := s.scheduler.Profiles()
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range profiles {
+			settings.Profiles = append(settings.Profiles, status.Profile)
+		}
+	}
+	return &settings, nil
+}
+
+// Update 校验并保存全部设置，返回保存后的设置。任一部分无效时不修改任何设置；
+// 各文件先写临时文件再替换，不会留下写了一半的文件
+func (s *Store) Update(settings *Settings) (*Settings, error) {
+	updated := clone(*settings)
+	updated.Version = FileVersion
+	if updated.HelperMode == "" {
+		updated.HelperMode = HelperOnDemand
+	}
+	if updated.Locale == "" {
+		updated.Locale = apperr.DefaultLocale
+	}
+	if err := s.validate(&updated); err != nil {
+		return nil, err
+	}
+	if s.protect != nil && !unchanged(updated.Exclusions) {
+		if err := protect.Validate(updated.Exclusions); err != nil {
+			return nil, err
+		}
+	}
+	if s.scheduler != nil && updated.Profiles != nil {
+		if err := s.scheduler.ValidateProfiles(updated.Profiles); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.save(&updated); err != nil {
+		return nil, err
+	}
+	return s.Get()
+}
+
+// unchanged 更新时没有提供受保护路径（三个列表都为 nil），保留原有规则
+func unchanged(rules models.ProtectedRules) bool {
+	return rules.Paths == nil && rules.Globs == nil && rules.Extensions == nil
+}
+
+// save 保存自动清理方案、受保护路径和设置文件（已校验）
+func (s *Store) save(settings *Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.readOnly {
+		return apperr.New(apperr.SettingsReadOnly)
+	}
+
+	content, err := json.MarshalIndent(file{
+		Version:      FileVersion,
+		LargeFiles:   settings.LargeFiles,
+		EnabledItems: settings.EnabledItems,
+		Filters:      settings.Filters,
+		HelperMode:   settings.HelperMode,
+		Locale:       settings.Locale,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if s.scheduler != nil && settings.Profiles != nil {
+		if _, err := s.scheduler.ReplaceProfiles(settings.Profiles); err != nil {
+			return err
+		}
+	}
+	if s.protect != nil && !unchanged(settings.Exclusions) {
+		if err := s.protect.Set(settings.Exclusions); err != nil {
+			return err
+		}
+	}
+	if err := s.fs.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return apperr.Wrap(apperr.SaveFailed, err, "file", s.path)
+	}
+	if err := fsys.WriteFileAtomic(s.fs, s.path, content, 0644); err != nil {
+		return apperr.Wrap(apperr.SaveFailed, err, "file", s.path)
+	}
+	s.current = clone(*settings)
+	s.current.Exclusions, s.current.Profiles = models.ProtectedRules{}, nil
+	return nil
+}
+
+// validate 校验保存在设置文件中的部分，清理项去重
+func (s *Store) validate(settings *Settings) error {
+	if settings.LargeFiles.MinSize < MinLargeFileSize {
+		return apperr.New(apperr.LargeFileMinSize)
+	}
+	for category, size := range settings.LargeFiles.Categories {
+		if !slices.Contains(services.ThresholdCategories, category) {
+			return apperr.New(apperr.UnknownCategory, "category", category)
+		}
+		if size < MinLargeFileSize {
+			return apperr.New(apperr.CategoryMinSize, "category", category)
+		}
+	}
+
+	if settings.EnabledItems != nil {
+		enabled := []string{}
+		for _, id := range settings.EnabledItems {
+			if _, ok := s.catalog.Rule(id); !ok {
+				return apperr.New(apperr.UnknownItem, "id", id)
+			}
+			if !slices.Contains(enabled, id) {
+				enabled = append(enabled, id)
+			}
+		}
+		settings.EnabledItems = enabled
+	}
+	if _, err := s.catalog.WithFilters(settings.Filters); err != nil {
+		return err
+	}
+
+	switch settings.HelperMode {
+	case HelperOnDemand, HelperSession:
+	default:
+		return apperr.New(apperr.UnknownHelperMode, "mode", settings.HelperMode)
+	}
+	if !slices.Contains(apperr.Locales(), settings.Locale) {
+		return apperr.New(apperr.UnsupportedLocale, "locale", settings.Locale)
+	}
+	return nil
+}
+
+// clone 复制设置（切片和 map 不与原设置共用）
+func clone(settings Settings) Settings {
+	copied := settings
+	copied.LargeFiles.Categories = maps.Clone(settings.LargeFiles.Categories)
+	copied.EnabledItems = slices.Clone(settings.EnabledItems)
+	copied.Filters = maps.Clone(settings.Filters)
+	copied.Profiles = slices.Clone(settings.Profiles)
+	return copied
+}
@@ -0,0 +1,437 @@
+// Package trend 系统盘空间趋势：保存剩余空间采样（每小时一个）和目录大小快照，
+// 按已用空间的线性趋势估算增长速度和磁盘写满的时间，比较前后两次快照找出增长最多的目录。
+// 采样和快照保存在用户配置目录。
+package trend
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/fsys"
+	"ccooler/backend/ops"
+	"ccooler/backend/walker"
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileVersion 趋势文件格式版本
+const FileVersion = 1
+
+const (
+	PointInterval     = time.Hour            // 每小时最多保存一个采样
+	PointRetention    = 180 * 24 * time.Hour // 采样保留 180 天
+	SnapshotInterval  = 24 * time.Hour       // 后台每天最多生成一次目录快照
+	SnapshotDepth     = 2                    // 快照统计到第几级目录（更深的目录计入第 2 级目录）
+	maxSnapshots      = 14                   // 最多保留的快照
+	minFolderSize     = 1 << 20              // 快照中不保存小于 1 MB 的目录
+	minForecastWindow = time.Hour            // 采样跨度小于该值时不估算趋势
+	recentWindow      = 7 * 24 * time.Hour   // 近期增长速度的时间范围
+	startDelay        = 10 * time.Minute     // 程序启动后多久开始检查是否需要生成快照
+)
+
+// ErrSnapshotRunning 正在生成目录快照
+var ErrSnapshotRunning = apperr.New(apperr.SnapshotRunning)
+
+// Point 一次空间采样
+type Point struct {
+	Time  time.Time `json:"time"`
+	Total int64     `json:"total"`
+	Used  int64     `json:"used"`
+}
+
+// Snapshot 目录大小快照：第 SnapshotDepth 级及以上目录的大小（更深的目录计入其第 SnapshotDepth 级上级目录）
+type Snapshot struct {
+	Time    time.Time        `json:"time"`
+	Root    string           `json:"root"`
+	Folders map[string]int64 `json:"folders"`
+}
+
+// DayChange 某一天结束时的已用空间及与前一天相比的变化
+type DayChange struct {
+	Date   string `json:"date"` // 2006-01-02
+	Used   int64  `json:"used"`
+	Change int64  `json:"change"` // 第一天为 0
+}
+
+// FolderGrowth 目录在两次快照之间的增长
+type FolderGrowth struct {
+	Path   string `json:"path"`
+	Before int64  `json:"before"`
+	After  int64  `json:"after"`
+	Growth int64  `json:"growth"`
+}
+
+// Forecast 趋势分析结果
+type Forecast struct {
+	Points []Point     `json:"points"` // 时间范围内的采样
+	Daily  []DayChange `json:"daily"`  // 每天的变化
+
+	// 已用空间的线性趋势（最小二乘拟合），采样不足时为零值
+	Trend              []Point   `json:"trend"`              // 趋势线：第一个采样、最后一个采样和预计写满时（如果在时间范围内）的拟合值
+	GrowthPerDay       int64     `json:"growthPerDay"`       // 字节/天，负数表示已用空间在减少
+	GrowthPerWeek      int64     `json:"growthPerWeek"`      // 字节/周
+	RecentGrowthPerDay int64     `json:"recentGrowthPerDay"` // 最近 7 天的增长速度
+	R2                 float64   `json:"r2"`                 // 拟合优度（0-1）
+	FullAt             time.Time `json:"fullAt,omitempty"`   // 预计写满的时间，已用空间没有增长时为零值
+	DaysLeft           float64   `json:"daysLeft,omitempty"` // 距写满的天数
+
+	// 增长最多的目录（比较时间范围内最早和最新的快照，少于两个快照时为空）
+	Folders      []FolderGrowth `json:"folders"`
+	SnapshotFrom time.Time      `json:"snapshotFrom,omitempty"`
+	SnapshotTo   time.Time      `json:"snapshotTo,omitempty"`
+}
+
+// data 趋势文件内容
+type data struct {
+	Version   int         `json:"version"`
+	Points    []Point     `json:"points"`
+	Snapshots []*Snapshot `json:"snapshots"`
+}
+
+// Tracker 空间趋势（并发安全）
+type Tracker struct {
+	fs    fsys.FS
+	path  string
+	index *walker.Index // 目录大小索引，为空时每次完整遍历
+
+	mu           sync.Mutex // 保护文件读写
+	snapshotting bool
+}
+
+// DefaultPath 默认的趋势文件位置（用户配置目录，无法获取时使用程序目录）
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		exePath, _ := os.Executable()
+		dir = filepath.Dir(exePath)
+	}
+	return filepath.Join(dir, "CCooler", "trend.json")
+}
+
+// New 创建空间趋势
+func New(filesystem fsys.FS, file string) *Tracker {
+	return &Tracker{fs: filesystem, path: file}
+}
+
+// SetSizeIndex 设置目录大小索引（生成快照时只重新遍历有变化的目录）
+func (t *Tracker) SetSizeIndex(index *walker.Index) {
+	t.index = index
+}
+
+// load 读取趋势文件，文件不存在时返回空内容
+func (t *Tracker) load() (*data, error) {
+	d := &data{Version: FileVersion}
+	content, err := t.fs.ReadFile(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return d, nil
+		}
+		return nil, apperr.Wrap(apperr.ReadFailed, err, "file", t.path)
+	}
+	if err := json.Unmarshal(content, d); err != nil {
+		return nil, apperr.Wrap(apperr.FileInvalid, err, "file", t.path)
+	}
+	if d.Version != FileVersion {
+		return nil, apperr.New(apperr.FileVersion, "file", t.path, "version", d.Version)
+	}
+	return d, nil
+}
+
+// save 写入趋势文件
+func (t *Tracker) save(d *data) error {
+	content, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	if err := t.fs.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return apperr.Wrap(apperr.SaveFailed, err, "file", t.path)
+	}
+	if err := t.fs.WriteFile(t.path, content, 0644); err != nil {
+		return apperr.Wrap(apperr.SaveFailed, err, "file", t.path)
+	}
+	return nil
+}
+
+// update 读取、修改并写入趋势文件
+func (t *Tracker) update(fn func(d *data) bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d, err := t.load()
+	if err != nil {
+		return err
+	}
+	if !fn(d) {
+		return nil
+	}
+	return t.save(d)
+}
+
+// Add 记录一次采样（距上一个采样不到 PointInterval 时忽略），删除超过 PointRetention 的采样
+func (t *Tracker) Add(now time.Time, total, free int64) error {
+	return t.update(func(d *data) bool {
+		if n := len(d.Points); n > 0 && now.Sub(d.Points[n-1].Time) < PointInterval {
+			return false
+		}
+		d.Points = append(d.Points, Point{Time: now, Total: total, Used: total - free})
+		cutoff := now.Add(-PointRetention)
+		i := 0
+		for i < len(d.Points) && d.Points[i].Time.Before(cutoff) {
+			i++
+		}
+		d.Points = append([]Point{}, d.Points[i:]...)
+		return true
+	})
+}
+
+// SnapshotDue 距上次快照是否已超过 SnapshotInterval
+func (t *Tracker) SnapshotDue(now time.Time) bool {
+	t.mu.Lock()
+	d, err := t.load()
+	t.mu.Unlock()
+	if err != nil {
+		return false
+	}
+	n := len(d.Snapshots)
+	return n == 0 || now.Sub(d.Snapshots[n-1].Time) >= SnapshotInterval
+}
+
+// TakeSnapshot 遍历 root 生成目录大小快照并保存（ctx 取消时不保存）。
+// 使用目录大小索引时只有增删过文件的目录会重新统计，文件内容变大不会反映在快照中
+func (t *Tracker) TakeSnapshot(ctx context.Context, root string) (*Snapshot, error) {
+	t.mu.Lock()
+	if t.snapshotting {
+		t.mu.Unlock()
+		return nil, ErrSnapshotRunning
+	}
+	t.snapshotting = true
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.snapshotting = false
+		t.mu.Unlock()
+	}()
+
+	snapshot := &Snapshot{Time: time.Now(), Root: root, Folders: make(map[string]int64)}
+	var mu sync.Mutex
+	_, err := walker.Walk(ctx, t.fs, root, walker.Options{
+		Index:   t.index,
+		Profile: walker.ProfileSize,
+		OnDir: func(path string, stats walker.DirStats) {
+			if stats.Size == 0 {
+				return
+			}
+			folder := folderAt(root, path)
+			mu.Lock()
+			snapshot.Folders[folder] += stats.Size
+			mu.Unlock()
+		},
+	})
+	if ops.Cancelled(ctx) {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.index != nil {
+		t.index.Save()
+	}
+
+	for folder, size := range snapshot.Folders {
+		if size < minFolderSize {
+			delete(snapshot.Folders, folder)
+		}
+	}
+	err = t.update(func(d *data) bool {
+		d.Snapshots = append(d.Snapshots, snapshot)
+		if len(d.Snapshots) > maxSnapshots {
+			d.Snapshots = append([]*Snapshot{}, d.Snapshots[len(d.Snapshots)-maxSnapshots:]...)
+		}
+		return true
+	})
+	return snapshot, err
+}
+
+// Start 程序启动 startDelay 后开始每小时检查一次，距上次快照超过 SnapshotInterval 时生成 root 的快照，
+// 直到 ctx 取消（onError 接收生成快照失败的错误）
+func (t *Tracker) Start(ctx context.Context, root string, onError func(error)) {
+	timer := time.NewTimer(startDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if t.SnapshotDue(time.Now()) {
+			if _, err := t.TakeSnapshot(ctx, root); err != nil && err != ErrSnapshotRunning && ctx.Err() == nil && onError != nil {
+				onError(err)
+			}
+		}
+		timer.Reset(PointInterval)
+	}
+}
+
+// folderAt 返回 path 的第 SnapshotDepth 级上级目录（path 本身不超过该级时返回 path）
+func folderAt(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return root
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if len(parts) > SnapshotDepth {
+		parts = parts[:SnapshotDepth]
+	}
+	return filepath.Join(append([]string{root}, parts...)...)
+}
+
+// Forecast 分析最近 window 内的采样和快照，返回增长最多的 top 个目录
+func (t *Tracker) Forecast(now time.Time, window time.Duration, top int) (*Forecast, error) {
+	t.mu.Lock()
+	d, err := t.load()
+	t.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	since := now.Add(-window)
+	f := &Forecast{Points: []Point{}, Daily: []DayChange{}, Trend: []Point{}, Folders: []FolderGrowth{}}
+	for _, p := range d.Points {
+		if !p.Time.Before(since) && !p.Time.After(now) {
+			f.Points = append(f.Points, p)
+		}
+	}
+	f.Daily = daily(f.Points)
+
+	if slope, intercept, r2, ok := fit(f.Points); ok {
+		first, last := f.Points[0], f.Points[len(f.Points)-1]
+		f.GrowthPerDay = int64(slope)
+		f.GrowthPerWeek = int64(slope * 7)
+		f.R2 = r2
+		f.Trend = append(f.Trend,
+			Point{Time: first.Time, Total: first.Total, Used: fitted(slope, intercept, first.Time)},
+			Point{Time: last.Time, Total: last.Total, Used: fitted(slope, intercept, last.Time)})
+		if used := fitted(slope, intercept, last.Time); slope > 0 && used < last.Total {
+			days := float64(last.Total-used) / slope
+			f.FullAt = last.Time.Add(time.Duration(days * float64(24*time.Hour)))
+			f.DaysLeft = math.Max(f.FullAt.Sub(now).Hours()/24, 0)
+			if f.FullAt.Before(now.Add(window)) {
+				f.Trend = append(f.Trend, Point{Time: f.FullAt, Total: last.Total, Used: last.Total})
+			}
+		} else if slope > 0 {
+			// 按趋势已经写满
+			f.FullAt = last.Time
+		}
+	}
+	var recent []Point
+	for _, p := range f.Points {
+		if !p.Time.Before(now.Add(-recentWindow)) {
+			recent = append(recent, p)
+		}
+	}
+	if slope, _, _, ok := fit(recent); ok {
+		f.RecentGrowthPerDay = int64(slope)
+	}
+
+	var snapshots []*Snapshot
+	for _, s := range d.Snapshots {
+		if !s.Time.Before(since) && !s.Time.After(now) {
+			snapshots = append(snapshots, s)
+		}
+	}
+	if len(snapshots) >= 2 {
+		from, to := snapshots[0], snapshots[len(snapshots)-1]
+		f.SnapshotFrom, f.SnapshotTo = from.Time, to.Time
+		f.Folders = growth(from, to, top)
+	}
+	return f, nil
+}
+
+// fit 最小二乘拟合已用空间随时间（天）的变化，返回斜率（字节/天）、截距（以 Unix 纪元为 0 天）和 R²
+func fit(points []Point) (slope, intercept, r2 float64, ok bool) {
+	if len(points) < 2 || points[len(points)-1].Time.Sub(points[0].Time) < minForecastWindow {
+		return 0, 0, 0, false
+	}
+	// 以第一个采样为原点，避免大数相减损失精度
+	origin := points[0].Time
+	n := float64(len(points))
+	var sx, sy, sxx, sxy float64
+	for _, p := range points {
+		x := p.Time.Sub(origin).Hours() / 24
+		y := float64(p.Used)
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+	denominator := n*sxx - sx*sx
+	if denominator == 0 {
+		return 0, 0, 0, false
+	}
+	slope = (n*sxy - sx*sy) / denominator
+	intercept = (sy - slope*sx) / n
+
+	mean := sy / n
+	var total, residual float64
+	for _, p := range points {
+		x := p.Time.Sub(origin).Hours() / 24
+		y := float64(p.Used)
+		total += (y - mean) * (y - mean)
+		residual += (y - slope*x - intercept) * (y - slope*x - intercept)
+	}
+	r2 = 1
+	if total > 0 {
+		r2 = math.Max(1-residual/total, 0)
+	}
+	// 截距换算到以 Unix 纪元为 0 天
+	intercept -= slope * float64(origin.Unix()) / 86400
+	return slope, intercept, r2, true
+}
+
+// fitted 趋势线在 t 时的已用空间
+func fitted(slope, intercept float64, t time.Time) int64 {
+	return int64(slope*float64(t.Unix())/86400 + intercept)
+}
+
+// daily 每天最后一个采样的已用空间（按本地日期）
+func daily(points []Point) []DayChange {
+	days := []DayChange{}
+	for _, p := range points {
+		date := p.Time.Local().Format("2006-01-02")
+		if n := len(days); n > 0 && days[n-1].Date == date {
+			days[n-1].Used = p.Used
+			continue
+		}
+		days = append(days, DayChange{Date: date, Used: p.Used})
+	}
+	for i := 1; i < len(days); i++ {
+		days[i].Change = days[i].Used - days[i-1].Used
+	}
+	return days
+}
+
+// growth 比较两次快照，返回增长最多的 top 个目录（只包括增长的目录）。
+// 快照中不保存小于 minFolderSize 的目录，这类目录按 0 计算
+func growth(from, to *Snapshot, top int) []FolderGrowth {
+	folders := []FolderGrowth{}
+	for path, after := range to.Folders {
+		before := from.Folders[path]
+		if after > before {
+			folders = append(folders, FolderGrowth{Path: path, Before: before, After: after, Growth: after - before})
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		if folders[i].Growth != folders[j].Growth {
+			return folders[i].Growth > folders[j].Growth
+		}
+		return folders[i].Path < folders[j].Path
+	})
+	if top > 0 && len(folders) > top {
+		folders = folders[:top]
+	}
+	return folders
+}
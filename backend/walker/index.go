@@ -0,0 +1,209 @@
+package walker
+
+import (
+	"bytes"
+	"ccooler/backend/fsys"
+	"encoding/gob"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IndexVersion 索引文件格式版本，不一致时丢弃旧索引
+const IndexVersion = 4
+
+// 遍历配置名：同一目录在不同配置下（跳过规则、Match 不同）的统计结果分别缓存
+const (
+	ProfileSize       = "size"       // 全部文件，无跳过规则
+	ProfileLargeFiles = "largefiles" // 大文件扫描（使用非默认大小阈值时加上阈值后缀）
+)
+
+// Record 缓存的 OnFile 文件
+type Record struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// IndexEntry 单个目录的缓存，目录修改时间和子项数量作为指纹
+type IndexEntry struct {
+	ModTime time.Time
+	Entries int // ReadDir 返回的子项数量
+
+	Size          int64 // 目录中直接包含、符合 Match 的文件总大小
+	Files         int   // 文件数
+	ExcludedSize  int64 // 被 Exclude 排除的文件（不计入 Size、Files）
+	ExcludedFiles int
+	Records       []Record // 传给 OnFile 的文件
+}
+
+// Index 持久化的目录大小索引：目录的修改时间在增删、重命名子项时改变，
+// 修改时间和子项数量都未改变的目录直接使用缓存的统计结果，不获取其中每个文件的信息。
+// 文件原地变大不会改变指纹，需要时用 Reset 强制完整重新扫描；Exclude 规则变化后也需要 Reset
+type Index struct {
+	fs   fsys.FS
+	path string
+
+	mu       sync.Mutex
+	profiles map[string]map[string]*IndexEntry
+	dirty    bool
+}
+
+// indexFile 索引文件内容（gob 编码，C 盘全盘索引用 JSON 过大）
+type indexFile struct {
+	Version  int
+	Profiles map[string]map[string]*IndexEntry
+}
+
+// DefaultIndexPath 默认的索引文件位置（用户缓存目录，无法获取时使用临时目录）
+func DefaultIndexPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "CCooler", "size-index.gob")
+}
+
+// OpenIndex 读取索引文件，文件不存在或损坏时返回空索引
+func OpenIndex(filesystem fsys.FS, path string) *Index {
+	ix := &Index{fs: filesystem, path: path, profiles: make(map[string]map[string]*IndexEntry)}
+
+	data, err := filesystem.ReadFile(path)
+	if err != nil {
+		return ix
+	}
+	var file indexFile
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&file); err != nil || file.Version != IndexVersion {
+		return ix
+	}
+	if file.Profiles != nil {
+		ix.profiles = file.Profiles
+	}
+	return ix
+}
+
+// indexKey Windows 路径不区分大小写
+func indexKey(dir string) string {
+	return strings.ToLower(filepath.Clean(dir))
+}
+
+// lookup 返回修改时间和子项数量都未变化的目录缓存
+func (ix *Index) lookup(profile, dir string, modTime time.Time, entries int) (*IndexEntry, bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	entry, ok := ix.profiles[profile][indexKey(dir)]
+	if !ok || !entry.ModTime.Equal(modTime) || entry.Entries != entries {
+		return nil, false
+	}
+	return entry, true
+}
+
+// store 记录目录的遍历结果
+func (ix *Index) store(profile, dir string, entry *IndexEntry) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	entries, ok := ix.profiles[profile]
+	if !ok {
+		entries = make(map[string]*IndexEntry)
+		ix.profiles[profile] = entries
+	}
+	key := indexKey(dir)
+	if old, ok := entries[key]; ok && old.ModTime.Equal(entry.ModTime) && old.Entries == entry.Entries {
+		return // 未变化，不需要重新保存
+	}
+	entries[key] = entry
+	ix.dirty = true
+}
+
+// Invalidate 删除 paths 及其所有子目录的缓存（清理、删除文件后调用）
+func (ix *Index) Invalidate(paths ...string) {
+	if len(paths) == 0 {
+		return
+	}
+	keys := make(map[string]bool, len(paths))
+	prefixes := make([]string, 0, len(paths))
+	for _, path := range paths {
+		key := indexKey(path)
+		keys[key] = true
+		prefixes = append(prefixes, strings.TrimSuffix(key, string(filepath.Separator))+string(filepath.Separator))
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for _, entries := range ix.profiles {
+		for dir := range entries {
+			if keys[dir] || hasAnyPrefix(dir, prefixes) {
+				delete(entries, dir)
+				ix.dirty = true
+			}
+		}
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// DropProfiles 删除名称以 prefix 开头、但不是 keep 的遍历配置的缓存
+// （配置的 Match 条件改变后，旧配置的结果不再使用）
+func (ix *Index) DropProfiles(prefix, keep string) {
+	if ix == nil {
+		return
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for profile := range ix.profiles {
+		if profile != keep && strings.HasPrefix(profile, prefix) {
+			delete(ix.profiles, profile)
+			ix.dirty = true
+		}
+	}
+}
+
+// Reset 清空索引，下次扫描完整遍历所有目录
+func (ix *Index) Reset() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.profiles = make(map[string]map[string]*IndexEntry)
+	ix.dirty = true
+}
+
+// Save 有变化时写入索引文件
+func (ix *Index) Save() error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if !ix.dirty {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(indexFile{Version: IndexVersion, Profiles: ix.profiles}); err != nil {
+		return err
+	}
+	if err := ix.fs.MkdirAll(filepath.Dir(ix.path), 0755); err != nil {
+		return err
+	}
+	if err := fsys.WriteFileAtomic(ix.fs, ix.path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	ix.dirty = false
+	return nil
+}
+
+// recordInfo 由缓存记录还原的文件信息
+type recordInfo struct{ Record }
+
+func (r recordInfo) Name() string       { return r.Record.Name }
+func (r recordInfo) Size() int64        { return r.Record.Size }
+func (r recordInfo) Mode() fs.FileMode  { return 0 }
+func (r recordInfo) ModTime() time.Time { return r.Record.ModTime }
+func (r recordInfo) IsDir() bool        { return false }
+func (r recordInfo) Sys() any           { return nil }
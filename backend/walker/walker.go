@@ -0,0 +1,298 @@
+// Package walker 并行遍历目录树：用有上限的工作协程读取目录，
+// 统一处理重解析点（符号链接、目录联接）、权限错误和跳过规则，并按目录汇总大小。
+package walker
+
+import (
+	"ccooler/backend/fsys"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWorkers 默认的并发读取目录数（SSD 上并发读取明显快于逐个读取）
+var DefaultWorkers = min(max(runtime.NumCPU()*2, 4), 32)
+
+// Options 遍历选项
+type Options struct {
+	Workers  int // 并发读取目录的协程数上限，0 使用 DefaultWorkers
+	MaxDepth int // 最大深度（1 表示只处理 root 的直接子项），0 表示不限制
+
+	// SkipDir 返回 true 的目录整体跳过（不统计、不进入）
+	SkipDir func(path string, d fs.DirEntry) bool
+
+	// Match 只统计返回 true 的文件，为 nil 时统计全部文件
+	Match func(path string, info fs.FileInfo) bool
+
+	// Exclude 返回 true 的文件和目录（包括其下所有内容）不统计、不调用 OnFile，
+	// 符合 Match 的文件大小单独计入 ExcludedSize（用于用户设置的受保护路径）
+	Exclude func(path string) bool
+
+	// OnFile 对每个统计的文件调用（会被多个协程并发调用，调用方需自行加锁）
+	OnFile func(path string, info fs.FileInfo)
+
+	// OnDir 每个目录统计完成后调用（包括使用索引的目录），stats 为目录中直接包含的统计文件（会被并发调用）
+	OnDir func(path string, stats DirStats)
+
+	// PerDir 为 true 时在结果中按目录汇总（只包含有统计文件的目录）
+	PerDir bool
+
+	// Index 不为空时修改时间和子项数量未变化的目录使用缓存的文件统计，不获取每个文件的信息；
+	// Profile 区分不同的 SkipDir/Match 配置，两者都设置时才使用索引
+	Index   *Index
+	Profile string
+}
+
+// DirStats 单个目录中直接包含的文件（不含子目录）
+type DirStats struct {
+	Size  int64 `json:"size"`
+	Files int   `json:"files"`
+
+	ExcludedSize  int64 `json:"excludedSize,omitempty"` // Exclude 排除的文件
+	ExcludedFiles int   `json:"excludedFiles,omitempty"`
+}
+
+// Result 遍历结果
+type Result struct {
+	Size    int64 // 统计文件的总大小
+	Files   int   // 统计的文件数
+	Folders int   // 遍历的目录数（不含 root）
+	Errors  int   // 无法读取的目录或文件数
+	Denied  int   // 其中因权限不足无法读取的数量
+
+	ExcludedSize  int64 // Exclude 排除的文件总大小
+	ExcludedFiles int   // Exclude 排除的文件数
+
+	PerDir map[string]*DirStats // Options.PerDir 为 true 时按目录汇总
+}
+
+// SkipNames 返回按目录名（不区分大小写）跳过的规则
+func SkipNames(names ...string) func(path string, d fs.DirEntry) bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = true
+	}
+	return func(_ string, d fs.DirEntry) bool {
+		return set[strings.ToLower(d.Name())]
+	}
+}
+
+// isReparsePoint 判断目录项是否为重解析点（符号链接、目录联接等），这类目录项不进入也不统计，
+// 避免循环和重复计算其他位置的文件
+func isReparsePoint(mode fs.FileMode) bool {
+	return mode&(fs.ModeSymlink|fs.ModeIrregular) != 0
+}
+
+// walk 一次遍历的共享状态
+type walk struct {
+	ctx  context.Context
+	fs   fsys.FS
+	opts Options
+	sem  chan struct{} // 空闲工作协程名额
+	wg   sync.WaitGroup
+
+	mu     sync.Mutex
+	result Result
+}
+
+// Walk 遍历 root（root 本身是链接时跟随）。root 不存在或无法访问时返回错误；
+// 子目录和文件的错误计入 Errors 后跳过。ctx 取消时尽快停止，返回已统计部分和 ctx 的错误
+func Walk(ctx context.Context, filesystem fsys.FS, root string, opts Options) (*Result, error) {
+	info, err := filesystem.Stat(root)
+	if err != nil {
+		return &Result{}, err
+	}
+	if opts.Profile == "" {
+		opts.Index = nil
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	w := &walk{
+		ctx:  ctx,
+		fs:   filesystem,
+		opts: opts,
+		sem:  make(chan struct{}, workers-1), // 调用方协程占一个名额
+	}
+	if opts.PerDir {
+		w.result.PerDir = make(map[string]*DirStats)
+	}
+
+	w.dir(root, 0, info.ModTime(), w.excluded(root))
+	w.wg.Wait()
+
+	return &w.result, ctx.Err()
+}
+
+// subdir 待遍历的子目录（修改时间为零时表示未知，不使用索引）
+type subdir struct {
+	path     string
+	modTime  time.Time
+	excluded bool // 目录本身或上级目录被 Exclude 排除
+}
+
+func (w *walk) excluded(path string) bool {
+	return w.opts.Exclude != nil && w.opts.Exclude(path)
+}
+
+// dir 遍历一个目录（修改时间和子项数量未变化时文件使用索引中的统计）；有空闲名额时子目录交给新协程，
+// 否则在当前协程中继续（不会因等待名额而阻塞）
+func (w *walk) dir(path string, depth int, modTime time.Time, excluded bool) {
+	if w.ctx.Err() != nil {
+		return
+	}
+	if w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth {
+		return // 目录本身在深度内，其内容不在
+	}
+
+	entries, err := w.fs.ReadDir(path)
+	if err != nil {
+		w.fail(err)
+		return
+	}
+
+	var cached, entry *IndexEntry
+	if w.opts.Index != nil && !modTime.IsZero() {
+		cached, _ = w.opts.Index.lookup(w.opts.Profile, path, modTime, len(entries))
+	}
+	if w.opts.Index != nil && cached == nil {
+		entry = &IndexEntry{ModTime: modTime, Entries: len(entries)}
+	}
+
+	var local Result
+	var own DirStats
+	var subdirs []subdir
+	for _, e := range entries {
+		if isReparsePoint(e.Type()) {
+			continue
+		}
+
+		full := filepath.Join(path, e.Name())
+		if e.IsDir() {
+			if w.opts.SkipDir != nil && w.opts.SkipDir(full, e) {
+				continue
+			}
+			local.Folders++
+			sub := subdir{path: full, excluded: excluded || w.excluded(full)}
+			if info, err := e.Info(); err == nil {
+				sub.modTime = info.ModTime()
+			}
+			subdirs = append(subdirs, sub)
+			continue
+		}
+		if cached != nil {
+			continue // 文件使用缓存的统计，不获取文件信息
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			local.Errors++
+			if os.IsPermission(err) {
+				local.Denied++
+			}
+			continue
+		}
+		if w.opts.Match != nil && !w.opts.Match(full, info) {
+			continue
+		}
+		if excluded || w.excluded(full) {
+			own.ExcludedSize += info.Size()
+			own.ExcludedFiles++
+			continue
+		}
+
+		own.Size += info.Size()
+		own.Files++
+		if w.opts.OnFile != nil {
+			w.opts.OnFile(full, info)
+			if entry != nil {
+				entry.Records = append(entry.Records, Record{Name: e.Name(), Size: info.Size(), ModTime: info.ModTime()})
+			}
+		}
+	}
+
+	if cached != nil {
+		own = DirStats{
+			Size:          cached.Size,
+			Files:         cached.Files,
+			ExcludedSize:  cached.ExcludedSize,
+			ExcludedFiles: cached.ExcludedFiles,
+		}
+		if w.opts.OnFile != nil {
+			for _, record := range cached.Records {
+				w.opts.OnFile(filepath.Join(path, record.Name), recordInfo{record})
+			}
+		}
+	} else if entry != nil && local.Errors == 0 && w.ctx.Err() == nil {
+		// 无法读取部分文件时不缓存，下次重新读取
+		entry.Size = own.Size
+		entry.Files = own.Files
+		entry.ExcludedSize = own.ExcludedSize
+		entry.ExcludedFiles = own.ExcludedFiles
+		w.opts.Index.store(w.opts.Profile, path, entry)
+	}
+
+	w.add(path, own, local)
+	w.children(subdirs, depth)
+}
+
+// add 合并一个目录的统计结果
+func (w *walk) add(path string, own DirStats, local Result) {
+	w.mu.Lock()
+	w.result.Size += own.Size
+	w.result.Files += own.Files
+	w.result.Folders += local.Folders
+	w.result.Errors += local.Errors
+	w.result.Denied += local.Denied
+	w.result.ExcludedSize += own.ExcludedSize
+	w.result.ExcludedFiles += own.ExcludedFiles
+	if w.result.PerDir != nil && own.Files > 0 {
+		w.result.PerDir[path] = &own
+	}
+	w.mu.Unlock()
+
+	if w.opts.OnDir != nil {
+		w.opts.OnDir(path, own)
+	}
+}
+
+// children 遍历子目录
+func (w *walk) children(subdirs []subdir, depth int) {
+	for _, sub := range subdirs {
+		select {
+		case w.sem <- struct{}{}:
+			w.wg.Add(1)
+			go func(sub subdir) {
+				defer func() {
+					<-w.sem
+					w.wg.Done()
+				}()
+				w.dir(sub.path, depth+1, sub.modTime, sub.excluded)
+			}(sub)
+		default:
+			w.dir(sub.path, depth+1, sub.modTime, sub.excluded)
+		}
+	}
+}
+
+// fail 记录无法读取的目录
+func (w *walk) fail(err error) {
+	w.mu.Lock()
+	w.result.Errors++
+	if os.IsPermission(err) {
+		w.result.Denied++
+	}
+	w.mu.Unlock()
+}
+
+// Size 返回目录中所有文件的总大小（忽略无法访问的内容），index 不为空时使用索引
+func Size(ctx context.Context, filesystem fsys.FS, root string, index *Index) int64 {
+	result, _ := Walk(ctx, filesystem, root, Options{Index: index, Profile: ProfileSize})
+	return result.Size
+}
@@ -3,17 +3,17 @@ echo ========================================
 echo Building CCooler
 echo ========================================
 
-echo [1/3] Building main application...
+echo [1/5] Building main application...
 call wails build
 if errorlevel 1 (
     echo Main build failed!
     exit /b 1
 )
 
-echo [2/4] Installing rsrc tool if needed...
+echo [2/5] Installing rsrc tool if needed...
 go install github.com/akavel/rsrc@latest 2>nul
 
-echo [3/4] Compiling resource file...
+echo [3/5] Compiling resource file...
 cd elevated
 rsrc -ico ../build/windows/icon.ico -o elevated.syso
 if errorlevel 1 (
@@ -21,7 +21,7 @@ if errorlevel 1 (
     if exist elevated.syso del elevated.syso
 )
 
-echo [4/4] Building elevated helper...
+echo [4/5] Building elevated helper...
 go build -ldflags "-H windowsgui -s -w" -o ../build/bin/CCoolerElevated.exe
 if errorlevel 1 (
     echo Helper build failed!
@@ -30,8 +30,17 @@ if errorlevel 1 (
 )
 cd ..
 
-echo [5/5] Build complete!
+echo [5/5] Building command line version...
+rem 控制台程序：cmd 和 PowerShell 会等待其结束，可以取得退出码
+go build -tags desktop,production -ldflags "-s -w" -o build/bin/CCoolerCli.exe
+if errorlevel 1 (
+    echo CLI build failed!
+    exit /b 1
+)
+
+echo Build complete!
 echo ========================================
 echo Main: build\bin\CCooler.exe
 echo Helper: build\bin\CCoolerElevated.exe
+echo CLI: build\bin\CCoolerCli.exe
 echo ========================================
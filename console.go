@@ -0,0 +1,63 @@
+package main
+
+import (
+	"ccooler/backend/cli"
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// attachParentProcess AttachConsole 的参数：附加到启动本程序的命令行窗口
+const attachParentProcess = ^uintptr(0)
+
+// runCLI 以命令行方式运行（不创建窗口），返回退出码
+func runCLI(args []string) int {
+	attachConsole()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	app := NewApp()
+	svc := &cli.Services{
+		Clean:      app.cleanService,
+		LargeFiles: app.largeFileService,
+		Software:   app.softwareService,
+		Optimize:   app.optimizeService,
+		WeChat:     app.wechatService,
+		Admin:      app.adminService,
+		Index:      app.sizeIndex,
+		Scheduler:  app.scheduler,
+		History:    app.history,
+	}
+	return cli.Run(ctx, svc, args, os.Stdout, os.Stderr)
+}
+
+// attachConsole CCooler.exe 以窗口程序编译，没有自己的控制台；从命令行启动时附加到父进程的控制台，
+// 重新打开标准输出，使输出显示在命令行窗口中（输出已被重定向时保持不变）。
+// 控制台版本 CCoolerCli.exe 已有控制台，AttachConsole 失败后不做处理
+func attachConsole() {
+	ret, _, _ := syscall.NewLazyDLL("kernel32.dll").NewProc("AttachConsole").Call(attachParentProcess)
+	if ret == 0 {
+		return
+	}
+	if !isValid(os.Stdout) {
+		if f, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+			os.Stdout = f
+		}
+	}
+	if !isValid(os.Stderr) {
+		if f, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+			os.Stderr = f
+		}
+	}
+}
+
+// isValid 标准输出句柄是否可用（被重定向到文件或管道时可用）
+func isValid(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	_, err := f.Stat()
+	return err == nil
+}
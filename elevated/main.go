@@ -2,11 +2,19 @@ package main
 
 import (
 	"bytes"
-	"encoding/json"
+	"ccooler/backend/apperr"
+	"ccooler/backend/catalog"
+	"ccooler/backend/ipc"
+	"ccooler/backend/models"
+	"ccooler/backend/ops"
+	"ccooler/backend/protect"
+	"ccooler/backend/quarantine"
+	"ccooler/backend/services"
+	"ccooler/backend/settings"
+	"context"
 	"flag"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -20,18 +28,26 @@ import (
 )
 
 type TaskResult struct {
-	Success      bool   `json:"success"`
-	Error        string `json:"error,omitempty"`
-	CleanedSize  int64  `json:"cleanedSize"`
-	CleanedCount int    `json:"cleanedCount"`
+	Success      bool          `json:"success"`
+	Error        string        `json:"error,omitempty"`
+	ErrorDetail  *apperr.Error `json:"errorDetail,omitempty"` // 错误码和参数，主程序按自己的语言重新生成提示文字
+	CleanedSize  int64         `json:"cleanedSize"`
+	CleanedCount int           `json:"cleanedCount"`
+
+	// 试运行时返回删除清单
+	Manifest *models.CleanManifest `json:"manifest,omitempty"`
+
+	// 清理报告（实际删除的文件、未能清理的文件及原因、各路径明细）
+	Report *models.CleanReport `json:"report,omitempty"`
+
+	// 任务被主程序取消时为 "cancelled"，结果只包含取消前已完成的部分
+	Status string `json:"status,omitempty"`
 }
 
-type ProgressUpdate struct {
-	ProcessedPaths int    `json:"processedPaths"`
-	TotalPaths     int    `json:"totalPaths"`
-	CleanedSize    int64  `json:"cleanedSize"`
-	CleanedCount   int    `json:"cleanedCount"`
-	CurrentPath    string `json:"currentPath"`
+// failedTask 失败的结果
+func failedTask(err error) *TaskResult {
+	detail := apperr.From(err)
+	return &TaskResult{Success: false, Error: detail.Error(), ErrorDetail: detail}
 }
 
 func main() {
@@ -79,40 +95,169 @@ func main() {
 		f.Close()
 	}
 
-	// 解析命令行参数
-	task := flag.String("task", "", "Task to execute")
+	// 解析命令行参数（任务参数都在主程序写入的任务文件中）
 	port := flag.String("port", "", "Main program HTTP port")
-	paths := flag.String("paths", "", "Paths to clean (comma separated)")
+	taskID := flag.String("task-id", "", "Task ID (or session ID with -session) assigned by the main program")
+	key := flag.String("key", "", "Task key used to sign requests and verify the task file")
+	taskFile := flag.String("task-file", "", "Task file written by the main program (deleted after reading)")
+	session := flag.Bool("session", false, "Stay running and take tasks from the main program until it exits")
+	idleTimeout := flag.Duration("idle-timeout", defaultIdleTimeout, "Exit the session after being idle this long")
 	flag.Parse()
 
-	log.Printf("Args: task=%s, port=%s, paths=%s", *task, *port, *paths)
+	if *session {
+		if *port == "" || *taskID == "" || *key == "" {
+			log.Fatal("Usage: CCoolerElevated.exe -session -port=<port> -task-id=<session id> -key=<key>")
+		}
+		runSession(exeDir, *port, *taskID, *key, *idleTimeout)
+		log.Println("=== CCoolerElevated Finished ===")
+		return
+	}
 
-	if *task == "" || *port == "" {
-		log.Fatal("Usage: CCoolerElevated.exe -task=<task> -port=<port> [-paths=<paths>]")
+	if *port == "" || *taskID == "" || *key == "" || *taskFile == "" {
+		log.Fatal("Usage: CCoolerElevated.exe -port=<port> -task-id=<id> -key=<key> -task-file=<file>")
+	}
+	runTask(exeDir, *port, ipc.SessionTask{ID: *taskID, Key: *key, File: *taskFile})
+	log.Println("=== CCoolerElevated Finished ===")
+}
+
+// runTask 读取任务文件，执行任务并把结果返回主程序
+func runTask(exeDir, port string, t ipc.SessionTask) {
+	// 与主程序的通信（每个请求带任务 ID 和签名）
+	client := ipc.NewClient(port, t.ID, t.Key)
+
+	// 报告生命周期：已启动、已提升权限，执行期间定期发送心跳（主程序据此判断辅助程序是否仍在运行）
+	sendStatus(client, ipc.TaskStatus{State: ipc.StateStarted, PID: os.Getpid()})
+	if checkIsAdmin() {
+		sendStatus(client, ipc.TaskStatus{State: ipc.StateElevated, PID: os.Getpid(), Elevated: true})
+	}
+	defer taskHeartbeat(client)()
+
+	// 读取并校验任务文件
+	task, err := ipc.ReadTaskFile(filesystem, t.File, t.ID, t.Key)
+	if err != nil {
+		log.Printf("Failed to read task file: %v", err)
+		sendResult(client, failedTask(err))
+		return
+	}
+	log.Printf("Task: %s, id=%s, paths=%d, dryRun=%v, manifest=%s, quarantineRun=%s", task.Task, task.TaskID, len(task.Paths), task.DryRun, task.Manifest, task.QuarantineRun)
+
+	// 加载清理规则（与主程序使用同一份规则文件）
+	cat, err := catalog.Load(exeDir)
+	if err != nil {
+		log.Printf("Failed to load clean rules, using builtin: %v", err)
+		cat = catalog.Builtin()
+	}
+
+	// 应用用户设置中的过滤条件（与主程序使用同一个设置文件，设置无效时两者都使用原有规则）
+	settingsPath := task.Settings
+	if settingsPath == "" {
+		settingsPath = settings.DefaultPath()
+	}
+	store, err := settings.Open(filesystem, settingsPath, cat)
+	if err != nil {
+		log.Printf("Failed to load settings: %v", err)
+	}
+	current := store.Current()
+	apperr.SetLocale(current.Locale)
+	if err == nil && current.Filters != nil {
+		if filtered, err := current.Catalog(cat); err == nil {
+			cat = filtered
+		}
+	}
+	if err := task.CheckFilters(cat); err != nil {
+		log.Printf("Clean rules mismatch: %v", err)
+		sendResult(client, failedTask(err))
+		return
 	}
 
 	// 执行任务
 	log.Println("Executing task...")
-	result := executeTask(*task, *paths, *port)
+	cleaner := services.NewCleanService(filesystem, cat)
+
+	// 受保护路径（与主程序使用同一个文件），读取失败时不清理任何内容
+	protectedPath := task.Protected
+	if protectedPath == "" {
+		protectedPath = protect.DefaultPath()
+	}
+	protected, err := protect.Load(filesystem, protectedPath)
+	if err != nil {
+		log.Printf("Failed to load protected paths: %v", err)
+		sendResult(client, failedTask(err))
+		return
+	}
+	cleaner.SetProtected(protected)
+
+	// 主程序取消操作时停止清理，返回已完成的部分
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchCancel(ctx, client, cancel)
+
+	result := executeTask(ctx, cleaner, task, client)
+	if ops.Cancelled(ctx) {
+		log.Println("Task cancelled by main program")
+		result.Status = ops.StatusCancelled
+	}
 	log.Printf("Task completed: success=%v, size=%d, count=%d", result.Success, result.CleanedSize, result.CleanedCount)
 
 	// 返回结果到主程序
 	log.Println("Sending result to main program...")
-	sendResult(*port, result)
-	log.Println("=== CCoolerElevated Finished ===")
+	sendResult(client, result)
 }
 
-func executeTask(task, pathsStr, port string) *TaskResult {
-	// 解析路径列表
-	paths := strings.Split(pathsStr, "|")
+func executeTask(ctx context.Context, cleaner *services.CleanService, task *ipc.TaskFile, client *ipc.Client) *TaskResult {
+	// 路径列表和每个路径所属的清理项
+	paths, itemIDs := task.SplitPaths()
+
+	switch task.Task {
+	case "restore-run":
+		// 恢复隔离区中的清理批次（管理员隔离区中的部分）
+		return restoreRun(task.QuarantineRun)
+	case "delete-run":
+		// 删除隔离区中的清理批次（管理员隔离区中的部分）
+		if err := quarantineStore.Delete(task.QuarantineRun); err != nil {
+			return failedTask(err)
+		}
+		return &TaskResult{Success: true}
+	}
+
+	// 隔离模式：文件移入隔离区而不是直接删除
+	var run *quarantine.Run
+	if task.QuarantineRun != "" && !task.DryRun {
+		var err error
+		run, err = quarantineStore.Begin(task.QuarantineRun, "elevated")
+		if err != nil {
+			return failedTask(err)
+		}
+		defer run.Close()
+	}
+
+	if itemID, ok := strings.CutPrefix(task.Task, "clean-item-"); ok {
+		// 清理单个清理项（清理项由规则文件定义）
+		rule, ok := cleaner.Catalog().Rule(itemID)
+		if !ok {
+			return failedTask(apperr.New(apperr.UnknownItem, "id", itemID))
+		}
+		for i := range itemIDs {
+			itemIDs[i] = itemID
+		}
+		if task.DryRun {
+			return planPaths(ctx, cleaner, paths, itemIDs, rule.Handler)
+		}
+		return cleanPathsWithProgress(ctx, cleaner, paths, itemIDs, client, run)
+	}
 
-	switch task {
-	case "clean-item-1", "clean-item-2", "clean-item-3", "clean-item-4", "clean-item-5", "clean-item-6", "clean-item-7":
-		return cleanPathsWithProgress(paths, port)
+	switch task.Task {
 	case "clean-batch":
 		// 批量清理多个项目（单次UAC）
 		log.Printf("Batch cleaning %d paths", len(paths))
-		return cleanPathsWithProgress(paths, port)
+		if task.DryRun {
+			// 批量任务只包含按目录清理的项目
+			return planPaths(ctx, cleaner, paths, itemIDs, catalog.HandlerFolder)
+		}
+		return cleanPathsWithProgress(ctx, cleaner, paths, itemIDs, client, run)
+	case "clean-manifest":
+		// 按删除清单重放清理
+		return replayManifest(ctx, cleaner, task.Manifest, run)
 	case "optimize-hibernation":
 		// 禁用休眠
 		return executeSystemCommand("powercfg", "/hibernate", "off")
@@ -123,10 +268,7 @@ func executeTask(task, pathsStr, port string) *TaskResult {
 		// 禁用虚拟内存
 		return disablePagefile()
 	default:
-		return &TaskResult{
-			Success: false,
-			Error:   fmt.Sprintf("unknown task: %s", task),
-		}
+		return failedTask(apperr.New(apperr.UnknownTask, "task", task.Task))
 	}
 }
 
@@ -152,10 +294,7 @@ func executeSystemCommand(name string, args ...string) *TaskResult {
 		if convErr != nil {
 			outputStr = string(output) // 转换失败则使用原始输出
 		}
-		return &TaskResult{
-			Success: false,
-			Error:   fmt.Sprintf("命令执行失败: %v, 输出: %s", err, outputStr),
-		}
+		return failedTask(apperr.Wrap(apperr.CommandFailed, err, "output", outputStr))
 	}
 
 	return &TaskResult{
@@ -170,29 +309,14 @@ func disablePagefile() *TaskResult {
 	return executeSystemCommand("powershell", "-NoProfile", "-NonInteractive", "-Command", psScript)
 }
 
-func sendResult(port string, result *TaskResult) {
-	url := fmt.Sprintf("http://127.0.0.1:%s/elevated-result", port)
-
-	data, err := json.Marshal(result)
-	if err != nil {
-		log.Printf("Failed to marshal result: %v", err)
-		return
-	}
-
-	log.Printf("Sending result to %s: %s", url, string(data))
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
-	if err != nil {
+// sendResult 报告任务完成并返回结果到主程序
+func sendResult(client *ipc.Client, result *TaskResult) {
+	sendStatus(client, ipc.TaskStatus{State: ipc.StateDone, PID: os.Getpid()})
+	if err := client.Post("/elevated-result", result); err != nil {
 		log.Printf("Failed to send result: %v", err)
 		return
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Server returned non-OK status: %d", resp.StatusCode)
-	} else {
-		log.Printf("Result sent successfully")
-	}
+	log.Printf("Result sent successfully")
 }
 
 // TOKEN_ELEVATION 结构体
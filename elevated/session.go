@@ -0,0 +1,103 @@
+package main
+
+import (
+	"ccooler/backend/ipc"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// 常驻模式的时间参数
+const (
+	defaultIdleTimeout = 10 * time.Minute // 没有任务时自动退出
+	maxFailures        = 3                // 连续请求失败的次数，超过时认为主程序已退出
+)
+
+// sessionState 常驻模式的状态（心跳 goroutine 并发读取）
+type sessionState struct {
+	mu   sync.Mutex
+	task string // 正在执行的任务 ID
+}
+
+func (s *sessionState) set(task string) {
+	s.mu.Lock()
+	s.task = task
+	s.mu.Unlock()
+}
+
+func (s *sessionState) get() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.task
+}
+
+// runSession 常驻模式：反复向主程序领取任务并逐个执行（一次 UAC 提示执行多个任务）。
+// 主程序要求退出、主程序无响应或空闲超过 idleTimeout 时返回
+func runSession(exeDir, port, sessionID, key string, idleTimeout time.Duration) {
+	log.Printf("Session started: id=%s, idleTimeout=%s", sessionID, idleTimeout)
+	client := ipc.NewClient(port, sessionID, key)
+	state := &sessionState{}
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go heartbeat(ctx, client, state, stop)
+
+	idleSince := time.Now()
+	failures := 0
+	for ctx.Err() == nil {
+		if time.Since(idleSince) > idleTimeout {
+			log.Println("Session idle timeout")
+			return
+		}
+
+		var reply ipc.SessionReply
+		if err := client.Get("/elevated-session/next", &reply); err != nil {
+			if failures++; failures >= maxFailures {
+				log.Printf("Main program not responding, ending session: %v", err)
+				return
+			}
+			time.Sleep(time.Second)
+			continue
+		}
+		failures = 0
+
+		if reply.Exit {
+			log.Println("Session ended by main program")
+			return
+		}
+		if reply.Task == nil {
+			continue // 没有任务，继续等待
+		}
+
+		state.set(reply.Task.ID)
+		runTask(exeDir, port, *reply.Task)
+		state.set("")
+		idleSince = time.Now()
+	}
+	log.Println("Main program not responding, ending session")
+}
+
+// heartbeat 定期向主程序报告会话状态（主程序 15 秒没有收到心跳视为会话结束），主程序连续无响应时调用 stop
+func heartbeat(ctx context.Context, client *ipc.Client, state *sessionState, stop context.CancelFunc) {
+	ticker := time.NewTicker(ipc.HeartbeatInterval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		if err := client.Post("/elevated-session/heartbeat", ipc.SessionHeartbeat{Task: state.get()}); err != nil {
+			if failures++; failures >= maxFailures {
+				stop()
+				return
+			}
+		} else {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
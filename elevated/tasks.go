@@ -1,123 +1,168 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
-	"net/http"
-	"os"
-	"path/filepath"
+	"ccooler/backend/apperr"
+	"ccooler/backend/catalog"
+	"ccooler/backend/fsys"
+	"ccooler/backend/ipc"
+	"ccooler/backend/quarantine"
+	"ccooler/backend/services"
+	"context"
+	"log"
 	"time"
 )
 
-// cleanPathsWithProgress 清理指定的路径列表，并定期报告进度
-func cleanPathsWithProgress(paths []string, port string) *TaskResult {
-	result := &TaskResult{Success: true}
-	totalPaths := len(paths)
+// filesystem 清理使用的文件系统
+var filesystem = fsys.OS()
+
+// quarantineStore 隔离区（只有管理员可以写入的部分，主程序列出批次时也包括）
+var quarantineStore = quarantine.NewAdminStore(filesystem)
+
+// cleanPathsWithProgress 清理指定的路径列表，并定期报告进度。
+// itemIDs 为每个路径所属的清理项（用于应用过滤条件），run 不为空时移入隔离区
+// ctx 取消时停止，返回已完成的部分
+func cleanPathsWithProgress(ctx context.Context, cleaner *services.CleanService, paths, itemIDs []string, client *ipc.Client, run *quarantine.Run) *TaskResult {
+	result := &TaskResult{Success: true, Report: services.NewCleanReport()}
+	tracker := services.NewCleanTracker(func(_ string, data any) {
+		client.Post("/elevated-progress", data)
+	}, len(paths))
+	ctx = services.WithCleanTracker(ctx, tracker)
 
 	for i, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
 		if path == "" {
 			continue
 		}
 
-		// 发送路径切换进度
-		progress := &ProgressUpdate{
-			ProcessedPaths: i,
-			TotalPaths:     totalPaths,
-			CleanedSize:    result.CleanedSize,
-			CleanedCount:   result.CleanedCount,
-			CurrentPath:    path,
-		}
-		sendProgress(port, progress)
-
-		// 清理路径（带定时进度报告）
-		size, count := removeDirectoryWithProgress(path, port, i, totalPaths, result)
-		result.CleanedSize += size
-		result.CleanedCount += count
+		// 清理路径（过滤条件、受保护路径和隔离区由 RemoveFiltered 处理，进度通过 tracker 限频发送）
+		itemID := itemIDAt(itemIDs, i)
+		tracker.BeginPath(itemID, path)
+		report := services.NewCleanReport()
+		cleaner.RemoveFiltered(ctx, path, itemID, run, report)
+		tracker.EndPath()
+		services.MergeCleanReport(result.Report, report)
+		result.CleanedSize += report.FreedSize
+		result.CleanedCount += report.RemovedCount
 	}
 
 	// 发送最终进度
-	finalProgress := &ProgressUpdate{
-		ProcessedPaths: totalPaths,
-		TotalPaths:     totalPaths,
-		CleanedSize:    result.CleanedSize,
-		CleanedCount:   result.CleanedCount,
-		CurrentPath:    "完成",
-	}
-	sendProgress(port, finalProgress)
+	tracker.Finish()
 
 	return result
 }
 
-// sendProgress 发送进度更新到主程序
-func sendProgress(port string, progress *ProgressUpdate) {
-	url := "http://127.0.0.1:" + port + "/elevated-progress"
+// itemIDAt 返回第 i 个路径所属的清理项（未提供时为空，不应用过滤条件）
+func itemIDAt(itemIDs []string, i int) string {
+	if i < len(itemIDs) {
+		return itemIDs[i]
+	}
+	return ""
+}
 
-	data, err := json.Marshal(progress)
-	if err != nil {
-		// 进度发送失败不影响清理继续
-		return
+// planPaths 生成删除清单（试运行，不删除任何文件）
+func planPaths(ctx context.Context, cleaner *services.CleanService, paths, itemIDs []string, handler catalog.HandlerKind) *TaskResult {
+	manifest := services.NewCleanManifest()
+	for i, path := range paths {
+		if path == "" {
+			continue
+		}
+		cleaner.PlanPath(ctx, path, handler, itemIDAt(itemIDs, i), manifest)
+	}
+
+	return &TaskResult{
+		Success:  true,
+		Manifest: manifest,
 	}
+}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
+// replayManifest 按删除清单删除文件
+func replayManifest(ctx context.Context, cleaner *services.CleanService, manifestPath string, run *quarantine.Run) *TaskResult {
+	manifest, err := services.LoadCleanManifest(manifestPath)
 	if err != nil {
-		// 进度发送失败不影响清理继续（可能主程序已关闭）
-		return
+		return failedTask(err)
+	}
+
+	report := services.NewCleanReport()
+	cleanedSize, cleanedCount, err := cleaner.ReplayManifest(ctx, manifest, run, report)
+	if err != nil && ctx.Err() == nil {
+		return failedTask(err)
 	}
-	defer resp.Body.Close()
 
-	// 静默处理非200状态码，不影响清理继续
-	if resp.StatusCode != http.StatusOK {
-		// 不记录日志，避免过多输出
+	return &TaskResult{
+		Success:      true,
+		CleanedSize:  cleanedSize,
+		CleanedCount: cleanedCount,
+		Report:       report,
 	}
 }
 
-// removeDirectoryWithProgress 清理目录并定期发送进度
-func removeDirectoryWithProgress(path, port string, pathIndex, totalPaths int, result *TaskResult) (int64, int) {
-	var totalSize int64
-	var count int
+// restoreRun 恢复隔离区中的清理批次（普通隔离区中的部分由主程序恢复，不计入未恢复的文件）
+func restoreRun(runID string) *TaskResult {
+	restored, err := quarantineStore.Restore(runID)
+	if err != nil {
+		return failedTask(err)
+	}
 
-	// 启动定时器，每2秒发送一次进度
-	ticker := time.NewTicker(2 * time.Second)
+	result := &TaskResult{
+		Success:      true,
+		CleanedSize:  restored.Size,
+		CleanedCount: restored.Count,
+	}
+	if restored.Conflicts > 0 || restored.Failed > 0 {
+		detail := apperr.New(apperr.RestoreIncomplete, "count", restored.Conflicts+restored.Failed)
+		result.Success, result.Error, result.ErrorDetail = false, detail.Error(), detail
+	}
+	return result
+}
+
+// watchCancel 定期向主程序查询是否取消当前任务，需要取消时调用 cancel
+func watchCancel(ctx context.Context, client *ipc.Client, cancel context.CancelFunc) {
+	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
-	done := make(chan struct{})
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var reply struct {
+			Cancel bool `json:"cancel"`
+		}
+		if err := client.Get("/elevated-cancel", &reply); err != nil {
+			continue // 主程序暂时无响应，继续执行
+		}
+		if reply.Cancel {
+			cancel()
+			return
+		}
+	}
+}
+
+// sendStatus 发送任务生命周期消息到主程序
+func sendStatus(client *ipc.Client, status ipc.TaskStatus) {
+	if err := client.Post("/elevated-status", status); err != nil {
+		log.Printf("Failed to send status %s: %v", status.State, err)
+	}
+}
 
-	// 后台goroutine定期发送进度
+// taskHeartbeat 执行期间定期向主程序发送心跳，返回停止函数
+func taskHeartbeat(client *ipc.Client) func() {
+	done := make(chan struct{})
 	go func() {
+		ticker := time.NewTicker(ipc.HeartbeatInterval)
+		defer ticker.Stop()
 		for {
 			select {
-			case <-ticker.C:
-				// 发送当前进度
-				progress := &ProgressUpdate{
-					ProcessedPaths: pathIndex,
-					TotalPaths:     totalPaths,
-					CleanedSize:    result.CleanedSize + totalSize,
-					CleanedCount:   result.CleanedCount + count,
-					CurrentPath:    path,
-				}
-				sendProgress(port, progress)
 			case <-done:
 				return
+			case <-ticker.C:
+				client.Post("/elevated-status", ipc.TaskStatus{State: ipc.StateHeartbeat})
 			}
 		}
 	}()
-
-	// 执行清理
-	filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // 跳过无法访问的文件
-		}
-
-		if !info.IsDir() {
-			totalSize += info.Size()
-			count++
-			os.Remove(filePath) // 忽略错误
-		}
-		return nil
-	})
-
-	// 停止定时器
-	close(done)
-
-	return totalSize, count
+	return func() { close(done) }
 }
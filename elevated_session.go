@@ -0,0 +1,286 @@
+package main
+
+import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/ipc"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// 常驻辅助程序会话的时间参数
+const (
+	sessionPollWait     = 20 * time.Second // 辅助程序领取任务时最长等待
+	sessionAliveTimeout = 15 * time.Second // 超过该时间没有心跳视为会话已结束
+	sessionStartTimeout = 30 * time.Second // UAC 确认后等待第一次心跳
+)
+
+// ElevatedSessionStatus 常驻辅助程序会话状态
+type ElevatedSessionStatus struct {
+	Active    bool   `json:"active"`              // 会话正在运行
+	Busy      bool   `json:"busy"`                // 正在执行任务
+	Task      string `json:"task,omitempty"`      // 正在执行的任务 ID
+	Tasks     int    `json:"tasks"`               // 已交给会话的任务数
+	LastSeen  string `json:"lastSeen,omitempty"`  // 最后一次心跳时间
+	StartedAt string `json:"startedAt,omitempty"` // 会话开始时间
+}
+
+// elevatedSession 常驻的辅助程序：启动一次（一次 UAC 提示），之后通过已认证的本地通道领取多个任务
+type elevatedSession struct {
+	id      string
+	tasks   chan ipc.SessionTask // 等待辅助程序领取的任务
+	closed  chan struct{}
+	started time.Time
+
+	mu        sync.Mutex
+	lastSeen  time.Time
+	busy      string // 正在执行的任务 ID
+	submitted int
+	closeOnce sync.Once
+}
+
+// alive 最近是否收到过心跳
+func (s *elevatedSession) alive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.lastSeen.IsZero() && time.Since(s.lastSeen) < sessionAliveTimeout
+}
+
+// seen 收到辅助程序的请求
+func (s *elevatedSession) seen(heartbeat *ipc.SessionHeartbeat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSeen = time.Now()
+	if heartbeat != nil {
+		s.busy = heartbeat.Task
+	}
+}
+
+func (s *elevatedSession) close() {
+	s.closeOnce.Do(func() { close(s.closed) })
+}
+
+// submit 把任务交给会话，会话忙时排队等待；会话已结束时返回 false（改为单次启动辅助程序）
+func (s *elevatedSession) submit(ctx context.Context, task ipc.SessionTask) (bool, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		if !s.alive() {
+			return false, nil
+		}
+		select {
+		case s.tasks <- task:
+			s.mu.Lock()
+			s.submitted++
+			s.mu.Unlock()
+			return true, nil
+		case <-s.closed:
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// StartElevatedSession 启动常驻的辅助程序（弹出一次 UAC 提示），之后需要管理员权限的任务都交给它执行，
+// 不再逐个弹出 UAC。会话在主程序退出、调用 StopElevatedSession 或空闲超时后结束
+func (a *App) StartElevatedSession() (*ElevatedSessionStatus, error) {
+	if a.IsElevated() {
+		return nil, apperr.New(apperr.AlreadyElevated)
+	}
+	if session := a.currentSession(); session != nil && session.alive() {
+		return a.GetElevatedSessionStatus(), nil
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		return nil, apperr.Wrap(apperr.ExePath, err)
+	}
+	helperPath := filepath.Join(filepath.Dir(exePath), helperName)
+	if _, err := os.Stat(helperPath); err != nil {
+		return nil, apperr.New(apperr.HelperMissing, "helper", helperName)
+	}
+
+	session := &elevatedSession{
+		id:      ipc.NewTaskID("session"),
+		tasks:   make(chan ipc.SessionTask),
+		closed:  make(chan struct{}),
+		started: time.Now(),
+	}
+	key := a.ipcServer.Register(session.id)
+
+	a.sessionMutex.Lock()
+	if a.session != nil {
+		a.endSession(a.session)
+	}
+	a.session = session
+	a.sessionMutex.Unlock()
+
+	args := fmt.Sprintf("-session -port=%s -task-id=%s -key=%s", a.httpPort, session.id, key)
+	process, err := a.shellExecuteElevated(helperPath, args)
+	if err != nil {
+		a.StopElevatedSession()
+		if errors.Is(err, ErrUACDeclined) {
+			return nil, err
+		}
+		return nil, apperr.Wrap(apperr.HelperStartFailed, err)
+	}
+
+	// 辅助程序退出时立即结束会话，之后的任务单次启动辅助程序
+	exited := watchProcess(process)
+	go func() {
+		code := <-exited
+		runtime.LogInfof(a.ctx, "常驻辅助程序已退出（退出码 %d）", code)
+		a.sessionMutex.Lock()
+		if a.session == session {
+			a.endSession(session)
+			a.session = nil
+		}
+		a.sessionMutex.Unlock()
+	}()
+
+	// 等待辅助程序的第一次心跳
+	deadline := time.Now().Add(sessionStartTimeout)
+	for !session.alive() {
+		select {
+		case <-session.closed:
+			return nil, apperr.New(apperr.HelperStartExited)
+		default:
+		}
+		if time.Now().After(deadline) {
+			a.StopElevatedSession()
+			return nil, apperr.New(apperr.HelperNotStarted, "seconds", int(sessionStartTimeout.Seconds()))
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	runtime.LogInfof(a.ctx, "常驻辅助程序已启动: %s", session.id)
+	return a.GetElevatedSessionStatus(), nil
+}
+
+// StopElevatedSession 结束常驻的辅助程序（正在执行的任务完成后退出）
+func (a *App) StopElevatedSession() {
+	a.sessionMutex.Lock()
+	defer a.sessionMutex.Unlock()
+	if a.session != nil {
+		a.endSession(a.session)
+		a.session = nil
+	}
+}
+
+// GetElevatedSessionStatus 返回常驻辅助程序的状态
+func (a *App) GetElevatedSessionStatus() *ElevatedSessionStatus {
+	session := a.currentSession()
+	if session == nil {
+		return &ElevatedSessionStatus{}
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	status := &ElevatedSessionStatus{
+		Active:    !session.lastSeen.IsZero() && time.Since(session.lastSeen) < sessionAliveTimeout,
+		Busy:      session.busy != "",
+		Task:      session.busy,
+		Tasks:     session.submitted,
+		StartedAt: session.started.Format(time.RFC3339),
+	}
+	if !session.lastSeen.IsZero() {
+		status.LastSeen = session.lastSeen.Format(time.RFC3339)
+	}
+	return status
+}
+
+// currentSession 返回当前会话（没有时为 nil）
+func (a *App) currentSession() *elevatedSession {
+	a.sessionMutex.Lock()
+	defer a.sessionMutex.Unlock()
+	return a.session
+}
+
+// endSession 通知辅助程序退出并注销会话（调用方持有 sessionMutex）
+func (a *App) endSession(session *elevatedSession) {
+	session.close()
+	a.ipcServer.Unregister(session.id)
+}
+
+// submitToSession 有可用的会话时把任务交给它执行，返回 false 时应单次启动辅助程序
+func (a *App) submitToSession(ctx context.Context, task *elevatedTask) (bool, error) {
+	session := a.currentSession()
+	if session == nil {
+		return false, nil
+	}
+	ok, err := session.submit(ctx, ipc.SessionTask{ID: task.id, Key: task.key, File: task.file})
+	if !ok && err == nil {
+		runtime.LogInfo(a.ctx, "常驻辅助程序已结束，改为单次启动辅助程序")
+		a.sessionMutex.Lock()
+		if a.session == session {
+			a.endSession(session)
+			a.session = nil
+		}
+		a.sessionMutex.Unlock()
+	}
+	return ok, err
+}
+
+// verifySession 校验会话请求（必须来自当前会话）
+func (a *App) verifySession(w http.ResponseWriter, r *http.Request) (*elevatedSession, []byte, bool) {
+	taskID, body, ok := a.verifyElevated(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	session := a.currentSession()
+	if session == nil || session.id != taskID {
+		http.Error(w, ipc.ErrUnknownTask.Error(), http.StatusForbidden)
+		return nil, nil, false
+	}
+	return session, body, true
+}
+
+// handleSessionHeartbeat 辅助程序定期报告状态
+func (a *App) handleSessionHeartbeat(w http.ResponseWriter, r *http.Request) {
+	session, body, ok := a.verifySession(w, r)
+	if !ok {
+		return
+	}
+	var heartbeat ipc.SessionHeartbeat
+	if err := json.Unmarshal(body, &heartbeat); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	session.seen(&heartbeat)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleSessionNext 辅助程序领取下一个任务（长轮询，没有任务时返回空回复）
+func (a *App) handleSessionNext(w http.ResponseWriter, r *http.Request) {
+	session, _, ok := a.verifySession(w, r)
+	if !ok {
+		return
+	}
+	session.seen(nil)
+
+	var reply ipc.SessionReply
+	select {
+	case task := <-session.tasks:
+		reply.Task = &task
+	case <-session.closed:
+		reply.Exit = true
+	case <-time.After(sessionPollWait):
+	case <-r.Context().Done():
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reply)
+}
+
+// shutdown 程序退出时结束常驻辅助程序
+func (a *App) shutdown(ctx context.Context) {
+	a.StopElevatedSession()
+}
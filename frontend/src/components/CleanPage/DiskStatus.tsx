@@ -61,7 +61,7 @@ export default function DiskStatus({ diskInfo, cleanedSize = 0, showCleanedTip =
   return (
     <div>
       <div className="flex items-center justify-between mb-2">
-        <h2 className="text-base font-semibold text-gray-700">C盘空间</h2>
+        <h2 className="text-base font-semibold text-gray-700">{diskInfo.drive ? `${diskInfo.drive.slice(0, 2)} 盘空间` : 'C盘空间'}</h2>
         <span className={`text-sm font-medium ${getTextColor()}`}>
           可用 {freeGB} GB / 共 {totalGB} GB
         </span>
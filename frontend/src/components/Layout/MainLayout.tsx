@@ -1,7 +1,9 @@
 import { ReactNode, useState, useEffect } from 'react';
 import Sidebar from './Sidebar';
 import DiskStatus from '../CleanPage/DiskStatus';
-import WailsAPI from '@/utils/wails';
+import WailsAPI, { type DiskMonitorEvent } from '@/utils/wails';
+import { EventsOn, EventsOff } from '@/utils/wails-runtime';
+import { formatSize } from '@/utils/formatters';
 import type { PageType, DiskInfo } from '@/types';
 
 interface MainLayoutProps {
@@ -61,6 +63,33 @@ export default function MainLayout({
     }
   }, [showCleanedTip]);
 
+  // 剩余空间监控提醒（启动时显示最近的提醒，包括窗口最小化期间发生的）
+  const [monitorAlert, setMonitorAlert] = useState<DiskMonitorEvent | null>(null);
+
+  useEffect(() => {
+    WailsAPI.getDiskEvents().then(events => {
+      const latest = events[events.length - 1];
+      setMonitorAlert(latest && latest.type !== 'recovered' ? latest : null);
+    });
+    const unsubscribe = EventsOn('disk-monitor', (event: DiskMonitorEvent) => {
+      setMonitorAlert(event.type === 'recovered' ? null : event);
+      WailsAPI.getDiskInfo().then(setDiskInfo).catch(() => {});
+    });
+
+    return () => {
+      EventsOff('disk-monitor');
+      unsubscribe?.();
+    };
+  }, []);
+
+  const formatAlert = (event: DiskMonitorEvent) => {
+    const time = new Date(event.time).toLocaleTimeString();
+    if (event.type === 'rapid-drop') {
+      return `${time} C盘剩余空间快速下降了 ${formatSize(event.drop ?? 0)}，当前剩余 ${formatSize(event.free)}`;
+    }
+    return `${time} C盘剩余空间低于 ${formatSize(event.threshold ?? 0)}，当前剩余 ${formatSize(event.free)}`;
+  };
+
   return (
     <div className="h-screen w-screen flex flex-col overflow-hidden bg-gray-50">
       {/* 全局顶部 - 应用标题 + C盘状态栏 */}
@@ -90,6 +119,14 @@ export default function MainLayout({
             />
           </div>
         </div>
+        {monitorAlert && (
+          <div className="flex items-center justify-between bg-yellow-50 px-6 py-2 text-sm text-yellow-800">
+            <span>{formatAlert(monitorAlert)}</span>
+            <button onClick={() => setMonitorAlert(null)} className="text-yellow-700 hover:underline">
+              知道了
+            </button>
+          </div>
+        )}
       </div>
 
       {/* 主体区域 */}
@@ -2,7 +2,7 @@ import { useState, useEffect } from 'react';
 import { Lightbulb, CheckCircle, Loader2 } from 'lucide-react';
 import CleanItemList from '@/components/CleanPage/CleanItemList';
 import CleanItemDetail from '@/components/CleanPage/CleanItemDetail';
-import WailsAPI, { type ElevatedProgress } from '@/utils/wails';
+import WailsAPI, { type CleanProgress, type ScanProgress, type PendingAdminItem } from '@/utils/wails';
 import type { CleanItem, CleanPageState } from '@/types';
 import { formatSize } from '@/utils/formatters';
 import { EventsOn, EventsOff } from '@/utils/wails-runtime';
@@ -16,27 +16,24 @@ interface CleanPageProps {
 }
 
 export default function CleanPage({ isFirstVisit = true, onCleanComplete, onCleanStart, onOptimizableSpaceUpdate, onScanComplete }: CleanPageProps) {
-  // 清理项列表
-  const [cleanItems, setCleanItems] = useState<CleanItem[]>([
-    { id: '1', name: '系统临时文件', size: 0, fileCount: 0, checked: true, safe: true, status: 'idle' },
-    { id: '2', name: '浏览器缓存', size: 0, fileCount: 0, checked: true, safe: true, status: 'idle' },
-    { id: '3', name: '回收站', size: 0, fileCount: 0, checked: true, safe: true, status: 'idle' },
-    { id: '4', name: 'Windows更新缓存', size: 0, fileCount: 0, checked: true, safe: true, status: 'idle' },
-    { id: '5', name: '系统文件清理', size: 0, fileCount: 0, checked: true, safe: true, status: 'idle' },
-    { id: '6', name: '应用缓存', size: 0, fileCount: 0, checked: false, safe: false, status: 'idle' },
-    { id: '7', name: '应用日志文件', size: 0, fileCount: 0, checked: false, safe: false, status: 'idle' },
-  ]);
+  // 清理项列表（由后端清理规则决定）
+  const [cleanItems, setCleanItems] = useState<CleanItem[]>([]);
 
   // 首次访问时自动开始扫描
   useEffect(() => {
-    if (!isFirstVisit) return;
+    if (!isFirstVisit) {
+      WailsAPI.getCleanCatalog().then(setCleanItems);
+      return;
+    }
 
     const autoScan = async () => {
       // 自动开始扫描 - 使用独立线程扫描每个清理项
       setPageState('scanning');
       
       // 获取所有清理项 ID
-      const itemIDs = ['1', '2', '3', '4', '5', '6', '7'];
+      const catalogItems: CleanItem[] = await WailsAPI.getCleanCatalog();
+      setCleanItems(catalogItems);
+      const itemIDs = catalogItems.map(item => item.id);
       
       // 并发扫描所有清理项
       const scanPromises = itemIDs.map(async (itemID) => {
@@ -103,11 +100,25 @@ export default function CleanPage({ isFirstVisit = true, onCleanComplete, onClea
   }>({ isOpen: false, itemName: '', itemID: '' });
 
   // 清理进度状态
-  const [cleanProgress, setCleanProgress] = useState<ElevatedProgress | null>(null);
+  const [cleanProgress, setCleanProgress] = useState<CleanProgress | null>(null);
+
+  // 扫描进度（当前扫描的目录）
+  const [scanPath, setScanPath] = useState('');
+
+  // 监听扫描进度事件
+  useEffect(() => {
+    const unsubscribe = EventsOn('scan-progress', (progress: ScanProgress) => {
+      if (progress.scan === 'clean') {
+        setScanPath(progress.done ? '' : progress.path);
+      }
+    });
+
+    return () => unsubscribe?.();
+  }, []);
 
   // 监听清理进度事件
   useEffect(() => {
-    const unsubscribe = EventsOn('clean-progress', (progress: ElevatedProgress) => {
+    const unsubscribe = EventsOn('clean-progress', (progress: CleanProgress) => {
       setCleanProgress(progress);
     });
 
@@ -117,6 +128,39 @@ export default function CleanPage({ isFirstVisit = true, onCleanComplete, onClea
     };
   }, []);
 
+  // 自动清理时因需要管理员权限而排队的清理项（提示用户清理）
+  const [pendingAdmin, setPendingAdmin] = useState<PendingAdminItem[]>([]);
+
+  useEffect(() => {
+    WailsAPI.getPendingAdminItems().then(setPendingAdmin);
+    const unsubscribe = EventsOn('schedule-run', () => {
+      WailsAPI.getPendingAdminItems().then(setPendingAdmin);
+    });
+
+    return () => {
+      EventsOff('schedule-run');
+      unsubscribe?.();
+    };
+  }, []);
+
+  // 选中排队的清理项，由用户确认清理
+  const handleSelectPending = async () => {
+    const ids = pendingAdmin.map(item => item.itemId);
+    setCleanItems(items =>
+      items.map(item =>
+        ids.includes(item.id) ? { ...item, checked: true } : item
+      )
+    );
+    await WailsAPI.clearPendingAdminItems(ids);
+    setPendingAdmin([]);
+  };
+
+  // 忽略排队的清理项
+  const handleDismissPending = async () => {
+    await WailsAPI.clearPendingAdminItems(pendingAdmin.map(item => item.itemId));
+    setPendingAdmin([]);
+  };
+
   // 切换清理项选中状态
   const handleToggleItem = (id: string) => {
     setCleanItems(items =>
@@ -146,7 +190,7 @@ export default function CleanPage({ isFirstVisit = true, onCleanComplete, onClea
     );
     
     // 获取所有清理项 ID
-    const itemIDs = ['1', '2', '3', '4', '5', '6', '7'];
+    const itemIDs = cleanItems.map(item => item.id);
     
     // 并发扫描所有清理项
     const scanPromises = itemIDs.map(async (itemID) => {
@@ -221,9 +265,9 @@ export default function CleanPage({ isFirstVisit = true, onCleanComplete, onClea
       let totalCleanedSize = 0;
       
       // 分离需要管理员权限的项目和普通项目
-      // ID 4 (Windows更新缓存) 和 ID 5 (系统文件清理) 需要管理员权限
-      const adminItems = checkedItems.filter(item => item.id === '4' || item.id === '5');
-      const normalItems = checkedItems.filter(item => item.id !== '4' && item.id !== '5');
+      // 是否需要管理员权限由清理规则决定
+      const adminItems = checkedItems.filter(item => item.needsAdmin);
+      const normalItems = checkedItems.filter(item => !item.needsAdmin);
       
       console.log(`管理员权限项目: ${adminItems.length}个, 普通项目: ${normalItems.length}个`);
       
@@ -295,6 +339,16 @@ export default function CleanPage({ isFirstVisit = true, onCleanComplete, onClea
                 : i
             )
           );
+        } else if (result.status === 'uac-declined') {
+          // 拒绝了 UAC 提示，辅助程序没有运行，项目保持扫描结果
+          setCleanItems(prev =>
+            prev.map(i =>
+              adminItems.some(item => item.id === i.id)
+                ? { ...i, status: 'scanned' }
+                : i
+            )
+          );
+          alert('需要管理员权限才能清理此项，请在UAC弹窗中点击\"是\"');
         } else {
           const errorMsg = result.error || '清理失败，未知错误';
           console.error(`批量清理管理员项目失败:`, errorMsg);
@@ -387,6 +441,9 @@ export default function CleanPage({ isFirstVisit = true, onCleanComplete, onClea
             <div className="mb-4 flex items-center gap-2 text-primary">
               <Loader2 size={20} className="animate-spin" />
               <span className="font-medium">正在扫描...</span>
+              {scanPath && (
+                <span className="text-xs text-gray-500 truncate max-w-md" title={scanPath}>{scanPath}</span>
+              )}
             </div>
 
             <CleanItemList
@@ -423,6 +480,22 @@ export default function CleanPage({ isFirstVisit = true, onCleanComplete, onClea
               <span className="font-medium">扫描完成</span>
             </div>
 
+            {pendingAdmin.length > 0 && (
+              <div className="mb-4 flex items-center justify-between gap-3 rounded-lg bg-yellow-50 p-3 text-sm text-yellow-800">
+                <span>
+                  自动清理跳过了需要管理员权限的项目：{pendingAdmin.map(item => item.name).join('、')}
+                </span>
+                <div className="flex flex-shrink-0 items-center gap-2">
+                  <button onClick={handleSelectPending} className="btn-secondary">
+                    选中这些项目
+                  </button>
+                  <button onClick={handleDismissPending} className="text-yellow-700 hover:underline">
+                    忽略
+                  </button>
+                </div>
+              </div>
+            )}
+
             <CleanItemList
               items={cleanItems}
               onToggle={handleToggleItem}
@@ -456,7 +529,7 @@ export default function CleanPage({ isFirstVisit = true, onCleanComplete, onClea
         );
 
       case 'cleaning':
-        const progressPercent = cleanProgress 
+        const progressPercent = cleanProgress && cleanProgress.totalPaths > 0
           ? (cleanProgress.processedPaths / cleanProgress.totalPaths) * 100 
           : 0;
         
@@ -1,7 +1,8 @@
-import { useState } from 'react';
+import { useState, useEffect } from 'react';
 import { Search, Play, RefreshCw, FileSearch, Database, Download, Film, FileText, Archive, Disc, Folder, File } from 'lucide-react';
 import type { LargeFileCategory, LargeFileInfo, CategoryStats, LargeFilePageState } from '@/types';
-import WailsAPI from '@/utils/wails';
+import WailsAPI, { type ScanProgress, type Volume } from '@/utils/wails';
+import { EventsOn } from '@/utils/wails-runtime';
 import ConfirmDialog from '@/components/Common/ConfirmDialog';
 import { formatFileSize } from '@/utils/formatters';
 
@@ -26,8 +27,27 @@ export default function LargeFilePage({ onOptimizableSpaceUpdate }: LargeFilePag
   const [sortBy, setSortBy] = useState<'size' | 'name' | 'time'>('size');
   const [filterSize, setFilterSize] = useState<'10' | '100' | '500' | '1000'>('10');
   
+  // 要扫描的分区（空为系统盘）
+  const [volumes, setVolumes] = useState<Volume[]>([]);
+  const [drive, setDrive] = useState('');
+
+  useEffect(() => {
+    WailsAPI.getVolumes().then(setVolumes).catch(() => {});
+  }, []);
+
   // 扫描进度
-  const [scanProgress, setScanProgress] = useState({ scanned: 0, found: 0 });
+  const [scanProgress, setScanProgress] = useState({ scanned: 0, found: 0, size: 0, path: '' });
+
+  // 监听扫描进度事件（已找到的大文件数和大小）
+  useEffect(() => {
+    const unsubscribe = EventsOn('scan-progress', (progress: ScanProgress) => {
+      if (progress.scan === 'largefiles') {
+        setScanProgress(prev => ({ ...prev, found: progress.files, size: progress.size, path: progress.path }));
+      }
+    });
+
+    return () => unsubscribe?.();
+  }, []);
   
   // 对话框状态
   const [confirmDialog, setConfirmDialog] = useState<{
@@ -95,17 +115,17 @@ export default function LargeFilePage({ onOptimizableSpaceUpdate }: LargeFilePag
       setPageState('scanning');
       setFiles([]);
       setSelectedFiles(new Set());
-      setScanProgress({ scanned: 0, found: 0 });
+      setScanProgress({ scanned: 0, found: 0, size: 0, path: '' });
       
       // 调用后端 API 扫描大文件
-      const result = await WailsAPI.scanLargeFiles();
+      const result = await WailsAPI.scanLargeFiles('', drive);
       
       if (result && result.files) {
         setFiles(result.files);
         setCategoryStats(result.stats);
         // 更新扫描结果（从 stats 中获取总数）
         const totalFiles = result.stats.find((s: any) => s.category === 'all')?.fileCount || result.files.length;
-        setScanProgress({ scanned: totalFiles, found: result.files.length });
+        setScanProgress({ scanned: totalFiles, found: result.files.length, size: result.totalSize, path: '' });
         setPageState(result.files.length > 0 ? 'scanned' : 'empty');
 
         // 计算并更新可优化空间（所有大文件的总大小）
@@ -270,7 +290,10 @@ export default function LargeFilePage({ onOptimizableSpaceUpdate }: LargeFilePag
           </div>
           <h3 className="text-xl font-semibold text-gray-700 mb-2">正在扫描大文件...</h3>
           {scanProgress.found > 0 && (
-            <p className="text-gray-500 mt-2">已找到: {scanProgress.found} 个大文件</p>
+            <p className="text-gray-500 mt-2">已找到: {scanProgress.found} 个大文件（{formatFileSize(scanProgress.size)}）</p>
+          )}
+          {scanProgress.path && (
+            <p className="text-xs text-gray-400 mt-1 max-w-lg truncate" title={scanProgress.path}>{scanProgress.path}</p>
           )}
         </div>
       );
@@ -374,6 +397,20 @@ export default function LargeFilePage({ onOptimizableSpaceUpdate }: LargeFilePag
       <div className="bg-white border-b border-gray-200 px-6 py-4 flex items-center justify-between">
         <h2 className="text-xl font-semibold text-gray-800">大文件清理</h2>
         <div className="flex gap-2">
+          {volumes.length > 1 && (
+            <select
+              className="text-sm border border-gray-300 rounded px-3 py-1.5 cursor-pointer"
+              value={drive}
+              onChange={(e) => setDrive(e.target.value)}
+              disabled={pageState === 'scanning'}
+            >
+              {volumes.map((volume) => (
+                <option key={volume.root} value={volume.system ? '' : volume.root}>
+                  {volume.root.slice(0, 2)} {volume.label}{volume.system ? '（系统盘）' : ''} - 可用 {formatSize(volume.free)}
+                </option>
+              ))}
+            </select>
+          )}
           <button 
             className={`flex items-center gap-2 ${
               pageState === 'scanning' 
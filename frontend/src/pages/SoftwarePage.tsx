@@ -1,6 +1,7 @@
 import { useState, useEffect } from 'react';
 import { Loader2, RefreshCw, AlertTriangle } from 'lucide-react';
-import WailsAPI from '@/utils/wails';
+import WailsAPI, { type ScanProgress } from '@/utils/wails';
+import { EventsOn } from '@/utils/wails-runtime';
 import type { SoftwareInfo, SoftwarePageState } from '@/types';
 
 interface SoftwarePageProps {
@@ -14,6 +15,7 @@ export default function SoftwarePage({ isFirstVisit = true, onOptimizableSpaceUp
   const [softwareList, setSoftwareList] = useState<SoftwareInfo[]>([]);
   const [scanProgress, setScanProgress] = useState(0);
   const [estimatedTime, setEstimatedTime] = useState(0);
+  const [currentSoftware, setCurrentSoftware] = useState('');
 
   const formatSize = (bytes: number): string => {
     const gb = bytes / (1024 ** 3);
@@ -34,33 +36,25 @@ export default function SoftwarePage({ isFirstVisit = true, onOptimizableSpaceUp
     setSoftwareList([]);
     setScanProgress(0);
     setEstimatedTime(0);
+    setCurrentSoftware('');
     const start = Date.now();
     
-    // 模拟进度更新
-    const progressInterval = setInterval(() => {
-      setScanProgress(prev => {
-        if (prev >= 95) return prev;
-        return prev + Math.random() * 5;
-      });
-    }, 200);
-
-    // 更新剩余时间估算
-    const timeInterval = setInterval(() => {
-      const elapsed = (Date.now() - start) / 1000;
-      setScanProgress(prev => {
-        if (prev > 5 && prev < 95) {
-          const estimated = (elapsed / prev) * (100 - prev);
-          setEstimatedTime(estimated);
-        }
-        return prev;
-      });
-    }, 500);
+    // 按已统计的软件数更新进度和剩余时间估算
+    const unsubscribe = EventsOn('scan-progress', (progress: ScanProgress) => {
+      if (progress.scan !== 'software' || progress.total === 0) return;
+      const percent = (progress.current / progress.total) * 100;
+      setScanProgress(percent);
+      setCurrentSoftware(progress.done ? '' : progress.item);
+      if (percent > 5 && percent < 100) {
+        const elapsed = (Date.now() - start) / 1000;
+        setEstimatedTime((elapsed / percent) * (100 - percent));
+      }
+    });
     
     try {
       const software = await WailsAPI.getInstalledSoftware();
       
-      clearInterval(progressInterval);
-      clearInterval(timeInterval);
+      unsubscribe?.();
       setScanProgress(100);
       
       if (software && software.length > 0) {
@@ -78,8 +72,7 @@ export default function SoftwarePage({ isFirstVisit = true, onOptimizableSpaceUp
         onOptimizableSpaceUpdate?.(0);
       }
     } catch (error) {
-      clearInterval(progressInterval);
-      clearInterval(timeInterval);
+      unsubscribe?.();
       console.error('Failed to load software:', error);
       setPageState('empty');
     }
@@ -118,6 +111,9 @@ export default function SoftwarePage({ isFirstVisit = true, onOptimizableSpaceUp
               <p className="text-sm text-gray-600">
                 进度: <span className="font-semibold text-primary">{Math.floor(scanProgress)}%</span>
               </p>
+              {currentSoftware && (
+                <p className="text-xs text-gray-500 max-w-md truncate">{currentSoftware}</p>
+              )}
               {estimatedTime > 0 && scanProgress > 10 && scanProgress < 95 && (
                 <p className="text-xs text-gray-500">
                   预计剩余: {formatTime(estimatedTime)}
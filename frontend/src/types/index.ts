@@ -14,9 +14,12 @@ export interface CleanItem {
   fileCount: number; // 文件总数
   checked: boolean;
   safe: boolean; // 是否为安全清理项
+  needsAdmin?: boolean; // 是否需要管理员权限
   status: 'idle' | 'scanning' | 'scanned' | 'cleaning' | 'completed' | 'error';
   error?: string;
   paths?: PathDetail[]; // 详细路径信息
+  excludedSize?: number; // 受保护路径中的内容（不计入 size，清理时跳过）
+  excludedCount?: number;
 }
 
 // C盘清理页面状态
@@ -31,6 +34,7 @@ export type CleanPageState =
 
 // 磁盘信息
 export interface DiskInfo {
+  drive?: string; // 分区根目录，如 C:\
   total: number;  // 总容量（字节）
   used: number;   // 已使用（字节）
   free: number;   // 剩余空间（字节）
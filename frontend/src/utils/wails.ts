@@ -6,27 +6,63 @@ declare global {
     go: {
       main: {
         App: {
-          GetDiskInfo(): Promise<any>;
-          ScanCleanItems(): Promise<any>;
+          GetDiskInfo(drive: string): Promise<any>;
+          GetVolumes(includeAll: boolean): Promise<Volume[]>;
+          ScanCleanItems(opID: string): Promise<any>;
+          GetCleanCatalog(): Promise<any>;
           ScanSingleCleanItem(itemID: string): Promise<any>;
-          CleanItems(items: any[]): Promise<void>;
-          GetInstalledSoftware(): Promise<any>;
-          DetectWeChat(): Promise<any>;
+          CleanItems(items: any[], opts: CleanOptions): Promise<ElevatedResult>;
+          GetInstalledSoftware(opID: string): Promise<any>;
+          DetectWeChat(opID: string): Promise<any>;
           OpenWeChat(): Promise<void>;
           OpenFolder(path: string): Promise<void>;
           IsAdmin(): Promise<boolean>;
           RestartAsAdmin(): Promise<void>;
-          ScanLargeFiles(): Promise<any>;
+          ScanLargeFiles(opID: string, drive: string): Promise<any>;
           DeleteLargeFile(path: string): Promise<void>;
           OpenLargeFileLocation(path: string): Promise<void>;
           SetLargeFileMinSize(sizeInMB: number): Promise<void>;
+          GetSettings(): Promise<Settings>;
+          UpdateSettings(settings: Settings): Promise<Settings>;
           ScanSystemOptimize(): Promise<any>;
           CleanSystemOptimizeItem(itemType: string): Promise<void>;
           ScanDesktop(desktopPath: string): Promise<any>;
           DeleteDesktopFile(filePath: string): Promise<void>;
           SelectFolder(): Promise<string>;
-          CleanItemElevated(item: any): Promise<ElevatedResult>;
-          CleanItemsElevated(items: any[]): Promise<ElevatedResult>;
+          CleanItemElevated(item: any, opts: CleanOptions): Promise<ElevatedResult>;
+          CleanItemsElevated(items: any[], opts: CleanOptions): Promise<ElevatedResult>;
+          ExportCleanManifest(manifest: CleanManifest, path: string): Promise<void>;
+          ReplayCleanManifest(path: string, opts: CleanOptions): Promise<ElevatedResult>;
+          NewCleanRunID(): Promise<string>;
+          ListCleanRuns(): Promise<QuarantineRun[]>;
+          RestoreCleanRun(runID: string): Promise<ElevatedResult>;
+          DeleteCleanRun(runID: string): Promise<void>;
+          NewOperationID(): Promise<string>;
+          CancelOperation(opID: string): Promise<void>;
+          ClearSizeIndex(): Promise<void>;
+          GetProtectedPaths(): Promise<ProtectedRules>;
+          SetProtectedPaths(rules: ProtectedRules): Promise<void>;
+          StartElevatedSession(): Promise<ElevatedSessionStatus>;
+          StopElevatedSession(): Promise<void>;
+          GetElevatedSessionStatus(): Promise<ElevatedSessionStatus>;
+          GetCleanProfiles(): Promise<CleanProfileStatus[]>;
+          SaveCleanProfile(profile: CleanProfile): Promise<CleanProfile>;
+          DeleteCleanProfile(id: string): Promise<void>;
+          RunCleanProfile(id: string, opID: string): Promise<ScheduleRun>;
+          GetScheduleHistory(profileID: string): Promise<ScheduleRun[]>;
+          GetPendingAdminItems(): Promise<PendingAdminItem[]>;
+          ClearPendingAdminItems(itemIDs: string[]): Promise<void>;
+          GetDiskMonitorConfig(): Promise<DiskMonitorConfig>;
+          SetDiskMonitorConfig(config: DiskMonitorConfig): Promise<void>;
+          GetDiskSamples(since: string): Promise<DiskSample[]>;
+          GetDiskEvents(since: string): Promise<DiskMonitorEvent[]>;
+          GetHistory(kind: string, itemID: string, since: string, limit: number): Promise<HistoryRecord[]>;
+          GetHistoryStats(since: string, until: string): Promise<HistorySummary>;
+          PruneHistory(before: string): Promise<number>;
+          GetDiskForecast(days: number, top: number): Promise<DiskForecast>;
+          TakeDiskSnapshot(opID: string): Promise<FolderSnapshot>;
+          ExportReport(format: string, path: string): Promise<string[]>;
+          SelectReportPath(format: string): Promise<string>;
         };
       };
     };
@@ -37,12 +73,324 @@ declare global {
 export interface ElevatedResult {
   success: boolean;
   error?: string;
+  errorDetail?: ErrorDetail; // 错误码和参数（error 为其提示文字）
   cleanedSize: number;
   cleanedCount: number;
+  manifest?: CleanManifest;
+  runId?: string;
+  report?: CleanReport;
+  status?: 'cancelled' | 'uac-declined'; // 操作被取消，结果只包含已完成的部分；或拒绝了 UAC 提示
 }
 
-// ElevatedProgress 提升权限执行进度
-export interface ElevatedProgress {
+// ProtectedRules 用户设置的受保护路径（不会被清理或删除）
+export interface ProtectedRules {
+  paths: string[];      // 目录或文件，支持 %LOCALAPPDATA% 等环境变量
+  globs: string[];      // 通配符，匹配文件名或目录名；含路径分隔符时匹配完整路径
+  extensions: string[]; // 扩展名，如 ".psd"
+}
+
+// ElevatedSessionStatus 常驻辅助程序会话状态（一次 UAC 执行多个管理员任务）
+export interface ElevatedSessionStatus {
+  active: boolean;
+  busy: boolean;
+  task?: string;      // 正在执行的任务 ID
+  tasks: number;      // 已交给会话的任务数
+  lastSeen?: string;  // 最后一次心跳时间
+  startedAt?: string;
+}
+
+// CleanFilter 清理项的过滤条件
+export interface CleanFilter {
+  minAge?: string;            // 只处理早于该时长的文件，如 "24h"
+  ageBy?: 'mtime' | 'atime';
+  include?: string[];
+  exclude?: string[];
+  maxDepth?: number;
+}
+
+// CleanProfile 自动清理方案：按计划或剩余空间不足时运行
+export interface CleanProfile {
+  id: string;                            // 为空时新建
+  name: string;
+  enabled: boolean;
+  items: string[];                       // 清理项 ID
+  filters?: Record<string, CleanFilter>; // 替换清理项的过滤条件
+  dryRun?: boolean;
+  quarantine?: boolean;
+  schedule?: string;                     // 如 "0 3 * * 0" 或 "@daily"
+  freeBelow?: number;                    // 系统盘剩余空间低于该值（字节）时运行
+  adminItems?: 'skip' | 'queue';         // 需要管理员权限的清理项：跳过或排队到下次打开程序
+}
+
+// CleanProfileStatus 方案及其运行状态
+export interface CleanProfileStatus extends CleanProfile {
+  lastRun?: string;
+  lastStatus?: string;
+  nextRun?: string;
+}
+
+// ScheduleRun 自动清理的一次运行记录
+export interface ScheduleRun {
+  id: string;
+  profileId: string;
+  profileName: string;
+  trigger: 'schedule' | 'threshold' | 'manual';
+  startedAt: string;
+  finishedAt: string;
+  status: 'completed' | 'partial' | 'failed' | 'cancelled';
+  error?: string;
+  dryRun?: boolean;
+  freeBefore?: number;
+  freeAfter?: number;
+  freedSize: number;
+  removedCount: number;
+  skippedCount: number;
+  plannedSize?: number;
+  plannedCount?: number;
+  manifest?: string;      // 试运行的删除清单文件
+  quarantineRun?: string; // 隔离批次 ID
+  items: { id: string; name: string; status: string; error?: string; size: number }[];
+}
+
+// PendingAdminItem 自动清理时因需要管理员权限而排队的清理项
+export interface PendingAdminItem {
+  itemId: string;
+  name: string;
+  profileId: string;
+  queuedAt: string;
+}
+
+// Volume 分区（type 为 removable / network / cdrom 的分区默认不列出）
+export interface Volume {
+  root: string;       // 如 D:\
+  label: string;
+  fileSystem: string;
+  type: 'fixed' | 'removable' | 'network' | 'cdrom' | 'ramdisk' | 'unknown';
+  total: number;
+  free: number;
+  system: boolean;    // Windows 所在分区
+}
+
+// DiskMonitorConfig 剩余空间监控设置
+export interface DiskMonitorConfig {
+  enabled: boolean;
+  intervalSeconds: number; // 采样间隔
+  thresholds: number[];    // 剩余空间阈值（字节）
+  dropBytes: number;       // 在 dropMinutes 内下降超过该值时提醒，0 表示不检查
+  dropMinutes: number;
+}
+
+// DiskSample 剩余空间采样
+export interface DiskSample {
+  time: string;
+  total: number;
+  free: number;
+}
+
+// DiskMonitorEvent 剩余空间监控事件（disk-monitor 事件）
+export interface DiskMonitorEvent {
+  type: 'below' | 'recovered' | 'rapid-drop';
+  time: string;
+  free: number;
+  threshold?: number; // below / recovered：跨过的阈值
+  drop?: number;      // rapid-drop：下降的大小
+  since?: string;     // rapid-drop：从何时开始下降
+}
+
+// HistoryItem 扫描或清理记录中的清理项
+export interface HistoryItem {
+  id: string;
+  name: string;
+  status?: string;
+  error?: string;
+  size: number;     // 扫描：扫描到的大小；清理：释放的大小
+  files: number;    // 扫描：文件数；清理：删除的文件数
+  failed?: number;  // 清理：未能清理的文件数
+}
+
+// HistoryRecord 一次扫描或清理
+export interface HistoryRecord {
+  id: string;
+  kind: 'scan' | 'clean';
+  source: 'app' | 'cli' | 'schedule';
+  startedAt: string;
+  durationMs: number;
+  elevated: boolean;
+  status: 'completed' | 'partial' | 'failed' | 'cancelled';
+  error?: string;
+  freeBefore?: number; // 开始前系统盘剩余空间
+  freeAfter?: number;  // 结束后系统盘剩余空间
+  items: HistoryItem[];
+  freedSize?: number;
+  removedCount?: number;
+  skippedSize?: number;
+  skippedCount?: number;
+  skippedReasons?: Partial<Record<SkipReason, number>>;
+  paths?: PathReport[];
+  quarantineRun?: string;
+}
+
+// HistoryItemStats 清理项的汇总
+export interface HistoryItemStats {
+  id: string;
+  name: string;
+  scans: number;
+  cleans: number;
+  errors: number;
+  freedSize: number;
+  removedCount: number;
+  failedCount: number;
+  lastScanSize: number;
+  lastCleaned?: string;
+  growthPerDay: number; // 清理后重新增长的速度（字节/天）
+}
+
+// HistorySummary 清理记录汇总
+export interface HistorySummary {
+  scans: number;
+  cleans: number;
+  freedSize: number;
+  removedCount: number;
+  skippedCount: number;
+  items: HistoryItemStats[]; // 按释放的空间从大到小排列
+}
+
+// TrendPoint 已用空间采样（每小时一个）
+export interface TrendPoint {
+  time: string;
+  total: number;
+  used: number;
+}
+
+// FolderSnapshot 系统盘目录大小快照（第 2 级及以上目录）
+export interface FolderSnapshot {
+  time: string;
+  root: string;
+  folders: Record<string, number>;
+}
+
+// FolderGrowth 目录在两次快照之间的增长
+export interface FolderGrowth {
+  path: string;
+  before: number;
+  after: number;
+  growth: number;
+}
+
+// DiskForecast 已用空间趋势和写满预测
+export interface DiskForecast {
+  points: TrendPoint[];
+  daily: { date: string; used: number; change: number }[];
+  trend: TrendPoint[];          // 趋势线（拟合值），采样不足时为空
+  growthPerDay: number;         // 字节/天，负数表示已用空间在减少
+  growthPerWeek: number;
+  recentGrowthPerDay: number;   // 最近 7 天
+  r2: number;                   // 拟合优度
+  fullAt?: string;              // 预计写满的时间
+  daysLeft?: number;
+  folders: FolderGrowth[];      // 增长最多的目录
+  snapshotFrom?: string;
+  snapshotTo?: string;
+}
+
+// Settings 用户设置（UpdateSettings 替换全部设置，先用 getSettings 获取再修改）
+export interface Settings {
+  version: number;
+  largeFiles: {
+    minSize: number;                                                                // 最小文件大小（字节，至少 1 MB）
+    categories?: Partial<Record<'media' | 'document' | 'archive' | 'installer' | 'other', number>>; // 各分类单独的最小大小
+  };
+  enabledItems: string[] | null;         // 默认选中的清理项，null 时使用清理规则中的推荐项
+  filters?: Record<string, CleanFilter>; // 替换清理项的过滤条件
+  helperMode: 'on-demand' | 'session';   // 每个任务单独启动辅助程序，或第一个任务启动常驻辅助程序
+  locale: 'zh-CN' | 'en-US';             // 错误等提示文字的语言
+  exclusions: ProtectedRules;            // 受保护路径
+  profiles: CleanProfile[] | null;       // 自动清理方案，更新时为 null 表示不修改
+}
+
+// SkipReason 文件未能清理的原因（excluded 为受保护路径）
+export type SkipReason = 'in-use' | 'access-denied' | 'protected' | 'vanished' | 'changed' | 'failed' | 'excluded' | 'quarantine';
+
+// SkippedFile 未能清理的文件
+export interface SkippedFile {
+  path: string;
+  size: number;
+  reason: SkipReason;
+  error?: string;
+}
+
+// PathReport 单个清理路径的结果
+export interface PathReport {
+  path: string;
+  itemId?: string;
+  freedSize: number;
+  removedCount: number;
+  skippedSize: number;
+  skippedCount: number;
+}
+
+// CleanReport 清理报告（只统计实际删除的文件）
+export interface CleanReport {
+  freedSize: number;
+  removedCount: number;
+  skippedSize: number;
+  skippedCount: number;
+  skippedReasons: Partial<Record<SkipReason, number>>;
+  skipped: SkippedFile[];
+  paths: PathReport[];
+}
+
+// CleanOptions 清理选项
+export interface CleanOptions {
+  dryRun: boolean;
+  quarantine?: boolean; // 移入隔离区而不是直接删除
+  runId?: string;       // 隔离批次 ID，同一次清理的多个调用共用
+  opId?: string;        // 操作 ID，可用 cancelOperation 取消
+}
+
+// QuarantineRun 隔离区中的清理批次
+export interface QuarantineRun {
+  runId: string;
+  createdAt: string;
+  expiresAt: string;
+  size: number;
+  fileCount: number;
+}
+
+// ManifestEntry 删除清单条目
+export interface ManifestEntry {
+  path: string;
+  size: number;
+  modTime: string;
+  itemId: string;
+  root: string;
+  reason: string;
+}
+
+// CleanManifest 删除清单（试运行结果）
+export interface CleanManifest {
+  version: number;
+  createdAt: string;
+  entries: ManifestEntry[];
+  totalSize: number;
+  totalFiles: number;
+}
+
+// ScanProgress 扫描进度（scan-progress 事件，限频发送）
+export interface ScanProgress {
+  scan: 'clean' | 'largefiles' | 'software';
+  current: number;      // 已完成的项目数
+  total: number;        // 项目总数（未知时为 0）
+  item: string;         // 当前项目
+  path: string;         // 当前目录
+  size: number;         // 已统计的大小
+  files: number;        // 已统计的文件数
+  done: boolean;        // 扫描结束
+}
+
+// CleanProgress 清理进度（clean-progress 事件，应用内清理和辅助程序清理相同）
+export interface CleanProgress {
+  item?: string;        // 当前清理项 ID
   processedPaths: number;
   totalPaths: number;
   cleanedSize: number;
@@ -55,15 +403,70 @@ export const isWailsEnv = () => {
   return typeof window !== 'undefined' && window.go !== undefined;
 };
 
+// ErrorSeverity 错误的严重程度（info 不是故障，如用户取消；warning 为部分完成或需要用户处理）
+export type ErrorSeverity = 'info' | 'warning' | 'error';
+
+// ErrorDetail 后端返回的结构化错误，message 为按用户设置的语言生成的提示文字
+export interface ErrorDetail {
+  code: string;                     // 错误码，如 "uac-declined"、"unknown-item"，见 DEVELOPMENT.md
+  severity: ErrorSeverity;
+  params?: Record<string, unknown>; // 提示文字中的参数，如 { id: "3" }
+  message: string;
+  cause?: string;                   // 原始错误
+}
+
+// AppError 后端方法失败时抛出的错误（按 code 处理，显示 message）
+export class AppError extends Error {
+  code: string;
+  severity: ErrorSeverity;
+  params: Record<string, unknown>;
+  cause?: string;
+
+  constructor(detail: ErrorDetail) {
+    super(detail.message);
+    this.name = 'AppError';
+    this.code = detail.code;
+    this.severity = detail.severity;
+    this.params = detail.params ?? {};
+    this.cause = detail.cause;
+  }
+}
+
+// toAppError 把后端的错误对象转换为 AppError，其他错误原样返回
+const toAppError = (error: unknown) => {
+  if (error && typeof error === 'object' && 'code' in error && 'message' in error) {
+    return new AppError(error as ErrorDetail);
+  }
+  return error;
+};
+
+// app 后端方法，失败时抛出 AppError
+let appProxy: Window['go']['main']['App'] | undefined;
+const app = () => {
+  appProxy ??= new Proxy(window.go.main.App, {
+    get(target, name) {
+      const method = Reflect.get(target, name);
+      if (typeof method !== 'function') {
+        return method;
+      }
+      return (...args: unknown[]) => method(...args).catch((error: unknown) => {
+        throw toAppError(error);
+      });
+    },
+  });
+  return appProxy;
+};
+
 // API 包装器
 export const WailsAPI = {
-  // 获取磁盘信息
-  getDiskInfo: async () => {
+  // 获取分区信息，drive 为空时为系统盘
+  getDiskInfo: async (drive: string = '') => {
     if (isWailsEnv()) {
-      return await window.go.main.App.GetDiskInfo();
+      return await app().GetDiskInfo(drive);
     }
     // 开发环境返回模拟数据
     return {
+      drive: 'C:\\',
       total: 300 * 1024 ** 3,
       used: 195 * 1024 ** 3,
       free: 105 * 1024 ** 3,
@@ -71,9 +474,9 @@ export const WailsAPI = {
   },
 
   // 扫描清理项
-  scanCleanItems: async () => {
+  scanCleanItems: async (opID: string = '') => {
     if (isWailsEnv()) {
-      return await window.go.main.App.ScanCleanItems();
+      return await app().ScanCleanItems(opID);
     }
     // 开发环境返回模拟数据
     return [
@@ -87,10 +490,27 @@ export const WailsAPI = {
     ];
   },
 
+  // 获取清理项目录
+  getCleanCatalog: async () => {
+    if (isWailsEnv()) {
+      return await app().GetCleanCatalog();
+    }
+    // 开发环境返回模拟数据
+    return [
+      { id: '1', name: '系统临时文件', size: 0, fileCount: 0, checked: true, safe: true, needsAdmin: false, status: 'idle' },
+      { id: '2', name: '浏览器缓存', size: 0, fileCount: 0, checked: true, safe: true, needsAdmin: false, status: 'idle' },
+      { id: '3', name: '回收站', size: 0, fileCount: 0, checked: true, safe: true, needsAdmin: false, status: 'idle' },
+      { id: '4', name: 'Windows更新缓存', size: 0, fileCount: 0, checked: true, safe: true, needsAdmin: true, status: 'idle' },
+      { id: '5', name: '系统文件清理', size: 0, fileCount: 0, checked: true, safe: true, needsAdmin: true, status: 'idle' },
+      { id: '6', name: '应用缓存', size: 0, fileCount: 0, checked: false, safe: false, needsAdmin: false, status: 'idle' },
+      { id: '7', name: '应用日志文件', size: 0, fileCount: 0, checked: false, safe: false, needsAdmin: false, status: 'idle' },
+    ];
+  },
+
   // 扫描单个清理项
   scanSingleCleanItem: async (itemID: string) => {
     if (isWailsEnv()) {
-      return await window.go.main.App.ScanSingleCleanItem(itemID);
+      return await app().ScanSingleCleanItem(itemID);
     }
     // 开发环境模拟延迟（模拟真实扫描时间）
     await new Promise(resolve => setTimeout(resolve, 1000 + Math.random() * 2000));
@@ -110,18 +530,19 @@ export const WailsAPI = {
   },
 
   // 清理项目
-  cleanItems: async (items: any[]) => {
+  cleanItems: async (items: any[], opts: CleanOptions = { dryRun: false }): Promise<ElevatedResult> => {
     if (isWailsEnv()) {
-      return await window.go.main.App.CleanItems(items);
+      return await app().CleanItems(items, opts);
     }
     // 开发环境模拟延迟
     await new Promise(resolve => setTimeout(resolve, 2000));
+    return { success: true, cleanedSize: 0, cleanedCount: 0 };
   },
 
   // 获取已安装软件
-  getInstalledSoftware: async () => {
+  getInstalledSoftware: async (opID: string = '') => {
     if (isWailsEnv()) {
-      return await window.go.main.App.GetInstalledSoftware();
+      return await app().GetInstalledSoftware(opID);
     }
     // 开发环境返回模拟数据（只显示C盘软件）
     return [
@@ -134,9 +555,9 @@ export const WailsAPI = {
   },
 
   // 检测微信
-  detectWeChat: async () => {
+  detectWeChat: async (opID: string = '') => {
     if (isWailsEnv()) {
-      return await window.go.main.App.DetectWeChat();
+      return await app().DetectWeChat(opID);
     }
     // 开发环境返回模拟数据
     return {
@@ -153,7 +574,7 @@ export const WailsAPI = {
   // 打开微信
   openWeChat: async () => {
     if (isWailsEnv()) {
-      return await window.go.main.App.OpenWeChat();
+      return await app().OpenWeChat();
     }
     // 开发环境模拟
     console.log('Opening WeChat...');
@@ -162,7 +583,7 @@ export const WailsAPI = {
   // 打开文件夹
   openFolder: async (path: string) => {
     if (isWailsEnv()) {
-      return await window.go.main.App.OpenFolder(path);
+      return await app().OpenFolder(path);
     }
     // 开发环境模拟
     console.log('Opening folder:', path);
@@ -171,7 +592,7 @@ export const WailsAPI = {
   // 检查是否有管理员权限
   isAdmin: async (): Promise<boolean> => {
     if (isWailsEnv()) {
-      return await window.go.main.App.IsAdmin();
+      return await app().IsAdmin();
     }
     // 开发环境模拟（返回 false）
     return false;
@@ -180,17 +601,17 @@ export const WailsAPI = {
   // 以管理员身份重启应用程序
   restartAsAdmin: async (): Promise<void> => {
     if (isWailsEnv()) {
-      return await window.go.main.App.RestartAsAdmin();
+      return await app().RestartAsAdmin();
     }
     // 开发环境模拟
     console.log('Restarting as admin...');
     alert('开发环境：模拟以管理员身份重启');
   },
 
-  // 扫描C盘大文件
-  scanLargeFiles: async () => {
+  // 扫描分区中的大文件，drive 为空时扫描系统盘
+  scanLargeFiles: async (opID: string = '', drive: string = '') => {
     if (isWailsEnv()) {
-      return await window.go.main.App.ScanLargeFiles();
+      return await app().ScanLargeFiles(opID, drive);
     }
     // 开发环境返回模拟数据
     return {
@@ -210,7 +631,7 @@ export const WailsAPI = {
   // 删除大文件
   deleteLargeFile: async (path: string) => {
     if (isWailsEnv()) {
-      return await window.go.main.App.DeleteLargeFile(path);
+      return await app().DeleteLargeFile(path);
     }
     // 开发环境模拟
     console.log('Deleting file:', path);
@@ -219,25 +640,41 @@ export const WailsAPI = {
   // 打开大文件位置
   openLargeFileLocation: async (path: string) => {
     if (isWailsEnv()) {
-      return await window.go.main.App.OpenLargeFileLocation(path);
+      return await app().OpenLargeFileLocation(path);
     }
     // 开发环境模拟
     console.log('Opening file location:', path);
   },
 
-  // 设置大文件最小大小
+  // 设置并保存大文件最小大小
   setLargeFileMinSize: async (sizeInMB: number) => {
     if (isWailsEnv()) {
-      return await window.go.main.App.SetLargeFileMinSize(sizeInMB);
+      return await app().SetLargeFileMinSize(sizeInMB);
     }
     // 开发环境模拟
     console.log('Setting min size:', sizeInMB, 'MB');
   },
 
+  // 获取全部用户设置
+  getSettings: async (): Promise<Settings | null> => {
+    if (isWailsEnv()) {
+      return await app().GetSettings();
+    }
+    return null;
+  },
+
+  // 保存全部用户设置，立即生效，返回保存后的设置
+  updateSettings: async (settings: Settings): Promise<Settings> => {
+    if (isWailsEnv()) {
+      return await app().UpdateSettings(settings);
+    }
+    return settings;
+  },
+
   // 扫描系统优化项
   scanSystemOptimize: async () => {
     if (isWailsEnv()) {
-      return await window.go.main.App.ScanSystemOptimize();
+      return await app().ScanSystemOptimize();
     }
     // 开发环境返回模拟数据
     return {
@@ -277,7 +714,7 @@ export const WailsAPI = {
   // 清理系统优化项
   cleanSystemOptimizeItem: async (itemType: string) => {
     if (isWailsEnv()) {
-      return await window.go.main.App.CleanSystemOptimizeItem(itemType);
+      return await app().CleanSystemOptimizeItem(itemType);
     }
     // 开发环境模拟
     console.log('Cleaning system optimize item:', itemType);
@@ -286,7 +723,7 @@ export const WailsAPI = {
   // 扫描桌面文件
   scanDesktop: async (desktopPath?: string) => {
     if (isWailsEnv()) {
-      return await window.go.main.App.ScanDesktop(desktopPath || "");
+      return await app().ScanDesktop(desktopPath || "");
     }
     // 开发环境返回模拟数据
     return [
@@ -320,7 +757,7 @@ export const WailsAPI = {
   // 删除桌面文件
   deleteDesktopFile: async (filePath: string) => {
     if (isWailsEnv()) {
-      return await window.go.main.App.DeleteDesktopFile(filePath);
+      return await app().DeleteDesktopFile(filePath);
     }
     // 开发环境模拟
     console.log('Deleting desktop file:', filePath);
@@ -329,16 +766,16 @@ export const WailsAPI = {
   // 选择文件夹
   selectFolder: async () => {
     if (isWailsEnv()) {
-      return await window.go.main.App.SelectFolder();
+      return await app().SelectFolder();
     }
     // 开发环境返回模拟路径（使用当前用户的桌面）
     return 'C:\\Users\\User\\Desktop';
   },
 
   // 以管理员权限清理项目
-  cleanItemElevated: async (item: any): Promise<ElevatedResult> => {
+  cleanItemElevated: async (item: any, opts: CleanOptions = { dryRun: false }): Promise<ElevatedResult> => {
     if (isWailsEnv()) {
-      return await window.go.main.App.CleanItemElevated(item);
+      return await app().CleanItemElevated(item, opts);
     }
     // 开发环境模拟
     return {
@@ -349,9 +786,9 @@ export const WailsAPI = {
   },
 
   // 批量以管理员权限清理多个项目（单次UAC提示）
-  cleanItemsElevated: async (items: any[]): Promise<ElevatedResult> => {
+  cleanItemsElevated: async (items: any[], opts: CleanOptions = { dryRun: false }): Promise<ElevatedResult> => {
     if (isWailsEnv()) {
-      return await window.go.main.App.CleanItemsElevated(items);
+      return await app().CleanItemsElevated(items, opts);
     }
     // 开发环境模拟
     const totalSize = items.reduce((sum, item) => sum + (item.size || 0), 0);
@@ -362,6 +799,266 @@ export const WailsAPI = {
       cleanedCount: totalCount || 100,
     };
   },
+
+  // 导出删除清单
+  exportCleanManifest: async (manifest: CleanManifest, path: string) => {
+    if (isWailsEnv()) {
+      return await app().ExportCleanManifest(manifest, path);
+    }
+  },
+
+  // 按删除清单重放清理
+  replayCleanManifest: async (path: string, opts: CleanOptions = { dryRun: false }): Promise<ElevatedResult> => {
+    if (isWailsEnv()) {
+      return await app().ReplayCleanManifest(path, opts);
+    }
+    // 开发环境模拟
+    return { success: true, cleanedSize: 0, cleanedCount: 0 };
+  },
+
+  // 生成清理批次 ID（隔离模式）
+  newCleanRunID: async (): Promise<string> => {
+    if (isWailsEnv()) {
+      return await app().NewCleanRunID();
+    }
+    return `dev-${Date.now()}`;
+  },
+
+  // 列出隔离区中的清理批次
+  listCleanRuns: async (): Promise<QuarantineRun[]> => {
+    if (isWailsEnv()) {
+      return await app().ListCleanRuns();
+    }
+    return [];
+  },
+
+  // 恢复清理批次
+  restoreCleanRun: async (runID: string): Promise<ElevatedResult> => {
+    if (isWailsEnv()) {
+      return await app().RestoreCleanRun(runID);
+    }
+    // 开发环境模拟
+    return { success: true, cleanedSize: 0, cleanedCount: 0, runId: runID };
+  },
+
+  // 永久删除清理批次
+  deleteCleanRun: async (runID: string) => {
+    if (isWailsEnv()) {
+      return await app().DeleteCleanRun(runID);
+    }
+  },
+
+  // 生成操作 ID（传给扫描或清理方法后可取消）
+  newOperationID: async (): Promise<string> => {
+    if (isWailsEnv()) {
+      return await app().NewOperationID();
+    }
+    return `op-${Date.now()}`;
+  },
+
+  // 取消正在执行的扫描或清理（操作会返回已完成的部分结果）
+  cancelOperation: async (opID: string) => {
+    if (isWailsEnv()) {
+      return await app().CancelOperation(opID);
+    }
+  },
+
+  // 清空目录大小索引，下次扫描完整重新遍历
+  clearSizeIndex: async () => {
+    if (isWailsEnv()) {
+      return await app().ClearSizeIndex();
+    }
+  },
+
+  // 获取受保护路径
+  getProtectedPaths: async (): Promise<ProtectedRules> => {
+    if (isWailsEnv()) {
+      return await app().GetProtectedPaths();
+    }
+    return { paths: [], globs: [], extensions: [] };
+  },
+
+  // 保存受保护路径（之后需要重新扫描）
+  setProtectedPaths: async (rules: ProtectedRules) => {
+    if (isWailsEnv()) {
+      return await app().SetProtectedPaths(rules);
+    }
+  },
+
+  // 启动常驻辅助程序（弹出一次 UAC），之后的管理员任务不再逐个弹出 UAC
+  startElevatedSession: async (): Promise<ElevatedSessionStatus> => {
+    if (isWailsEnv()) {
+      return await app().StartElevatedSession();
+    }
+    return { active: false, busy: false, tasks: 0 };
+  },
+
+  // 结束常驻辅助程序
+  stopElevatedSession: async () => {
+    if (isWailsEnv()) {
+      return await app().StopElevatedSession();
+    }
+  },
+
+  // 获取常驻辅助程序状态
+  getElevatedSessionStatus: async (): Promise<ElevatedSessionStatus> => {
+    if (isWailsEnv()) {
+      return await app().GetElevatedSessionStatus();
+    }
+    return { active: false, busy: false, tasks: 0 };
+  },
+
+  // 获取自动清理方案
+  getCleanProfiles: async (): Promise<CleanProfileStatus[]> => {
+    if (isWailsEnv()) {
+      return await app().GetCleanProfiles();
+    }
+    return [];
+  },
+
+  // 保存自动清理方案（id 为空时新建）
+  saveCleanProfile: async (profile: CleanProfile): Promise<CleanProfile> => {
+    if (isWailsEnv()) {
+      return await app().SaveCleanProfile(profile);
+    }
+    return profile;
+  },
+
+  // 删除自动清理方案
+  deleteCleanProfile: async (id: string) => {
+    if (isWailsEnv()) {
+      return await app().DeleteCleanProfile(id);
+    }
+  },
+
+  // 立即运行自动清理方案
+  runCleanProfile: async (id: string, opID: string = ''): Promise<ScheduleRun | null> => {
+    if (isWailsEnv()) {
+      return await app().RunCleanProfile(id, opID);
+    }
+    return null;
+  },
+
+  // 获取自动清理的运行记录（最新的在前）
+  getScheduleHistory: async (profileID: string = ''): Promise<ScheduleRun[]> => {
+    if (isWailsEnv()) {
+      return await app().GetScheduleHistory(profileID);
+    }
+    return [];
+  },
+
+  // 获取排队等待清理的管理员清理项
+  getPendingAdminItems: async (): Promise<PendingAdminItem[]> => {
+    if (isWailsEnv()) {
+      return await app().GetPendingAdminItems();
+    }
+    return [];
+  },
+
+  // 从队列中移除清理项（为空时清空）
+  clearPendingAdminItems: async (itemIDs: string[] = []) => {
+    if (isWailsEnv()) {
+      return await app().ClearPendingAdminItems(itemIDs);
+    }
+  },
+
+  // 获取剩余空间监控设置
+  getDiskMonitorConfig: async (): Promise<DiskMonitorConfig | null> => {
+    if (isWailsEnv()) {
+      return await app().GetDiskMonitorConfig();
+    }
+    return null;
+  },
+
+  // 保存剩余空间监控设置
+  setDiskMonitorConfig: async (config: DiskMonitorConfig) => {
+    if (isWailsEnv()) {
+      return await app().SetDiskMonitorConfig(config);
+    }
+  },
+
+  // 获取最近 24 小时的剩余空间采样（since 为空时返回全部）
+  getDiskSamples: async (since: string = ''): Promise<DiskSample[]> => {
+    if (isWailsEnv()) {
+      return await app().GetDiskSamples(since);
+    }
+    return [];
+  },
+
+  // 获取剩余空间监控事件（since 为空时返回全部）
+  getDiskEvents: async (since: string = ''): Promise<DiskMonitorEvent[]> => {
+    if (isWailsEnv()) {
+      return await app().GetDiskEvents(since);
+    }
+    return [];
+  },
+
+  // 获取扫描和清理记录（最新的在前），since 为 ISO 时间
+  getHistory: async (kind: '' | 'scan' | 'clean' = '', itemID: string = '', since: string = '', limit: number = 0): Promise<HistoryRecord[]> => {
+    if (isWailsEnv()) {
+      return await app().GetHistory(kind, itemID, since, limit);
+    }
+    return [];
+  },
+
+  // 按清理项汇总 [since, until) 之间的记录
+  getHistoryStats: async (since: string = '', until: string = ''): Promise<HistorySummary | null> => {
+    if (isWailsEnv()) {
+      return await app().GetHistoryStats(since, until);
+    }
+    return null;
+  },
+
+  // 删除 before 之前的记录，返回删除的数量
+  pruneHistory: async (before: string): Promise<number> => {
+    if (isWailsEnv()) {
+      return await app().PruneHistory(before);
+    }
+    return 0;
+  },
+
+  // 分析最近 days 天的已用空间趋势，预测写满时间，返回增长最多的 top 个目录
+  getDiskForecast: async (days: number = 30, top: number = 10): Promise<DiskForecast | null> => {
+    if (isWailsEnv()) {
+      return await app().GetDiskForecast(days, top);
+    }
+    return null;
+  },
+
+  // 立即生成系统盘的目录大小快照
+  takeDiskSnapshot: async (opID: string = ''): Promise<FolderSnapshot | null> => {
+    if (isWailsEnv()) {
+      return await app().TakeDiskSnapshot(opID);
+    }
+    return null;
+  },
+
+  // 把最近一次各项扫描的结果导出为报告（json、csv 或 html），返回写入的文件
+  exportReport: async (format: 'json' | 'csv' | 'html', path: string): Promise<string[]> => {
+    if (isWailsEnv()) {
+      return await app().ExportReport(format, path);
+    }
+    return [];
+  },
+
+  // 选择报告的保存位置，用户取消时返回空字符串
+  selectReportPath: async (format: 'json' | 'csv' | 'html'): Promise<string> => {
+    if (isWailsEnv()) {
+      return await app().SelectReportPath(format);
+    }
+    return '';
+  },
+
+  // 列出分区，includeAll 为 false 时不包括可移动磁盘和网络驱动器
+  getVolumes: async (includeAll: boolean = false): Promise<Volume[]> => {
+    if (isWailsEnv()) {
+      return await app().GetVolumes(includeAll);
+    }
+    // 开发环境返回模拟数据
+    return [
+      { root: 'C:\\', label: '', fileSystem: 'NTFS', type: 'fixed', total: 300 * 1024 ** 3, free: 105 * 1024 ** 3, system: true },
+    ];
+  },
 };
 
 export default WailsAPI;
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"ccooler/backend/apperr"
+	"ccooler/backend/cli"
 	"embed"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -13,6 +16,11 @@ import (
 var assets embed.FS
 
 func main() {
+	// 命令行模式：ccooler scan、ccooler clean 等，不创建窗口
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(runCLI(os.Args[1:]))
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 
@@ -32,6 +40,9 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 249, G: 250, B: 251, A: 1},
 		OnStartup:        app.startup,
+		OnShutdown:       app.shutdown,
+		// 方法返回的错误以错误码、参数和当前语言的提示文字传给前端
+		ErrorFormatter: func(err error) any { return apperr.From(err) },
 		Bind: []interface{}{
 			app,
 		},